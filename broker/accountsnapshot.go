@@ -0,0 +1,52 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import "github.com/shopspring/decimal"
+
+// AccountSnapshot is a record of the account state at a point in time.
+type AccountSnapshot struct {
+
+	// Cash is the realized trade balance.
+	Cash decimal.Decimal `csv:"cash"`
+
+	// Equity is the cash balance plus unrealized PNL.
+	Equity decimal.Decimal `csv:"equity"`
+
+	// UnrealizedPNL is the PNL of open positions marked to the latest price.
+	UnrealizedPNL decimal.Decimal `csv:"unrealized_pnl"`
+
+	// GrossExposure is the sum of the absolute notional value of open positions.
+	GrossExposure decimal.Decimal `csv:"gross_exposure"`
+
+	// NetExposure is the notional value of long positions less short positions.
+	NetExposure decimal.Decimal `csv:"net_exposure"`
+
+	// Leverage is gross exposure divided by equity. Zero when equity is not positive.
+	Leverage float64 `csv:"leverage"`
+
+	// OpenPositionCount is the number of open positions.
+	OpenPositionCount int `csv:"open_position_count"`
+
+	// CumFees is the cumulative transaction fees and funding charges paid.
+	CumFees decimal.Decimal `csv:"cum_fees"`
+}
+
+// AccountSeries is a time series of account snapshots.
+type AccountSeries TimeSeries[AccountSnapshot]
+
+// SortKeys returns a sorted slice of keys in ascending chronological order.
+func (as AccountSeries) SortKeys() []Timestamp {
+	return TimeSeries[AccountSnapshot](as).SortKeys()
+}
+
+// SortValuesByTime returns a sorted slice of values in ascending chronological order.
+func (as AccountSeries) SortValuesByTime() []AccountSnapshot {
+	return TimeSeries[AccountSnapshot](as).SortValuesByTime()
+}
+
+// Sorted returns an immutable copy of the series in ascending chronological order.
+func (as AccountSeries) Sorted() SortedSeries[AccountSnapshot] {
+	return TimeSeries[AccountSnapshot](as).Sorted()
+}
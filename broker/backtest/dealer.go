@@ -10,6 +10,7 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/options"
 	"github.com/thecolngroup/alphakit/web"
 )
 
@@ -40,6 +41,22 @@ func (d *Dealer) SetInitialCapital(amount decimal.Decimal) {
 	d.simulator.SetInitialCapital(amount)
 }
 
+// SetRolls sets the roll events of a continuous futures series so that the simulator charges roll costs.
+func (d *Dealer) SetRolls(rolls []market.Roll) {
+	d.simulator.SetRolls(rolls)
+}
+
+// SetDividends sets the cash dividends of an equity so that the simulator pays them to open positions.
+func (d *Dealer) SetDividends(dividends []market.CorporateAction) {
+	d.simulator.SetDividends(dividends)
+}
+
+// SetOption sets the option traded by the dealer so that the simulator values the received underlying prices
+// as option prices and settles the position at expiry.
+func (d *Dealer) SetOption(option options.Option, pricer *options.Pricer) {
+	d.simulator.SetOption(option, pricer)
+}
+
 // GetBalance returns the current balance of the dealer.
 func (d *Dealer) GetBalance(ctx context.Context) (*broker.AccountBalance, *web.Response, error) {
 	acc := d.simulator.Balance()
@@ -73,6 +90,11 @@ func (d *Dealer) EquityHistory() broker.EquitySeries {
 	return d.simulator.EquityHistory()
 }
 
+// AccountHistory returns the history of account snapshots recorded alongside the equity curve.
+func (d *Dealer) AccountHistory() broker.AccountSeries {
+	return d.simulator.AccountHistory()
+}
+
 // ReceivePrice initiates processing by supplying the next market price to the simulator.
 func (d *Dealer) ReceivePrice(ctx context.Context, price market.Kline) error {
 	return d.simulator.Next(price)
@@ -4,6 +4,7 @@
 package backtest
 
 import (
+	"encoding/json"
 	"math"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 )
 
 var _ Coster = (*PerpCoster)(nil)
+var _ CostSnapshotter = (*PerpCoster)(nil)
 
 // PerpCoster implements the Coster interface for Perpetual Future style assets.
 type PerpCoster struct {
@@ -69,3 +71,23 @@ func (c *PerpCoster) Funding(position broker.Position, price decimal.Decimal, el
 
 	return totalCost
 }
+
+// perpCosterState is the serialised state of a PerpCoster.
+type perpCosterState struct {
+	LastFundingHour float64 `json:"last_funding_hour"`
+}
+
+// SnapshotCost returns the funding state so that funding is not charged twice when a simulation is resumed.
+func (c *PerpCoster) SnapshotCost() (json.RawMessage, error) {
+	return json.Marshal(perpCosterState{LastFundingHour: c.lastFundingHour})
+}
+
+// RestoreCost restores the funding state saved by SnapshotCost.
+func (c *PerpCoster) RestoreCost(state json.RawMessage) error {
+	var restored perpCosterState
+	if err := json.Unmarshal(state, &restored); err != nil {
+		return err
+	}
+	c.lastFundingHour = restored.LastFundingHour
+	return nil
+}
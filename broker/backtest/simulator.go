@@ -5,11 +5,13 @@ package backtest
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/options"
 	"github.com/thecolngroup/gou/dec"
 	"golang.org/x/exp/maps"
 )
@@ -37,12 +39,20 @@ type Simulator struct {
 	balance     broker.AccountBalance
 	marketPrice market.Kline
 
-	cost Coster
+	cost      Coster
+	rolls     []market.Roll
+	dividends []market.CorporateAction
+
+	option  *options.Option
+	pricer  *options.Pricer
+	settled bool
 
 	orders     []broker.Order
 	positions  []broker.Position
 	roundturns []broker.RoundTurn
 	equity     broker.EquitySeries
+	account    broker.AccountSeries
+	fees       decimal.Decimal
 }
 
 // NewSimulator create a new backtest simulator with zero cost model.
@@ -57,6 +67,7 @@ func NewSimulatorWithCost(cost Coster) *Simulator {
 		clock:   NewClock(),
 		cost:    cost,
 		equity:  make(broker.EquitySeries),
+		account: make(broker.AccountSeries),
 	}
 }
 
@@ -65,12 +76,39 @@ func (s *Simulator) SetInitialCapital(amount decimal.Decimal) {
 	s.balance.Trade = amount
 }
 
+// SetRolls sets the roll events of a continuous futures series, such as those returned by market.StitchContracts.
+// A position open at a roll is charged the cost of closing it in the old contract and opening it in the new:
+// twice the spread, slippage and transaction cost of the position at the open price of the first kline after the roll.
+func (s *Simulator) SetRolls(rolls []market.Roll) {
+	s.rolls = rolls
+}
+
+// SetDividends sets the cash dividends of an equity, such as those returned by market.AdjustForCorporateActions.
+// A position held at the close before an ex-date is paid the dividend per unit held: credited to the trade balance
+// of a long position and debited from a short. Dividends are not included in the profit of the round-turn.
+func (s *Simulator) SetDividends(dividends []market.CorporateAction) {
+	s.dividends = dividends
+}
+
+// SetOption sets the option traded by the simulator.
+// Each price received is then a price of the underlying, valued as a price of the option by the pricer
+// to match orders and mark positions.
+// From the first price at or after expiry the option is valued at its intrinsic value at the underlying open:
+// open orders are cancelled, an open position is settled at that value without costs and further orders are rejected.
+func (s *Simulator) SetOption(option options.Option, pricer *options.Pricer) {
+	s.option = &option
+	s.pricer = pricer
+}
+
 // AddOrder adds an order to the simulator and returns the processed order or an error.
 func (s *Simulator) AddOrder(order broker.Order) (broker.Order, error) {
 	var empty broker.Order
 	if order.Side == 0 || order.Type == 0 || order.State() != broker.OrderPending || !order.Size.IsPositive() {
 		return empty, ErrInvalidOrderState
 	}
+	if s.settled {
+		return empty, fmt.Errorf("%w: option has expired", ErrRejectedOrder)
+	}
 	order, err := s.processOrder(order)
 	if err != nil {
 		return empty, err
@@ -92,9 +130,42 @@ func (s *Simulator) Next(price market.Kline) error {
 	// Advance the clock epoch to the start time of the kline
 	s.clock.Advance(price.Start)
 
+	// Value the price of the underlying as a price of the option, if set
+	if s.option != nil {
+		if !price.Start.Before(s.option.Expiry) {
+			value := s.option.Intrinsic(price.O)
+			price = market.Kline{Start: price.Start, O: value, H: value, L: value, C: value}
+		} else {
+			price = s.pricer.MarkKline(*s.option, price)
+		}
+	}
+
 	// Set the market price used in this epoch to the received price
+	prev := s.marketPrice
 	s.marketPrice = price
 
+	// Pay any dividends and deduct the cost of any roll to the next contract since the previous price
+	// from the position held at its close, before processing orders
+	if held := s.getPosition(); held.State() == broker.PositionOpen {
+		s.balance.Trade = s.balance.Trade.Add(s.dividendAmount(held, prev.Start, price.Start))
+		roll := s.rollCost(held, prev.Start, price)
+		if held.Side == broker.Sell {
+			// Short position PNL is the cost less the market value
+			held.Cost = held.Cost.Sub(roll)
+		} else {
+			held.Cost = held.Cost.Add(roll)
+		}
+		s.fees = s.fees.Add(roll)
+		s.upsertPosition(held)
+	}
+
+	// Settle an expired option before processing orders, so that open orders are cancelled rather than filled at expiry
+	if s.option != nil && !s.settled && !price.Start.Before(s.option.Expiry) {
+		if err := s.settle(); err != nil {
+			return err
+		}
+	}
+
 	for i := range s.orders {
 		order := s.orders[i]
 		if order.State() != broker.OrderOpen {
@@ -111,9 +182,12 @@ func (s *Simulator) Next(price market.Kline) error {
 	equity := s.balance.Trade
 
 	// Mark open position to market and add unrealized PNL to equity
-	if position := s.getPosition(); position.State() == broker.OrderOpen {
+	position := s.getPosition()
+	if position.State() == broker.OrderOpen {
 		// Deduct funding fees from position PNL
-		position.Cost = position.Cost.Add(s.cost.Funding(position, s.marketPrice.C, s.clock.Elapsed()))
+		funding := s.cost.Funding(position, s.marketPrice.C, s.clock.Elapsed())
+		position.Cost = position.Cost.Add(funding)
+		s.fees = s.fees.Add(funding)
 		// Mark position PNL to latest price
 		position = markPositionToMarket(position, s.marketPrice.C)
 		s.upsertPosition(position)
@@ -122,8 +196,10 @@ func (s *Simulator) Next(price market.Kline) error {
 	}
 
 	// Update equity balance
-	s.equity[broker.Timestamp(s.clock.Peek().UnixMilli())] = equity
+	epoch := broker.Timestamp(s.clock.Peek().UnixMilli())
+	s.equity[epoch] = equity
 	s.balance.Equity = equity
+	s.account[epoch] = s.snapshot(position)
 
 	return nil
 }
@@ -170,6 +246,13 @@ func (s *Simulator) EquityHistory() broker.EquitySeries {
 	return copied
 }
 
+// AccountHistory returns a copy of the account snapshot series.
+func (s *Simulator) AccountHistory() broker.AccountSeries {
+	copied := make(broker.AccountSeries, len(s.account))
+	maps.Copy(copied, s.account)
+	return copied
+}
+
 // Balance returns the current account balance.
 func (s *Simulator) Balance() broker.AccountBalance {
 	return s.balance
@@ -211,6 +294,7 @@ func (s *Simulator) processOrder(order broker.Order) (broker.Order, error) {
 			return order, err
 		}
 		s.upsertPosition(position)
+		s.fees = s.fees.Add(order.Fee)
 		order = s.closeOrder(order)
 	}
 	return order, nil
@@ -378,6 +462,84 @@ func (s *Simulator) createRoundTurn(position broker.Position) broker.RoundTurn {
 	}
 }
 
+// rollCost returns the cost of rolling the position for each roll after the previous kline start, up to the start of price.
+func (s *Simulator) rollCost(position broker.Position, prev time.Time, price market.Kline) decimal.Decimal {
+	cost := decimal.Zero
+	for _, roll := range s.rolls {
+		if !roll.Start.After(prev) || roll.Start.After(price.Start) {
+			continue
+		}
+		order := broker.Order{FilledPrice: price.O, FilledSize: position.Size}
+		perUnit := s.cost.Slippage(price.O).Add(s.cost.Spread(price.O))
+		cost = cost.Add(perUnit.Mul(position.Size).Add(s.cost.Transaction(order)).Mul(dec.New(2)))
+	}
+	return cost
+}
+
+// settle cancels open orders and closes any open position at the current price, without costs, at option expiry.
+func (s *Simulator) settle() error {
+	s.settled = true
+	s.CancelOrders()
+
+	position := s.getPosition()
+	if position.State() != broker.PositionOpen {
+		return nil
+	}
+	order := s.openOrder(broker.NewOrder(position.Asset, position.Side.Opposite(), position.Size))
+	order.ReduceOnly = true
+	order.FilledAt = s.clock.Now()
+	order.FilledPrice = s.marketPrice.C
+	order.FilledSize = order.Size
+	order, err := s.processOrder(order)
+	if err != nil {
+		return err
+	}
+	s.orders = append(s.orders, order)
+	return nil
+}
+
+// dividendAmount returns the dividends paid to the position for each ex-date after the previous kline start,
+// up to the start of price. Negative for a short position.
+func (s *Simulator) dividendAmount(position broker.Position, prev, start time.Time) decimal.Decimal {
+	amount := decimal.Zero
+	for _, dividend := range s.dividends {
+		if dividend.Kind != market.Dividend || !dividend.ExDate.After(prev) || dividend.ExDate.After(start) {
+			continue
+		}
+		amount = amount.Add(dividend.Amount.Mul(position.Size))
+	}
+	if position.Side == broker.Sell {
+		return amount.Neg()
+	}
+	return amount
+}
+
+// snapshot records the account state given the open position (if any) marked to the current price.
+func (s *Simulator) snapshot(position broker.Position) broker.AccountSnapshot {
+	snapshot := broker.AccountSnapshot{
+		Cash:    s.balance.Trade,
+		Equity:  s.balance.Equity,
+		CumFees: s.fees,
+	}
+	if position.State() != broker.PositionOpen {
+		return snapshot
+	}
+
+	notional := position.Size.Mul(position.MarkPrice)
+	snapshot.UnrealizedPNL = position.PNL
+	snapshot.GrossExposure = notional.Abs()
+	snapshot.NetExposure = notional
+	if position.Side == broker.Sell {
+		snapshot.NetExposure = notional.Neg()
+	}
+	snapshot.OpenPositionCount = 1
+	if s.balance.Equity.IsPositive() {
+		snapshot.Leverage = snapshot.GrossExposure.Div(s.balance.Equity).InexactFloat64()
+	}
+
+	return snapshot
+}
+
 func markPositionToMarket(position broker.Position, markPrice decimal.Decimal) broker.Position {
 	position.MarkPrice = markPrice
 	position.PNL = position.Size.Mul(position.MarkPrice).Sub(position.Cost)
@@ -9,8 +9,10 @@ import (
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/options"
 	"github.com/thecolngroup/gou/dec"
 	"github.com/thecolngroup/gou/test"
 )
@@ -534,3 +536,204 @@ func TestEqualClock(t *testing.T) {
 	t2 = time.Date(0, 0, 0, 1, 1, 1, 8, time.Local)
 	assert.False(t, equalClock(t1, t2))
 }
+
+func TestSimulator_AccountHistory(t *testing.T) {
+	sim := NewSimulatorWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+	sim.SetInitialCapital(dec.New(100))
+
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, sim.Next(market.Kline{Start: start, O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}))
+	_, err := sim.AddOrder(broker.NewOrder(market.NewAsset("BTCUSD"), broker.Sell, dec.New(5)))
+	assert.NoError(t, err)
+	assert.NoError(t, sim.Next(market.Kline{Start: start.Add(time.Hour), O: dec.New(8), H: dec.New(8), L: dec.New(8), C: dec.New(8)}))
+
+	act := sim.AccountHistory()
+	assert.Len(t, act, 2)
+
+	flat := act[broker.Timestamp(start.UnixMilli())]
+	assert.True(t, flat.Equity.Equal(dec.New(100)))
+	assert.Zero(t, flat.OpenPositionCount)
+
+	short := act[broker.Timestamp(start.Add(time.Hour).UnixMilli())]
+	assert.Equal(t, 1, short.OpenPositionCount)
+	assert.True(t, short.GrossExposure.Equal(dec.New(40)))
+	assert.True(t, short.NetExposure.Equal(dec.New(-40)))
+	assert.True(t, short.Equity.Equal(short.Cash.Add(short.UnrealizedPNL)))
+	assert.True(t, short.CumFees.Equal(dec.New(0.5)))
+	assert.InDelta(t, 40/short.Equity.InexactFloat64(), short.Leverage, 0.0001)
+}
+
+func TestSimulator_SetRolls(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	flat := func(hours int) market.Kline {
+		return market.Kline{Start: start.Add(time.Duration(hours) * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+	}
+
+	tests := []struct {
+		name string
+		give broker.OrderSide
+	}{
+		{name: "long", give: broker.Buy},
+		{name: "short", give: broker.Sell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulatorWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+			sim.SetInitialCapital(dec.New(100))
+			sim.SetRolls([]market.Roll{{Start: flat(0).Start}, {Start: flat(2).Start}})
+
+			assert.NoError(t, sim.Next(flat(0)))
+			_, err := sim.AddOrder(broker.NewOrder(market.NewAsset("ESH22"), tt.give, dec.New(5)))
+			assert.NoError(t, err)
+			assert.NoError(t, sim.Next(flat(1)))
+			before := sim.Balance().Equity
+
+			// Closing and opening 5 contracts at 10 with a 1% transaction cost
+			assert.NoError(t, sim.Next(flat(2)))
+			assert.True(t, before.Sub(dec.New(1)).Equal(sim.Balance().Equity), "equity %s", sim.Balance().Equity)
+			assert.True(t, sim.AccountHistory()[broker.Timestamp(flat(2).Start.UnixMilli())].CumFees.Equal(dec.New(1.5)))
+		})
+	}
+}
+
+func TestSimulator_SetRolls_OpenedOnRoll(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	flat := func(hours int) market.Kline {
+		return market.Kline{Start: start.Add(time.Duration(hours) * time.Hour), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+	}
+
+	sim := NewSimulatorWithCost(&PerpCoster{TransactionPct: dec.New(0.01)})
+	sim.SetInitialCapital(dec.New(100))
+	sim.SetRolls([]market.Roll{{Start: flat(1).Start}})
+
+	assert.NoError(t, sim.Next(flat(0)))
+	order := broker.NewOrder(market.NewAsset("ESH22"), broker.Buy, dec.New(5))
+	order.Type = broker.Limit
+	order.LimitPrice = dec.New(10)
+	_, err := sim.AddOrder(order)
+	assert.NoError(t, err)
+
+	// The position is opened in the new contract so only the entry fee is charged
+	assert.NoError(t, sim.Next(flat(1)))
+	require.Len(t, sim.Positions(), 1)
+	assert.True(t, sim.Balance().Equity.Equal(dec.New(99.5)), "equity %s", sim.Balance().Equity)
+	assert.True(t, sim.Positions()[0].Cost.Equal(dec.New(50.5)), "cost %s", sim.Positions()[0].Cost)
+}
+
+func TestSimulator_SetDividends(t *testing.T) {
+	start := time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC)
+	flat := func(days int) market.Kline {
+		return market.Kline{Start: start.AddDate(0, 0, days), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10)}
+	}
+
+	tests := []struct {
+		name string
+		give broker.OrderSide
+		want float64
+	}{
+		{name: "long credited", give: broker.Buy, want: 100 + 5*0.5},
+		{name: "short debited", give: broker.Sell, want: 100 - 5*0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulator()
+			sim.SetInitialCapital(dec.New(100))
+			sim.SetDividends([]market.CorporateAction{
+				{Kind: market.Dividend, ExDate: flat(1).Start, Amount: dec.New(0.25)}, // Before the position is held
+				{Kind: market.Dividend, ExDate: flat(2).Start, Amount: dec.New(0.5)},
+				{Kind: market.Split, ExDate: flat(2).Start, Ratio: 2}, // Ignored
+			})
+
+			assert.NoError(t, sim.Next(flat(0)))
+			assert.NoError(t, sim.Next(flat(1)))
+			_, err := sim.AddOrder(broker.NewOrder(market.NewAsset("AAPL"), tt.give, dec.New(5)))
+			assert.NoError(t, err)
+			assert.True(t, dec.New(100).Equal(sim.Balance().Trade))
+
+			assert.NoError(t, sim.Next(flat(2)))
+			assert.NoError(t, sim.Next(flat(3)))
+			assert.True(t, dec.New(tt.want).Equal(sim.Balance().Trade), "trade balance %s", sim.Balance().Trade)
+			assert.True(t, dec.New(tt.want).Equal(sim.Balance().Equity), "equity %s", sim.Balance().Equity)
+		})
+	}
+}
+
+func TestSimulator_SetOption(t *testing.T) {
+	expiry := time.Date(2022, time.March, 25, 0, 0, 0, 0, time.UTC)
+	underlying := func(days int, price float64) market.Kline {
+		p := dec.New(price)
+		return market.Kline{Start: expiry.AddDate(0, 0, days), O: p, H: p, L: p, C: p}
+	}
+	call := options.Option{Underlying: market.NewAsset("BTC"), Strike: dec.New(40000), Expiry: expiry, Right: options.Call}
+	pricer := options.NewPricer(options.Black76, options.FlatSurface(0.8))
+
+	sim := NewSimulator()
+	sim.SetInitialCapital(dec.New(10000))
+	sim.SetOption(call, pricer)
+
+	// Buy 2 calls at the option value of the underlying close
+	assert.NoError(t, sim.Next(underlying(-10, 40000)))
+	premium := pricer.Price(call, dec.New(40000), expiry.AddDate(0, 0, -10))
+	order, err := sim.AddOrder(broker.NewOrder(call.Asset(), broker.Buy, dec.New(2)))
+	assert.NoError(t, err)
+	assert.True(t, premium.Equal(order.FilledPrice), "filled at %s", order.FilledPrice)
+
+	// Marked to the option value
+	assert.NoError(t, sim.Next(underlying(-5, 41000)))
+	mark := pricer.Price(call, dec.New(41000), expiry.AddDate(0, 0, -5))
+	assert.True(t, mark.Equal(sim.Positions()[0].MarkPrice))
+
+	// A limit order left open is cancelled at expiry
+	_, err = sim.AddOrder(broker.Order{Asset: call.Asset(), Side: broker.Sell, Type: broker.Limit, Size: dec.New(2), LimitPrice: dec.New(100000)})
+	assert.NoError(t, err)
+
+	// Settled at intrinsic value of the underlying open at expiry without costs
+	assert.NoError(t, sim.Next(underlying(0, 43000)))
+	require.Len(t, sim.RoundTurns(), 1)
+	want := dec.New(3000).Sub(premium).Mul(dec.New(2))
+	assert.True(t, want.Equal(sim.RoundTurns()[0].Profit), "profit %s", sim.RoundTurns()[0].Profit)
+	assert.True(t, dec.New(10000).Add(want).Equal(sim.Balance().Equity))
+	for _, o := range sim.Orders() {
+		assert.EqualValues(t, broker.OrderClosed, o.State())
+	}
+
+	_, err = sim.AddOrder(broker.NewOrder(call.Asset(), broker.Buy, dec.New(1)))
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+}
+
+func TestSimulator_SetOption_LimitAtExpiry(t *testing.T) {
+	expiry := time.Date(2022, time.March, 25, 0, 0, 0, 0, time.UTC)
+	underlying := func(days int, price float64) market.Kline {
+		p := dec.New(price)
+		return market.Kline{Start: expiry.AddDate(0, 0, days), O: p, H: p, L: p, C: p}
+	}
+	call := options.Option{Underlying: market.NewAsset("BTC"), Strike: dec.New(40000), Expiry: expiry, Right: options.Call}
+	pricer := options.NewPricer(options.Black76, options.FlatSurface(0.8))
+
+	sim := NewSimulator()
+	sim.SetInitialCapital(dec.New(10000))
+	sim.SetOption(call, pricer)
+	require.NoError(t, sim.Next(underlying(-10, 40000)))
+	premium := pricer.Price(call, dec.New(40000), expiry.AddDate(0, 0, -10))
+	_, err := sim.AddOrder(broker.NewOrder(call.Asset(), broker.Buy, dec.New(2)))
+	require.NoError(t, err)
+	// Placed at midday so that the order is not in the same epoch as the expiry kline
+	midday := underlying(-5, 41000)
+	midday.Start = midday.Start.Add(12 * time.Hour)
+	require.NoError(t, sim.Next(midday))
+
+	// A limit sell at the intrinsic value at expiry is cancelled rather than filled
+	limit, err := sim.AddOrder(broker.Order{Asset: call.Asset(), Side: broker.Sell, Type: broker.Limit, Size: dec.New(2), LimitPrice: dec.New(3000)})
+	require.NoError(t, err)
+	require.NoError(t, sim.Next(underlying(0, 43000)))
+
+	for _, o := range sim.Orders() {
+		if o.ID == limit.ID {
+			assert.EqualValues(t, broker.OrderClosed, o.State())
+			assert.True(t, o.FilledSize.IsZero(), "filled %s", o.FilledSize)
+		}
+	}
+	require.Len(t, sim.RoundTurns(), 1)
+	want := dec.New(3000).Sub(premium).Mul(dec.New(2))
+	assert.True(t, want.Equal(sim.RoundTurns()[0].Profit), "profit %s", sim.RoundTurns()[0].Profit)
+}
@@ -0,0 +1,211 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/options"
+	"golang.org/x/exp/maps"
+)
+
+// SnapshotVersion is the version of the Snapshot format written by this package.
+// Increment when a change to Snapshot would prevent an older snapshot from being restored correctly.
+const SnapshotVersion = 2
+
+// ErrSnapshotVersion is returned when restoring a snapshot written with an unsupported format version.
+var ErrSnapshotVersion = errors.New("unsupported snapshot version")
+
+// ErrSnapshotCost is returned when a snapshot contains cost model state that the simulator cost model cannot restore.
+var ErrSnapshotCost = errors.New("snapshot cost state cannot be restored by cost model")
+
+// ErrSnapshotPricer is returned when a snapshot contains an unexpired option but the simulator has no option pricer.
+var ErrSnapshotPricer = errors.New("snapshot option cannot be valued without pricer")
+
+// CostSnapshotter is implemented by a Coster that holds state between calls,
+// such as the last funding period charged, that must be saved to resume a simulation exactly.
+type CostSnapshotter interface {
+	SnapshotCost() (json.RawMessage, error)
+	RestoreCost(json.RawMessage) error
+}
+
+// Snapshot is a serialisable record of the complete state of a Simulator.
+// Continuing a simulation after restoring a snapshot gives the same results as an uninterrupted run,
+// provided the restored simulator is created with the same cost model parameters.
+// The option pricer is not saved, as its volatility surface may not be serialisable,
+// so a simulator restoring an option that has not yet expired must first be given a pricer with SetOption.
+type Snapshot struct {
+	Version int
+
+	ClockNow      time.Time
+	ClockInterval time.Duration
+	ClockElapsed  time.Duration
+
+	Balance     broker.AccountBalance
+	MarketPrice market.Kline
+	Fees        decimal.Decimal
+
+	Orders     []broker.Order
+	Positions  []broker.Position
+	RoundTurns []broker.RoundTurn
+	Equity     broker.EquitySeries
+	Account    broker.AccountSeries
+
+	// Cost is the state of the cost model if it implements CostSnapshotter.
+	Cost json.RawMessage
+
+	Rolls     []market.Roll
+	Dividends []market.CorporateAction
+	Option    *options.Option
+
+	// Settled is true once the option has expired and the position has been settled.
+	Settled bool
+}
+
+// Snapshot returns a copy of the complete simulator state.
+// The clock state is only saved if the simulator uses the default Clock.
+func (s *Simulator) Snapshot() (Snapshot, error) {
+	snapshot := Snapshot{
+		Version:     SnapshotVersion,
+		Balance:     s.balance,
+		MarketPrice: s.marketPrice,
+		Fees:        s.fees,
+		Orders:      append([]broker.Order(nil), s.orders...),
+		Positions:   append([]broker.Position(nil), s.positions...),
+		RoundTurns:  append([]broker.RoundTurn(nil), s.roundturns...),
+		Equity:      make(broker.EquitySeries, len(s.equity)),
+		Account:     make(broker.AccountSeries, len(s.account)),
+		Rolls:       append([]market.Roll(nil), s.rolls...),
+		Dividends:   append([]market.CorporateAction(nil), s.dividends...),
+		Settled:     s.settled,
+	}
+	if s.option != nil {
+		option := *s.option
+		snapshot.Option = &option
+	}
+	maps.Copy(snapshot.Equity, s.equity)
+	maps.Copy(snapshot.Account, s.account)
+
+	if clock, ok := s.clock.(*Clock); ok {
+		snapshot.ClockNow = clock.now
+		snapshot.ClockInterval = clock.interval
+		snapshot.ClockElapsed = clock.elapsed
+	}
+
+	if cost, ok := s.cost.(CostSnapshotter); ok {
+		state, err := cost.SnapshotCost()
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Cost = state
+	}
+
+	return snapshot, nil
+}
+
+// Restore replaces the simulator state with the given snapshot.
+// The simulator clock is replaced with a default Clock set to the snapshot time.
+// The option pricer, if any, is kept.
+func (s *Simulator) Restore(snapshot Snapshot) error {
+	if snapshot.Version != SnapshotVersion {
+		return ErrSnapshotVersion
+	}
+	if snapshot.Option != nil && !snapshot.Settled && s.pricer == nil {
+		return ErrSnapshotPricer
+	}
+
+	if len(snapshot.Cost) > 0 {
+		cost, ok := s.cost.(CostSnapshotter)
+		if !ok {
+			return ErrSnapshotCost
+		}
+		if err := cost.RestoreCost(snapshot.Cost); err != nil {
+			return err
+		}
+	}
+
+	interval := snapshot.ClockInterval
+	if interval == 0 {
+		interval = _defaultTockInterval
+	}
+	s.clock = &Clock{
+		now:      snapshot.ClockNow,
+		interval: interval,
+		elapsed:  snapshot.ClockElapsed,
+	}
+
+	s.balance = snapshot.Balance
+	s.marketPrice = snapshot.MarketPrice
+	s.fees = snapshot.Fees
+	s.orders = append([]broker.Order(nil), snapshot.Orders...)
+	s.positions = append([]broker.Position(nil), snapshot.Positions...)
+	s.roundturns = append([]broker.RoundTurn(nil), snapshot.RoundTurns...)
+	s.equity = make(broker.EquitySeries, len(snapshot.Equity))
+	maps.Copy(s.equity, snapshot.Equity)
+	s.account = make(broker.AccountSeries, len(snapshot.Account))
+	maps.Copy(s.account, snapshot.Account)
+	s.rolls = append([]market.Roll(nil), snapshot.Rolls...)
+	s.dividends = append([]market.CorporateAction(nil), snapshot.Dividends...)
+	s.option = nil
+	if snapshot.Option != nil {
+		option := *snapshot.Option
+		s.option = &option
+	}
+	s.settled = snapshot.Settled
+
+	return nil
+}
+
+// Snapshot returns a copy of the complete dealer state.
+func (d *Dealer) Snapshot() (Snapshot, error) {
+	return d.simulator.Snapshot()
+}
+
+// Restore replaces the dealer state with the given snapshot.
+func (d *Dealer) Restore(snapshot Snapshot) error {
+	return d.simulator.Restore(snapshot)
+}
+
+// WriteSnapshotJSON writes a snapshot as JSON.
+func WriteSnapshotJSON(w io.Writer, snapshot Snapshot) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(snapshot)
+}
+
+// ReadSnapshotJSON reads a snapshot written by WriteSnapshotJSON.
+func ReadSnapshotJSON(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Version != SnapshotVersion {
+		return Snapshot{}, ErrSnapshotVersion
+	}
+	return snapshot, nil
+}
+
+// WriteSnapshotGob writes a snapshot in gob format.
+func WriteSnapshotGob(w io.Writer, snapshot Snapshot) error {
+	return gob.NewEncoder(w).Encode(snapshot)
+}
+
+// ReadSnapshotGob reads a snapshot written by WriteSnapshotGob.
+func ReadSnapshotGob(r io.Reader) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := gob.NewDecoder(r).Decode(&snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	if snapshot.Version != SnapshotVersion {
+		return Snapshot{}, ErrSnapshotVersion
+	}
+	return snapshot, nil
+}
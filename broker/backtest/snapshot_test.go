@@ -0,0 +1,157 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package backtest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/options"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func newCosterForSnapshotTest() *PerpCoster {
+	cost := NewPerpCoster()
+	cost.SpreadPct = dec.New(0.001)
+	cost.TransactionPct = dec.New(0.0005)
+	cost.FundingHourPct = dec.New(0.0001)
+	return cost
+}
+
+// runForSnapshotTest feeds prices to the simulator from index from to to,
+// alternating between opening and closing a position every third price.
+func runForSnapshotTest(t *testing.T, sim *Simulator, prices []market.Kline, from, to int) {
+	asset := market.NewAsset("BTCUSD")
+	for i := from; i < to; i++ {
+		require.NoError(t, sim.Next(prices[i]))
+		if i%3 != 0 {
+			continue
+		}
+		side := broker.Buy
+		if i%6 == 3 {
+			side = broker.Sell
+		}
+		order := broker.NewOrder(asset, side, dec.New(1))
+		order.ID = broker.DealID(prices[i].Start.Format(time.RFC3339))
+		_, err := sim.AddOrder(order)
+		require.NoError(t, err)
+	}
+}
+
+func TestSimulator_SnapshotRestore(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	prices := make([]market.Kline, 20)
+	for i := range prices {
+		c := dec.New(100 + float64(i%7))
+		prices[i] = market.Kline{Start: start.Add(time.Duration(i) * time.Hour), O: c, H: c, L: c, C: c}
+	}
+
+	// A roll and a dividend while a position is held after the snapshot
+	rolls := []market.Roll{{Start: prices[13].Start}}
+	dividends := []market.CorporateAction{{Kind: market.Dividend, ExDate: prices[14].Start, Amount: dec.New(2)}}
+
+	uninterrupted := NewSimulatorWithCost(newCosterForSnapshotTest())
+	uninterrupted.SetInitialCapital(dec.New(1000))
+	uninterrupted.SetRolls(rolls)
+	uninterrupted.SetDividends(dividends)
+	runForSnapshotTest(t, uninterrupted, prices, 0, len(prices))
+
+	tests := []struct {
+		name  string
+		write func(io.Writer, Snapshot) error
+		read  func(io.Reader) (Snapshot, error)
+	}{
+		{name: "json", write: WriteSnapshotJSON, read: ReadSnapshotJSON},
+		{name: "gob", write: WriteSnapshotGob, read: ReadSnapshotGob},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim := NewSimulatorWithCost(newCosterForSnapshotTest())
+			sim.SetInitialCapital(dec.New(1000))
+			sim.SetRolls(rolls)
+			sim.SetDividends(dividends)
+			runForSnapshotTest(t, sim, prices, 0, 10)
+
+			snapshot, err := sim.Snapshot()
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, tt.write(&buf, snapshot))
+
+			restored, err := tt.read(&buf)
+			require.NoError(t, err)
+			resumed := NewSimulatorWithCost(newCosterForSnapshotTest())
+			require.NoError(t, resumed.Restore(restored))
+			runForSnapshotTest(t, resumed, prices, 10, len(prices))
+
+			assert.True(t, uninterrupted.Balance().Trade.Equal(resumed.Balance().Trade))
+			assert.True(t, uninterrupted.Balance().Equity.Equal(resumed.Balance().Equity))
+			assert.Len(t, resumed.RoundTurns(), len(uninterrupted.RoundTurns()))
+			assert.Len(t, resumed.Orders(), len(uninterrupted.Orders()))
+
+			want := uninterrupted.EquityHistory()
+			act := resumed.EquityHistory()
+			assert.Equal(t, want.SortKeys(), act.SortKeys())
+			for k := range want {
+				assert.True(t, want[k].Equal(act[k]), "equity at %v", k.Time())
+			}
+		})
+	}
+}
+
+func TestSimulator_SnapshotRestore_Option(t *testing.T) {
+	expiry := time.Date(2022, time.March, 25, 0, 0, 0, 0, time.UTC)
+	underlying := func(days int, price float64) market.Kline {
+		p := dec.New(price)
+		return market.Kline{Start: expiry.AddDate(0, 0, days), O: p, H: p, L: p, C: p}
+	}
+	call := options.Option{Underlying: market.NewAsset("BTC"), Strike: dec.New(40000), Expiry: expiry, Right: options.Call}
+	pricer := options.NewPricer(options.Black76, options.FlatSurface(0.8))
+
+	sim := NewSimulator()
+	sim.SetInitialCapital(dec.New(10000))
+	sim.SetOption(call, pricer)
+	require.NoError(t, sim.Next(underlying(-10, 40000)))
+	_, err := sim.AddOrder(broker.NewOrder(call.Asset(), broker.Buy, dec.New(2)))
+	require.NoError(t, err)
+
+	// An unexpired option needs a pricer to be restored
+	open, err := sim.Snapshot()
+	require.NoError(t, err)
+	assert.ErrorIs(t, NewSimulator().Restore(open), ErrSnapshotPricer)
+	resumed := NewSimulator()
+	resumed.SetOption(call, pricer)
+	require.NoError(t, resumed.Restore(open))
+	require.NoError(t, resumed.Next(underlying(-5, 41000)))
+	assert.True(t, pricer.Price(call, dec.New(41000), expiry.AddDate(0, 0, -5)).Equal(resumed.Positions()[0].MarkPrice))
+
+	// A settled option is not settled again and rejects further orders
+	require.NoError(t, sim.Next(underlying(0, 43000)))
+	settled, err := sim.Snapshot()
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, WriteSnapshotJSON(&buf, settled))
+	settled, err = ReadSnapshotJSON(&buf)
+	require.NoError(t, err)
+	resumed = NewSimulator()
+	require.NoError(t, resumed.Restore(settled))
+	require.NoError(t, resumed.Next(underlying(1, 44000)))
+	assert.Len(t, resumed.RoundTurns(), 1)
+	assert.True(t, sim.Balance().Equity.Equal(resumed.Balance().Equity))
+	_, err = resumed.AddOrder(broker.NewOrder(call.Asset(), broker.Buy, dec.New(1)))
+	assert.ErrorIs(t, err, ErrRejectedOrder)
+}
+
+func TestSimulator_RestoreVersion(t *testing.T) {
+	sim := NewSimulator()
+	assert.ErrorIs(t, sim.Restore(Snapshot{Version: SnapshotVersion + 1}), ErrSnapshotVersion)
+
+	_, err := ReadSnapshotJSON(bytes.NewBufferString(`{"Version": 0}`))
+	assert.ErrorIs(t, err, ErrSnapshotVersion)
+}
@@ -27,6 +27,7 @@ type SimulatedDealer interface {
 	Dealer
 	market.Receiver
 	EquityHistory() EquitySeries
+	AccountHistory() AccountSeries
 	SetInitialCapital(amount decimal.Decimal)
 }
 
@@ -19,3 +19,26 @@ func (es EquitySeries) SortKeys() []Timestamp {
 func (es EquitySeries) SortValuesByTime() []decimal.Decimal {
 	return TimeSeries[decimal.Decimal](es).SortValuesByTime()
 }
+
+// Sorted returns an immutable copy of the series in ascending chronological order.
+func (es EquitySeries) Sorted() SortedSeries[decimal.Decimal] {
+	return TimeSeries[decimal.Decimal](es).Sorted()
+}
+
+// Float64s returns the timestamps and values as floats in ascending chronological order.
+func (es EquitySeries) Float64s() ([]Timestamp, []float64) {
+	sorted := es.Sorted()
+	return sorted.keys, MapSeries(sorted, decimal.Decimal.InexactFloat64).values
+}
+
+// NewEquitySeriesFromFloat64s creates an equity series from parallel slices of timestamps and float values.
+func NewEquitySeriesFromFloat64s(keys []Timestamp, values []float64) (EquitySeries, error) {
+	if len(keys) != len(values) {
+		return nil, ErrSeriesLengthMismatch
+	}
+	es := make(EquitySeries, len(keys))
+	for i := range keys {
+		es[keys[i]] = decimal.NewFromFloat(values[i])
+	}
+	return es, nil
+}
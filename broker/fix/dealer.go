@@ -0,0 +1,382 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package fix provides a broker.Dealer implementation over a FIX 4.4 initiator session.
+// Orders are sent as NewOrderSingle and cancelled with OrderCancelRequest.
+// ExecutionReport messages from the counterparty are mapped back to broker.Order and its states.
+// A StubAcceptor is included as a local stand-in for a venue to enable offline integration tests.
+package fix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/web"
+)
+
+// Enforce at compile time that the type implements the interface
+var _ broker.Dealer = (*Dealer)(nil)
+
+const _defaultReplyTimeout = 10 * time.Second
+
+var (
+	// ErrOrderRejected is returned when the counterparty rejects an order.
+	ErrOrderRejected = errors.New("order rejected by counterparty")
+
+	// ErrReplyTimeout is returned when no ExecutionReport is received for an order within the reply timeout.
+	ErrReplyTimeout = errors.New("timeout awaiting execution report")
+)
+
+// Dealer is a broker.Dealer that places orders with a venue using a FIX 4.4 session.
+// Market orders are awaited until filled, and limit orders until acknowledged by the venue.
+// Positions, round-turns and balance are derived locally from trade executions,
+// as FIX 4.4 has no standard query for them.
+//
+// An order the venue has not answered within the reply timeout is not reported by Orders,
+// but is kept as unresolved: a late ExecutionReport for it is still applied to positions and balance,
+// and the order is then reported as normal.
+type Dealer struct {
+	// InitialCapital is the trade balance before any realized profit and loss.
+	InitialCapital decimal.Decimal
+
+	// ReplyTimeout is the maximum time to wait for an ExecutionReport. Defaults to 10 seconds.
+	ReplyTimeout time.Duration
+
+	config  SessionConfig
+	session *Session
+
+	mu      sync.Mutex
+	orders  []broker.Order
+	index   map[string]int
+	cancels map[string]string
+	sent    map[int]string
+	waiters map[string]*waiter
+	ledger  *ledger
+
+	// unresolved are orders without a response from the venue when PlaceOrder returned, keyed by ClOrdID
+	unresolved map[string]broker.Order
+}
+
+// waiter is notified when an order or cancel request reaches the state awaited by the caller.
+type waiter struct {
+	untilClosed bool
+	ch          chan error
+}
+
+// NewDealer creates a new Dealer for the given session config. Call Connect before placing orders.
+func NewDealer(config SessionConfig) *Dealer {
+	return &Dealer{
+		ReplyTimeout: _defaultReplyTimeout,
+		config:       config,
+		index:        make(map[string]int),
+		cancels:      make(map[string]string),
+		sent:         make(map[int]string),
+		waiters:      make(map[string]*waiter),
+		ledger:       newLedger(),
+		unresolved:   make(map[string]broker.Order),
+	}
+}
+
+// Connect opens the FIX session and logs on to the counterparty.
+func (d *Dealer) Connect(ctx context.Context) error {
+	session := NewSession(d.config, d.handle)
+	if err := session.Logon(ctx); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.session = session
+	d.mu.Unlock()
+	return nil
+}
+
+// Disconnect logs out of the FIX session.
+func (d *Dealer) Disconnect(ctx context.Context) error {
+	session := d.getSession()
+	if session == nil {
+		return nil
+	}
+	return session.Logout(ctx)
+}
+
+// Session returns the underlying FIX session, or nil if not connected.
+func (d *Dealer) Session() *Session {
+	return d.getSession()
+}
+
+// GetBalance returns the initial capital plus realized profit as the trade balance,
+// and the trade balance plus unrealized profit as equity.
+func (d *Dealer) GetBalance(ctx context.Context) (*broker.AccountBalance, *web.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	trade := d.InitialCapital.Add(d.ledger.realized)
+	return &broker.AccountBalance{
+		Trade:  trade,
+		Equity: trade.Add(d.ledger.unrealized()),
+	}, nil, nil
+}
+
+// PlaceOrder sends the order as a NewOrderSingle and waits for the venue to respond.
+// A new ID is assigned to the order and used as ClOrdID.
+func (d *Dealer) PlaceOrder(ctx context.Context, order broker.Order) (*broker.Order, *web.Response, error) {
+	session := d.getSession()
+	if session == nil {
+		return nil, nil, ErrNotLoggedOn
+	}
+	if order.State() != broker.OrderPending || !order.Size.IsPositive() {
+		return nil, nil, ErrUnsupportedOrder
+	}
+
+	order.ID = broker.NewID()
+	msg, err := NewOrderSingle(order, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	clOrdID := string(order.ID)
+
+	w := &waiter{untilClosed: order.Type == broker.Market, ch: make(chan error, 1)}
+	d.mu.Lock()
+	d.orders = append(d.orders, order)
+	d.index[clOrdID] = len(d.orders) - 1
+	d.waiters[clOrdID] = w
+	d.mu.Unlock()
+
+	if err := d.send(session, clOrdID, msg); err != nil {
+		d.detachPendingOrder(clOrdID)
+		return nil, nil, err
+	}
+	if err := d.await(ctx, session, clOrdID, w); err != nil {
+		d.detachPendingOrder(clOrdID)
+		return nil, nil, err
+	}
+
+	d.mu.Lock()
+	placed := d.orders[d.index[clOrdID]]
+	d.mu.Unlock()
+
+	return &placed, nil, nil
+}
+
+// CancelOrders sends an OrderCancelRequest for each open order and waits for the venue to respond.
+// A cancel rejected by the venue, for example because the order has already been filled, is not an error.
+func (d *Dealer) CancelOrders(ctx context.Context) (*web.Response, error) {
+	session := d.getSession()
+	if session == nil {
+		return nil, ErrNotLoggedOn
+	}
+
+	type pending struct {
+		id string
+		w  *waiter
+	}
+	var requests []pending
+	var msgs []Message
+
+	d.mu.Lock()
+	for i := range d.orders {
+		order := d.orders[i]
+		if order.State() != broker.OrderOpen {
+			continue
+		}
+		cancelID := string(broker.NewID())
+		msg, err := OrderCancelRequest(order, cancelID, time.Now())
+		if err != nil {
+			d.mu.Unlock()
+			return nil, err
+		}
+		w := &waiter{ch: make(chan error, 1)}
+		d.cancels[cancelID] = string(order.ID)
+		d.waiters[cancelID] = w
+		requests = append(requests, pending{id: cancelID, w: w})
+		msgs = append(msgs, msg)
+	}
+	d.mu.Unlock()
+
+	for i := range requests {
+		if err := d.send(session, requests[i].id, msgs[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range requests {
+		if err := d.await(ctx, session, requests[i].id, requests[i].w); err != nil && !errors.Is(err, ErrOrderRejected) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// ListPositions returns all historical (closed) and open positions derived from executions.
+func (d *Dealer) ListPositions(ctx context.Context, opts *web.ListOpts) ([]broker.Position, *web.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copied := make([]broker.Position, len(d.ledger.positions))
+	copy(copied, d.ledger.positions)
+	return copied, nil, nil
+}
+
+// ListRoundTurns returns all historical round-turns derived from executions.
+func (d *Dealer) ListRoundTurns(ctx context.Context, opts *web.ListOpts) ([]broker.RoundTurn, *web.Response, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copied := make([]broker.RoundTurn, len(d.ledger.roundturns))
+	copy(copied, d.ledger.roundturns)
+	return copied, nil, nil
+}
+
+// Orders returns a copy of all historical and open orders placed in this session.
+func (d *Dealer) Orders() []broker.Order {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	copied := make([]broker.Order, len(d.orders))
+	copy(copied, d.orders)
+	return copied
+}
+
+// detachPendingOrder moves an order that the venue has not responded to, after a failed send or wait,
+// to the unresolved orders so that it is not reported as an open order.
+// An order the venue has acknowledged or rejected is kept.
+func (d *Dealer) detachPendingOrder(clOrdID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i, ok := d.index[clOrdID]
+	if !ok || d.orders[i].State() != broker.OrderPending {
+		return
+	}
+	d.unresolved[clOrdID] = d.orders[i]
+	d.orders = append(d.orders[:i], d.orders[i+1:]...)
+	delete(d.index, clOrdID)
+	for id, j := range d.index {
+		if j > i {
+			d.index[id] = j - 1
+		}
+	}
+}
+
+func (d *Dealer) getSession() *Session {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.session
+}
+
+func (d *Dealer) send(session *Session, clOrdID string, msg Message) error {
+	seq, err := session.Send(msg)
+	if err != nil {
+		d.mu.Lock()
+		delete(d.waiters, clOrdID)
+		d.mu.Unlock()
+		return err
+	}
+	d.mu.Lock()
+	d.sent[seq] = clOrdID
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *Dealer) await(ctx context.Context, session *Session, clOrdID string, w *waiter) error {
+	timeout := time.NewTimer(d.ReplyTimeout)
+	defer timeout.Stop()
+
+	var err error
+	select {
+	case err = <-w.ch:
+		return err
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout.C:
+		err = ErrReplyTimeout
+	case <-session.Done():
+		err = session.Err()
+	}
+
+	d.mu.Lock()
+	delete(d.waiters, clOrdID)
+	d.mu.Unlock()
+
+	return err
+}
+
+// handle is the session Handler for application messages.
+func (d *Dealer) handle(msg Message) {
+	switch msg.MsgType() {
+	case MsgTypeExecutionReport:
+		exec, err := ParseExecutionReport(msg)
+		if err != nil {
+			return
+		}
+		d.onExecution(exec)
+	case MsgTypeOrderCancelReject:
+		clOrdID, _ := msg.Get(TagClOrdID)
+		text, _ := msg.Get(TagText)
+		d.notify(clOrdID, fmt.Errorf("%w: cancel: %s", ErrOrderRejected, text))
+	case MsgTypeReject:
+		ref, err := msg.GetInt(TagRefSeqNum)
+		if err != nil {
+			return
+		}
+		text, _ := msg.Get(TagText)
+		d.mu.Lock()
+		clOrdID := d.sent[ref]
+		d.mu.Unlock()
+		d.notify(clOrdID, fmt.Errorf("%w: session: %s", ErrOrderRejected, text))
+	}
+}
+
+func (d *Dealer) onExecution(exec Execution) {
+	d.mu.Lock()
+
+	clOrdID := exec.ClOrdID
+	if orig, ok := d.cancels[clOrdID]; ok {
+		clOrdID = orig
+	} else if _, ok := d.index[clOrdID]; !ok && exec.OrigClOrdID != "" {
+		clOrdID = exec.OrigClOrdID
+	}
+	if order, ok := d.unresolved[clOrdID]; ok {
+		// The venue has answered an order after PlaceOrder gave up waiting, so report it from now on
+		delete(d.unresolved, clOrdID)
+		d.orders = append(d.orders, order)
+		d.index[clOrdID] = len(d.orders) - 1
+	}
+	i, ok := d.index[clOrdID]
+	if !ok {
+		d.mu.Unlock()
+		return
+	}
+
+	order := d.orders[i]
+	if exec.ExecType == ExecTypeTrade {
+		d.ledger.apply(order.Asset, order.Side, exec.LastQty, exec.LastPx, exec.Commission, exec.TransactAt, order.ID)
+	}
+	order = ApplyExecution(order, exec)
+	d.orders[i] = order
+	d.mu.Unlock()
+
+	switch {
+	case exec.OrdStatus == OrdStatusRejected:
+		d.notify(exec.ClOrdID, fmt.Errorf("%w: %s", ErrOrderRejected, exec.Text))
+	case exec.ClOrdID != clOrdID:
+		// Response to a cancel request
+		d.notify(exec.ClOrdID, nil)
+	default:
+		d.mu.Lock()
+		w, ok := d.waiters[clOrdID]
+		d.mu.Unlock()
+		if ok && (!w.untilClosed || order.State() == broker.OrderClosed) {
+			d.notify(clOrdID, nil)
+		}
+	}
+}
+
+func (d *Dealer) notify(clOrdID string, err error) {
+	d.mu.Lock()
+	w, ok := d.waiters[clOrdID]
+	delete(d.waiters, clOrdID)
+	d.mu.Unlock()
+	if ok {
+		w.ch <- err
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// newDealerForTest connects a dealer to a local stub acceptor.
+func newDealerForTest(t *testing.T) (*Dealer, *StubAcceptor) {
+	acceptor := NewStubAcceptor("VENUE", "ALPHAKIT")
+	acceptor.MarketPrice = dec.New(100)
+	acceptor.CommissionPct = dec.New(0.001)
+	acceptor.RejectSymbol = "BANNED"
+	addr, err := acceptor.Listen()
+	require.NoError(t, err)
+
+	dealer := NewDealer(SessionConfig{
+		Addr:         addr,
+		SenderCompID: "ALPHAKIT",
+		TargetCompID: "VENUE",
+		HeartBtInt:   time.Second,
+	})
+	dealer.InitialCapital = dec.New(1000)
+	dealer.ReplyTimeout = 5 * time.Second
+	require.NoError(t, dealer.Connect(context.Background()))
+
+	t.Cleanup(func() {
+		assert.NoError(t, dealer.Disconnect(context.Background()))
+		assert.NoError(t, acceptor.Close())
+	})
+
+	return dealer, acceptor
+}
+
+func TestDealer_PlaceMarketOrder(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	ctx := context.Background()
+	asset := market.NewAsset("BTCUSD")
+
+	placed, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(asset, broker.Buy, dec.New(2)))
+	require.NoError(t, err)
+	assert.EqualValues(t, broker.OrderClosed, placed.State())
+	assert.True(t, placed.FilledPrice.Equal(dec.New(100)))
+	assert.True(t, placed.FilledSize.Equal(dec.New(2)))
+	assert.True(t, placed.Fee.Equal(dec.New(0.2)))
+
+	positions, _, err := dealer.ListPositions(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.EqualValues(t, broker.PositionOpen, positions[0].State())
+
+	acceptor.MarketPrice = dec.New(110)
+	_, _, err = dealer.PlaceOrder(ctx, broker.NewOrder(asset, broker.Sell, dec.New(2)))
+	require.NoError(t, err)
+
+	roundturns, _, err := dealer.ListRoundTurns(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, roundturns, 1)
+	assert.True(t, roundturns[0].Profit.Equal(dec.New(19.58)))
+
+	balance, _, err := dealer.GetBalance(ctx)
+	require.NoError(t, err)
+	assert.True(t, balance.Trade.Equal(dec.New(1019.58)))
+	assert.True(t, balance.Equity.Equal(balance.Trade))
+}
+
+func TestDealer_LimitOrderCancelAndFill(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	ctx := context.Background()
+	asset := market.NewAsset("BTCUSD")
+
+	limit := broker.NewOrder(asset, broker.Buy, dec.New(1))
+	limit.Type = broker.Limit
+	limit.LimitPrice = dec.New(90)
+
+	placed, _, err := dealer.PlaceOrder(ctx, limit)
+	require.NoError(t, err)
+	assert.EqualValues(t, broker.OrderOpen, placed.State())
+
+	_, err = dealer.CancelOrders(ctx)
+	require.NoError(t, err)
+	orders := dealer.Orders()
+	require.Len(t, orders, 1)
+	assert.EqualValues(t, broker.OrderClosed, orders[0].State())
+	assert.True(t, orders[0].FilledAt.IsZero())
+
+	// A second limit order is filled by the venue after acknowledgement
+	placed, _, err = dealer.PlaceOrder(ctx, limit)
+	require.NoError(t, err)
+	require.NoError(t, acceptor.FillRestingOrders())
+	assert.Eventually(t, func() bool {
+		orders := dealer.Orders()
+		return orders[len(orders)-1].State() == broker.OrderClosed
+	}, 5*time.Second, 10*time.Millisecond)
+	orders = dealer.Orders()
+	assert.Equal(t, placed.ID, orders[1].ID)
+	assert.True(t, orders[1].FilledPrice.Equal(dec.New(90)))
+}
+
+func TestDealer_RejectedOrder(t *testing.T) {
+	dealer, _ := newDealerForTest(t)
+
+	_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.NewAsset("BANNED"), broker.Buy, dec.New(1)))
+	assert.ErrorIs(t, err, ErrOrderRejected)
+
+	orders := dealer.Orders()
+	require.Len(t, orders, 1)
+	assert.EqualValues(t, broker.OrderClosed, orders[0].State())
+}
+
+func TestDealer_UnansweredOrder(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	acceptor.IgnoreSymbol = "SILENT"
+	dealer.ReplyTimeout = 50 * time.Millisecond
+
+	_, _, err := dealer.PlaceOrder(context.Background(), broker.NewOrder(market.NewAsset("SILENT"), broker.Buy, dec.New(1)))
+	assert.ErrorIs(t, err, ErrReplyTimeout)
+	assert.Empty(t, dealer.Orders())
+
+	// Orders placed after the timeout are tracked as normal
+	dealer.ReplyTimeout = 5 * time.Second
+	_, _, err = dealer.PlaceOrder(context.Background(), broker.NewOrder(market.NewAsset("BTCUSD"), broker.Buy, dec.New(1)))
+	require.NoError(t, err)
+	orders := dealer.Orders()
+	require.Len(t, orders, 1)
+	assert.EqualValues(t, broker.OrderClosed, orders[0].State())
+}
+
+func TestDealer_LateFill(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	acceptor.IgnoreSymbol = "SILENT"
+	dealer.ReplyTimeout = 50 * time.Millisecond
+	ctx := context.Background()
+
+	_, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(market.NewAsset("SILENT"), broker.Buy, dec.New(2)))
+	assert.ErrorIs(t, err, ErrReplyTimeout)
+	assert.Empty(t, dealer.Orders())
+
+	// The venue fills the order after the dealer gave up waiting
+	acceptor.AnswerIgnoredOrders()
+	require.Eventually(t, func() bool {
+		orders := dealer.Orders()
+		return len(orders) == 1 && orders[0].State() == broker.OrderClosed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	positions, _, err := dealer.ListPositions(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "SILENT", positions[0].Asset.Symbol)
+	assert.True(t, positions[0].Size.Equal(dec.New(2)))
+}
+
+func TestDealer_RecoversFromInboundGap(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	ctx := context.Background()
+
+	// Acceptor skips a MsgSeqNum so the dealer must request a resend to receive the fill
+	acceptor.SkipSeqNum()
+	placed, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(market.NewAsset("BTCUSD"), broker.Buy, dec.New(1)))
+	require.NoError(t, err)
+	assert.EqualValues(t, broker.OrderClosed, placed.State())
+
+	var resendRequested bool
+	for _, msg := range acceptor.Received() {
+		if msg.MsgType() == MsgTypeResendRequest {
+			resendRequested = true
+		}
+	}
+	assert.True(t, resendRequested)
+	assert.Equal(t, acceptor.Session().NextSenderMsgSeqNum(), dealer.Session().NextTargetMsgSeqNum())
+}
+
+func TestDealer_ServicesResendRequest(t *testing.T) {
+	dealer, acceptor := newDealerForTest(t)
+	ctx := context.Background()
+
+	_, _, err := dealer.PlaceOrder(ctx, broker.NewOrder(market.NewAsset("BTCUSD"), broker.Buy, dec.New(1)))
+	require.NoError(t, err)
+
+	require.NoError(t, acceptor.RequestResend(1, 0))
+
+	// Logon is replaced by a gap fill and the order is replayed as a possible duplicate
+	assert.Eventually(t, func() bool {
+		var gapFill, replayed bool
+		for _, msg := range acceptor.Received() {
+			possDup, _ := msg.Get(TagPossDupFlag)
+			switch {
+			case msg.MsgType() == MsgTypeSequenceReset && msg.SeqNum() == 1:
+				gapFill = true
+			case msg.MsgType() == MsgTypeNewOrderSingle && possDup == "Y":
+				replayed = true
+			}
+		}
+		return gapFill && replayed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Duplicate is ignored by the acceptor and the session remains in sync
+	assert.NoError(t, acceptor.Session().Err())
+	assert.Len(t, dealer.Orders(), 1)
+}
+
+func TestDealer_Heartbeat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("heartbeat interval is at least 1 second")
+	}
+	dealer, acceptor := newDealerForTest(t)
+
+	assert.Eventually(t, func() bool {
+		for _, msg := range acceptor.Received() {
+			if msg.MsgType() == MsgTypeHeartbeat {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.NoError(t, dealer.Session().Err())
+}
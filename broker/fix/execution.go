@@ -0,0 +1,208 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// ErrUnsupportedOrder is returned when a broker.Order cannot be represented as a NewOrderSingle.
+var ErrUnsupportedOrder = errors.New("order cannot be mapped to fix")
+
+// Execution is the decoded content of an ExecutionReport(8) relevant to broker.Order.
+type Execution struct {
+	ClOrdID     string
+	OrigClOrdID string
+	OrderID     string
+	ExecID      string
+	ExecType    string
+	OrdStatus   string
+	Symbol      string
+	Side        broker.OrderSide
+	LastQty     decimal.Decimal
+	LastPx      decimal.Decimal
+	CumQty      decimal.Decimal
+	AvgPx       decimal.Decimal
+	Commission  decimal.Decimal
+	TransactAt  time.Time
+	Text        string
+}
+
+// NewOrderSingle maps a broker.Order to a NewOrderSingle(D) message using the order ID as ClOrdID.
+func NewOrderSingle(order broker.Order, transactAt time.Time) (Message, error) {
+	var empty Message
+
+	side, err := encodeSide(order.Side)
+	if err != nil {
+		return empty, err
+	}
+
+	m := NewMessage(MsgTypeNewOrderSingle)
+	m.Set(TagClOrdID, string(order.ID))
+	m.Set(TagSymbol, order.Asset.Symbol)
+	m.Set(TagSide, side)
+	m.SetTime(TagTransactTime, transactAt)
+	m.Set(TagOrderQty, order.Size.String())
+
+	switch order.Type {
+	case broker.Market:
+		m.Set(TagOrdType, OrdTypeMarket)
+		m.Set(TagTimeInForce, TimeInForceIOC)
+	case broker.Limit:
+		m.Set(TagOrdType, OrdTypeLimit)
+		m.Set(TagPrice, order.LimitPrice.String())
+		m.Set(TagTimeInForce, TimeInForceGTC)
+	default:
+		return empty, ErrUnsupportedOrder
+	}
+
+	if order.ReduceOnly {
+		m.Set(TagExecInst, ExecInstReduceOnly)
+	}
+
+	return m, nil
+}
+
+// OrderCancelRequest maps a cancel of the given open order to an OrderCancelRequest(F) message.
+// Param clOrdID identifies the cancel request itself and must be unique for the session.
+func OrderCancelRequest(order broker.Order, clOrdID string, transactAt time.Time) (Message, error) {
+	var empty Message
+
+	side, err := encodeSide(order.Side)
+	if err != nil {
+		return empty, err
+	}
+
+	m := NewMessage(MsgTypeOrderCancelRequest)
+	m.Set(TagOrigClOrdID, string(order.ID))
+	m.Set(TagClOrdID, clOrdID)
+	m.Set(TagSymbol, order.Asset.Symbol)
+	m.Set(TagSide, side)
+	m.SetTime(TagTransactTime, transactAt)
+	m.Set(TagOrderQty, order.Size.String())
+
+	return m, nil
+}
+
+// ParseExecutionReport decodes an ExecutionReport(8) message.
+func ParseExecutionReport(m Message) (Execution, error) {
+	var exec, empty Execution
+	var err error
+
+	if m.MsgType() != MsgTypeExecutionReport {
+		return empty, ErrMalformedMessage
+	}
+
+	var ok bool
+	if exec.ClOrdID, ok = m.Get(TagClOrdID); !ok {
+		return empty, ErrFieldNotFound
+	}
+	if exec.ExecType, ok = m.Get(TagExecType); !ok {
+		return empty, ErrFieldNotFound
+	}
+	if exec.OrdStatus, ok = m.Get(TagOrdStatus); !ok {
+		return empty, ErrFieldNotFound
+	}
+	exec.OrigClOrdID, _ = m.Get(TagOrigClOrdID)
+	exec.OrderID, _ = m.Get(TagOrderID)
+	exec.ExecID, _ = m.Get(TagExecID)
+	exec.Symbol, _ = m.Get(TagSymbol)
+	exec.Text, _ = m.Get(TagText)
+
+	if v, ok := m.Get(TagSide); ok {
+		if exec.Side, err = decodeSide(v); err != nil {
+			return empty, err
+		}
+	}
+
+	for _, f := range []struct {
+		tag Tag
+		dst *decimal.Decimal
+	}{
+		{TagLastQty, &exec.LastQty},
+		{TagLastPx, &exec.LastPx},
+		{TagCumQty, &exec.CumQty},
+		{TagAvgPx, &exec.AvgPx},
+		{TagCommission, &exec.Commission},
+	} {
+		v, ok := m.Get(f.tag)
+		if !ok {
+			continue
+		}
+		if *f.dst, err = decimal.NewFromString(v); err != nil {
+			return empty, ErrMalformedMessage
+		}
+	}
+
+	exec.TransactAt = time.Now().UTC()
+	if m.Has(TagTransactTime) {
+		if exec.TransactAt, err = m.GetTime(TagTransactTime); err != nil {
+			return empty, err
+		}
+	}
+
+	return exec, nil
+}
+
+// ApplyExecution updates the order with the execution and returns the new order state.
+//
+// - New: the order is opened.
+//
+// - PartiallyFilled: the filled size and average price are updated, the order remains open.
+//
+// - Filled: the order is filled and closed, mirroring how backtest.Simulator closes filled orders.
+//
+// - Canceled, Rejected or Expired: the order is closed without a fill.
+func ApplyExecution(order broker.Order, exec Execution) broker.Order {
+	if order.OpenedAt.IsZero() {
+		order.OpenedAt = exec.TransactAt
+	}
+	if exec.ExecType == ExecTypeTrade {
+		order.Fee = order.Fee.Add(exec.Commission)
+	}
+	if exec.CumQty.IsPositive() {
+		order.FilledSize = exec.CumQty
+		order.FilledPrice = exec.AvgPx
+	}
+
+	switch exec.OrdStatus {
+	case OrdStatusFilled:
+		order.FilledAt = exec.TransactAt
+		order.ClosedAt = exec.TransactAt
+	case OrdStatusCanceled, OrdStatusRejected, OrdStatusExpired:
+		order.ClosedAt = exec.TransactAt
+	}
+
+	return order
+}
+
+// Asset returns the market asset of the execution.
+func (e Execution) Asset() market.Asset {
+	return market.NewAsset(e.Symbol)
+}
+
+func encodeSide(side broker.OrderSide) (string, error) {
+	switch side {
+	case broker.Buy:
+		return SideBuy, nil
+	case broker.Sell:
+		return SideSell, nil
+	}
+	return "", ErrUnsupportedOrder
+}
+
+func decodeSide(v string) (broker.OrderSide, error) {
+	switch v {
+	case SideBuy:
+		return broker.Buy, nil
+	case SideSell:
+		return broker.Sell, nil
+	}
+	return 0, ErrMalformedMessage
+}
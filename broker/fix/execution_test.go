@@ -0,0 +1,113 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestNewOrderSingle(t *testing.T) {
+	transactAt := time.Date(2022, time.January, 1, 12, 0, 0, 0, time.UTC)
+	give := broker.Order{
+		ID:         "1",
+		Asset:      market.NewAsset("BTCUSD"),
+		Side:       broker.Sell,
+		Type:       broker.Limit,
+		LimitPrice: dec.New(100.5),
+		Size:       dec.New(2),
+		ReduceOnly: true,
+	}
+
+	act, err := NewOrderSingle(give, transactAt)
+	assert.NoError(t, err)
+	assert.Equal(t, MsgTypeNewOrderSingle, act.MsgType())
+
+	for tag, want := range map[Tag]string{
+		TagClOrdID:      "1",
+		TagSymbol:       "BTCUSD",
+		TagSide:         SideSell,
+		TagOrdType:      OrdTypeLimit,
+		TagPrice:        "100.5",
+		TagOrderQty:     "2",
+		TagExecInst:     ExecInstReduceOnly,
+		TagTransactTime: "20220101-12:00:00.000",
+	} {
+		v, ok := act.Get(tag)
+		assert.True(t, ok)
+		assert.Equal(t, want, v, "tag %d", tag)
+	}
+
+	_, err = NewOrderSingle(broker.Order{Side: broker.Buy}, transactAt)
+	assert.ErrorIs(t, err, ErrUnsupportedOrder)
+}
+
+func TestApplyExecution(t *testing.T) {
+	at := time.Date(2022, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		give Execution
+		want broker.OrderState
+	}{
+		{
+			name: "new",
+			give: Execution{ExecType: ExecTypeNew, OrdStatus: OrdStatusNew, TransactAt: at},
+			want: broker.OrderOpen,
+		},
+		{
+			name: "partially filled",
+			give: Execution{ExecType: ExecTypeTrade, OrdStatus: OrdStatusPartiallyFilled, CumQty: dec.New(1), AvgPx: dec.New(10), TransactAt: at},
+			want: broker.OrderOpen,
+		},
+		{
+			name: "filled",
+			give: Execution{ExecType: ExecTypeTrade, OrdStatus: OrdStatusFilled, CumQty: dec.New(2), AvgPx: dec.New(10), TransactAt: at},
+			want: broker.OrderClosed,
+		},
+		{
+			name: "canceled",
+			give: Execution{ExecType: ExecTypeCanceled, OrdStatus: OrdStatusCanceled, TransactAt: at},
+			want: broker.OrderClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := ApplyExecution(broker.Order{Size: dec.New(2)}, tt.give)
+			assert.Equal(t, tt.want, act.State())
+			assert.Equal(t, at, act.OpenedAt)
+			assert.True(t, act.FilledSize.Equal(tt.give.CumQty))
+		})
+	}
+}
+
+func TestLedger(t *testing.T) {
+	asset := market.NewAsset("BTCUSD")
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	l := newLedger()
+	l.apply(asset, broker.Buy, dec.New(2), dec.New(10), dec.New(0.5), start, "1")
+	l.apply(asset, broker.Buy, dec.New(2), dec.New(20), dec.New(0.5), start.Add(time.Hour), "2")
+	assert.Len(t, l.positions, 1)
+	assert.True(t, l.positions[0].EntryPrice.Equal(dec.New(15)))
+	assert.True(t, l.positions[0].PNL.Equal(dec.New(19)))
+
+	// Sell more than held to close and flip short
+	l.apply(asset, broker.Sell, dec.New(5), dec.New(25), dec.New(1), start.Add(2*time.Hour), "3")
+	assert.Len(t, l.roundturns, 1)
+	assert.True(t, l.roundturns[0].Profit.Equal(dec.New(38)))
+	assert.Equal(t, 2*time.Hour, l.roundturns[0].HoldPeriod)
+	assert.Equal(t, 3, l.roundturns[0].TradeCount)
+
+	assert.Len(t, l.positions, 2)
+	short := l.positions[1]
+	assert.EqualValues(t, broker.PositionOpen, short.State())
+	assert.Equal(t, broker.Sell, short.Side)
+	assert.True(t, short.Size.Equal(dec.New(1)))
+	assert.True(t, l.realized.Equal(dec.New(38)))
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// ledger keeps positions and round-turns from the trade executions reported by the counterparty.
+// FIX 4.4 has no position or balance query supported by all venues,
+// so the dealer derives them locally.
+// A single position per asset is tracked. A fill larger than the open position closes it
+// and opens a new position on the opposite side with the remaining size.
+// Positions are marked to the price of the latest fill.
+type ledger struct {
+	positions  []broker.Position
+	roundturns []broker.RoundTurn
+	accruals   map[string]*accrual
+	realized   decimal.Decimal
+}
+
+// accrual tracks the realized gross PNL and fees of an open position.
+type accrual struct {
+	realized decimal.Decimal
+	fees     decimal.Decimal
+}
+
+func newLedger() *ledger {
+	return &ledger{
+		accruals: make(map[string]*accrual),
+	}
+}
+
+// apply books a single trade execution of size at price.
+func (l *ledger) apply(asset market.Asset, side broker.OrderSide, size, price, fee decimal.Decimal, at time.Time, id broker.DealID) {
+	if !size.IsPositive() {
+		return
+	}
+
+	i := l.openIndex(asset)
+	if i < 0 {
+		l.positions = append(l.positions, broker.Position{
+			ID:       id,
+			OpenedAt: at,
+			Asset:    asset,
+			Side:     side,
+		})
+		l.accruals[asset.Symbol] = &accrual{}
+		i = len(l.positions) - 1
+	}
+	position := l.positions[i]
+	acc := l.accruals[asset.Symbol]
+
+	position.TradeCount++
+	acc.fees = acc.fees.Add(fee)
+
+	var remain decimal.Decimal
+	if position.Side == side {
+		notional := position.EntryPrice.Mul(position.Size).Add(price.Mul(size))
+		position.Size = position.Size.Add(size)
+		position.EntryPrice = notional.Div(position.Size)
+	} else {
+		reduce := decimal.Min(size, position.Size)
+		remain = size.Sub(reduce)
+		acc.realized = acc.realized.Add(pnl(position.Side, position.EntryPrice, price, reduce))
+		position.Size = position.Size.Sub(reduce)
+	}
+	position.Cost = position.EntryPrice.Mul(position.Size).Add(acc.fees)
+	position.MarkPrice = price
+	position.PNL = acc.realized.Add(pnl(position.Side, position.EntryPrice, price, position.Size)).Sub(acc.fees)
+
+	if position.Size.IsPositive() {
+		l.positions[i] = position
+		return
+	}
+
+	position.ClosedAt = at
+	position.ExitPrice = price
+	l.positions[i] = position
+	delete(l.accruals, asset.Symbol)
+
+	l.realized = l.realized.Add(position.PNL)
+	l.roundturns = append(l.roundturns, broker.RoundTurn{
+		ID:         position.ID,
+		CreatedAt:  at,
+		Asset:      asset,
+		Side:       position.Side,
+		Profit:     position.PNL,
+		HoldPeriod: at.Sub(position.OpenedAt),
+		TradeCount: position.TradeCount,
+	})
+
+	if remain.IsPositive() {
+		l.apply(asset, side, remain, price, decimal.Zero, at, broker.NewIDWithTime(at))
+	}
+}
+
+// unrealized returns the sum of the PNL of open positions.
+func (l *ledger) unrealized() decimal.Decimal {
+	total := decimal.Zero
+	for i := range l.positions {
+		if l.positions[i].State() == broker.PositionOpen {
+			total = total.Add(l.positions[i].PNL)
+		}
+	}
+	return total
+}
+
+func (l *ledger) openIndex(asset market.Asset) int {
+	for i := len(l.positions) - 1; i >= 0; i-- {
+		p := l.positions[i]
+		if p.Asset.Equal(asset) && p.State() == broker.PositionOpen {
+			return i
+		}
+	}
+	return -1
+}
+
+func pnl(side broker.OrderSide, entry, exit, size decimal.Decimal) decimal.Decimal {
+	diff := exit.Sub(entry).Mul(size)
+	if side == broker.Sell {
+		return diff.Mul(dec.New(-1))
+	}
+	return diff
+}
@@ -0,0 +1,265 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SOH is the field delimiter of the FIX tag=value encoding.
+const SOH = '\x01'
+
+// BeginString44 is the protocol version identifier for FIX 4.4.
+const BeginString44 = "FIX.4.4"
+
+// UTCTimestampFormat is the layout of a FIX UTCTimestamp field with millisecond precision.
+const UTCTimestampFormat = "20060102-15:04:05.000"
+
+var (
+	// ErrMalformedMessage is returned when a message does not follow the FIX tag=value encoding.
+	ErrMalformedMessage = errors.New("malformed fix message")
+
+	// ErrInvalidChecksum is returned when the CheckSum(10) field does not match the message content.
+	ErrInvalidChecksum = errors.New("invalid fix checksum")
+
+	// ErrFieldNotFound is returned when a required field is missing from a message.
+	ErrFieldNotFound = errors.New("fix field not found")
+)
+
+// Field is a single tag=value pair of a FIX message.
+type Field struct {
+	Tag   Tag
+	Value string
+}
+
+// Message is a FIX message represented as an ordered list of fields.
+// The standard header fields BeginString(8), BodyLength(9) and the trailer CheckSum(10)
+// are managed by Encode and are not stored in Fields.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage creates a new message of the given MsgType(35).
+func NewMessage(msgType string) Message {
+	return Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// MsgType returns the value of the MsgType(35) field.
+func (m Message) MsgType() string {
+	v, _ := m.Get(TagMsgType)
+	return v
+}
+
+// SeqNum returns the value of the MsgSeqNum(34) field or 0 if not present.
+func (m Message) SeqNum() int {
+	v, _ := m.GetInt(TagMsgSeqNum)
+	return v
+}
+
+// IsAdmin returns true if the message belongs to the session layer rather than the application layer.
+func (m Message) IsAdmin() bool {
+	switch m.MsgType() {
+	case MsgTypeHeartbeat, MsgTypeTestRequest, MsgTypeResendRequest, MsgTypeReject,
+		MsgTypeSequenceReset, MsgTypeLogout, MsgTypeLogon:
+		return true
+	}
+	return false
+}
+
+// Has returns true if the tag is present in the message.
+func (m Message) Has(tag Tag) bool {
+	_, ok := m.Get(tag)
+	return ok
+}
+
+// Get returns the value of the first field with the given tag.
+func (m Message) Get(tag Tag) (string, bool) {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			return m.Fields[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// GetInt returns the value of the first field with the given tag parsed as an int.
+func (m Message) GetInt(tag Tag) (int, error) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return 0, fmt.Errorf("%w: %d", ErrFieldNotFound, tag)
+	}
+	return strconv.Atoi(v)
+}
+
+// GetTime returns the value of the first field with the given tag parsed as a UTCTimestamp.
+func (m Message) GetTime(tag Tag) (time.Time, error) {
+	v, ok := m.Get(tag)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: %d", ErrFieldNotFound, tag)
+	}
+	return parseUTCTimestamp(v)
+}
+
+// Set replaces the value of the first field with the given tag, or appends a new field if not present.
+func (m *Message) Set(tag Tag, value string) {
+	for i := range m.Fields {
+		if m.Fields[i].Tag == tag {
+			m.Fields[i].Value = value
+			return
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+}
+
+// SetInt sets an int field.
+func (m *Message) SetInt(tag Tag, value int) {
+	m.Set(tag, strconv.Itoa(value))
+}
+
+// SetTime sets a UTCTimestamp field.
+func (m *Message) SetTime(tag Tag, value time.Time) {
+	m.Set(tag, value.UTC().Format(UTCTimestampFormat))
+}
+
+// Clone returns a deep copy of the message.
+func (m Message) Clone() Message {
+	fields := make([]Field, len(m.Fields))
+	copy(fields, m.Fields)
+	return Message{Fields: fields}
+}
+
+// String renders the message with '|' in place of SOH for logging.
+func (m Message) String() string {
+	var buf bytes.Buffer
+	for _, f := range m.Fields {
+		fmt.Fprintf(&buf, "%d=%s|", f.Tag, f.Value)
+	}
+	return buf.String()
+}
+
+// Encode renders the message to the FIX wire format, computing BodyLength(9) and CheckSum(10).
+// MsgType(35) is always written as the first body field.
+func Encode(beginString string, m Message) []byte {
+	var body bytes.Buffer
+	writeField := func(buf *bytes.Buffer, tag Tag, value string) {
+		buf.WriteString(strconv.Itoa(int(tag)))
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte(SOH)
+	}
+
+	writeField(&body, TagMsgType, m.MsgType())
+	for _, f := range m.Fields {
+		switch f.Tag {
+		case TagBeginString, TagBodyLength, TagCheckSum, TagMsgType:
+			continue
+		}
+		writeField(&body, f.Tag, f.Value)
+	}
+
+	var out bytes.Buffer
+	writeField(&out, TagBeginString, beginString)
+	writeField(&out, TagBodyLength, strconv.Itoa(body.Len()))
+	out.Write(body.Bytes())
+	writeField(&out, TagCheckSum, fmt.Sprintf("%03d", checksum(out.Bytes())))
+
+	return out.Bytes()
+}
+
+// Decode reads the next message from r.
+// BeginString(8), BodyLength(9) and CheckSum(10) are validated and stripped from the returned message.
+func Decode(r *bufio.Reader) (Message, error) {
+	var empty Message
+
+	head, err := r.ReadBytes(SOH)
+	if err != nil {
+		return empty, err
+	}
+	if !bytes.HasPrefix(head, []byte("8=")) {
+		return empty, ErrMalformedMessage
+	}
+
+	lengthField, err := r.ReadBytes(SOH)
+	if err != nil {
+		return empty, err
+	}
+	if !bytes.HasPrefix(lengthField, []byte("9=")) {
+		return empty, ErrMalformedMessage
+	}
+	length, err := strconv.Atoi(string(lengthField[2 : len(lengthField)-1]))
+	if err != nil || length < 0 {
+		return empty, ErrMalformedMessage
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return empty, err
+	}
+
+	trailer, err := r.ReadBytes(SOH)
+	if err != nil {
+		return empty, err
+	}
+	if !bytes.HasPrefix(trailer, []byte("10=")) {
+		return empty, ErrMalformedMessage
+	}
+	want, err := strconv.Atoi(string(trailer[3 : len(trailer)-1]))
+	if err != nil {
+		return empty, ErrMalformedMessage
+	}
+
+	sum := checksum(head) + checksum(lengthField) + checksum(body)
+	if sum%256 != want {
+		return empty, ErrInvalidChecksum
+	}
+
+	return parseBody(body)
+}
+
+func parseBody(body []byte) (Message, error) {
+	var m Message
+	for len(body) > 0 {
+		end := bytes.IndexByte(body, SOH)
+		if end < 0 {
+			return Message{}, ErrMalformedMessage
+		}
+		eq := bytes.IndexByte(body[:end], '=')
+		if eq <= 0 {
+			return Message{}, ErrMalformedMessage
+		}
+		tag, err := strconv.Atoi(string(body[:eq]))
+		if err != nil {
+			return Message{}, ErrMalformedMessage
+		}
+		m.Fields = append(m.Fields, Field{Tag: Tag(tag), Value: string(body[eq+1 : end])})
+		body = body[end+1:]
+	}
+	if m.MsgType() == "" {
+		return Message{}, ErrMalformedMessage
+	}
+	return m, nil
+}
+
+func checksum(b []byte) int {
+	var sum int
+	for i := range b {
+		sum += int(b[i])
+	}
+	return sum % 256
+}
+
+func parseUTCTimestamp(v string) (time.Time, error) {
+	for _, layout := range []string{UTCTimestampFormat, "20060102-15:04:05"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid UTCTimestamp '%s'", ErrMalformedMessage, v)
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	give := NewMessage(MsgTypeLogon)
+	give.Set(TagSenderCompID, "SERVER")
+	give.Set(TagTargetCompID, "CLIENT")
+	give.SetInt(TagMsgSeqNum, 177)
+	give.Set(TagSendingTime, "20090107-18:15:16")
+	give.Set(TagEncryptMethod, "0")
+	give.SetInt(TagHeartBtInt, 30)
+
+	want := "8=FIX.4.2|9=65|35=A|49=SERVER|56=CLIENT|34=177|52=20090107-18:15:16|98=0|108=30|10=062|"
+	act := strings.ReplaceAll(string(Encode("FIX.4.2", give)), string(SOH), "|")
+	assert.Equal(t, want, act)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		give string
+		want Message
+		err  error
+	}{
+		{
+			name: "valid message",
+			give: "8=FIX.4.2|9=65|35=A|49=SERVER|56=CLIENT|34=177|52=20090107-18:15:16|98=0|108=30|10=062|",
+			want: Message{Fields: []Field{
+				{TagMsgType, "A"},
+				{TagSenderCompID, "SERVER"},
+				{TagTargetCompID, "CLIENT"},
+				{TagMsgSeqNum, "177"},
+				{TagSendingTime, "20090107-18:15:16"},
+				{TagEncryptMethod, "0"},
+				{TagHeartBtInt, "30"},
+			}},
+		},
+		{
+			name: "invalid checksum",
+			give: "8=FIX.4.2|9=65|35=A|49=SERVER|56=CLIENT|34=177|52=20090107-18:15:16|98=0|108=30|10=063|",
+			err:  ErrInvalidChecksum,
+		},
+		{
+			name: "missing begin string",
+			give: "9=65|35=A|10=000|",
+			err:  ErrMalformedMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := strings.ReplaceAll(tt.give, "|", string(SOH))
+			act, err := Decode(bufio.NewReader(strings.NewReader(raw)))
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.want, act)
+		})
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	give := NewMessage(MsgTypeNewOrderSingle)
+	give.Set(TagClOrdID, "abc")
+	give.Set(TagSymbol, "BTCUSD")
+	give.Set(TagSide, SideBuy)
+	give.Set(TagOrderQty, "1.5")
+
+	var buf bytes.Buffer
+	buf.Write(Encode(BeginString44, give))
+	buf.Write(Encode(BeginString44, NewMessage(MsgTypeHeartbeat)))
+	reader := bufio.NewReader(&buf)
+
+	act, err := Decode(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, give, act)
+
+	act, err = Decode(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, MsgTypeHeartbeat, act.MsgType())
+	assert.True(t, act.IsAdmin())
+}
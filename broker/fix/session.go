@@ -0,0 +1,534 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const _defaultHeartBtInt = 30 * time.Second
+
+const _writeTimeout = 5 * time.Second
+
+var (
+	// ErrNotLoggedOn is returned when sending an application message before logon has completed.
+	ErrNotLoggedOn = errors.New("fix session not logged on")
+
+	// ErrSessionClosed is returned when operating on a session that has been terminated.
+	ErrSessionClosed = errors.New("fix session closed")
+
+	// ErrLogonRejected is returned when the counterparty responds to a Logon with a Logout.
+	ErrLogonRejected = errors.New("fix logon rejected")
+
+	// ErrSeqNumTooLow is returned when a message is received with a MsgSeqNum lower than expected and no PossDupFlag.
+	ErrSeqNumTooLow = errors.New("fix MsgSeqNum too low")
+
+	// ErrHeartbeatTimeout is returned when the counterparty fails to respond to a TestRequest.
+	ErrHeartbeatTimeout = errors.New("fix heartbeat timeout")
+)
+
+// SessionConfig identifies a FIX session and its counterparty.
+type SessionConfig struct {
+	// Addr is the host:port of the counterparty acceptor.
+	Addr string
+
+	// BeginString is the protocol version. Defaults to FIX.4.4.
+	BeginString string
+
+	// SenderCompID identifies this side of the session.
+	SenderCompID string
+
+	// TargetCompID identifies the counterparty.
+	TargetCompID string
+
+	// HeartBtInt is the heartbeat interval negotiated at logon. Defaults to 30 seconds.
+	HeartBtInt time.Duration
+}
+
+// Handler is called with each application message received from the counterparty,
+// in MsgSeqNum order and from a single goroutine.
+type Handler func(Message)
+
+// Session is a FIX session over a single TCP connection.
+// It manages logon and logout, outbound and inbound sequence numbers, heartbeats and test requests,
+// and gap recovery with ResendRequest and SequenceReset.
+// Sent messages are kept in memory so they can be replayed on a ResendRequest.
+// Sequence numbers start at 1 for every connection and the Logon carries ResetSeqNumFlag(141)=Y,
+// so a Session must not be reused after it has terminated.
+type Session struct {
+	config    SessionConfig
+	handler   Handler
+	initiator bool
+	monitor   func(Message)
+
+	mu            sync.Mutex
+	conn          net.Conn
+	nextOutSeq    int
+	nextInSeq     int
+	store         map[int]Message
+	lastSentAt    time.Time
+	lastRecvAt    time.Time
+	testReqID     string
+	gapEndSeq     int
+	loggedOn      bool
+	loggingOut    bool
+	logonCh       chan error
+	done          chan struct{}
+	terminateOnce sync.Once
+	err           error
+}
+
+// NewSession creates a new initiator session. Call Logon to connect to the counterparty.
+func NewSession(config SessionConfig, handler Handler) *Session {
+	if config.BeginString == "" {
+		config.BeginString = BeginString44
+	}
+	if config.HeartBtInt <= 0 {
+		config.HeartBtInt = _defaultHeartBtInt
+	}
+	if handler == nil {
+		handler = func(Message) {}
+	}
+	return &Session{
+		config:     config,
+		handler:    handler,
+		initiator:  true,
+		nextOutSeq: 1,
+		nextInSeq:  1,
+		store:      make(map[int]Message),
+		logonCh:    make(chan error, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// newAcceptorSession creates a session that awaits a Logon from the counterparty.
+// Call start with the accepted connection to begin processing.
+func newAcceptorSession(config SessionConfig, handler Handler, monitor func(Message)) *Session {
+	s := NewSession(config, handler)
+	s.initiator = false
+	s.monitor = monitor
+	return s
+}
+
+// Logon dials the counterparty, sends a Logon and waits for the Logon response.
+func (s *Session) Logon(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	s.start(conn)
+
+	logon := NewMessage(MsgTypeLogon)
+	logon.Set(TagEncryptMethod, "0")
+	logon.SetInt(TagHeartBtInt, int(s.config.HeartBtInt.Seconds()))
+	logon.Set(TagResetSeqNumFlag, "Y")
+	s.mu.Lock()
+	err = s.sendLocked(logon)
+	s.mu.Unlock()
+	if err != nil {
+		s.terminate(err)
+		return err
+	}
+
+	select {
+	case err := <-s.logonCh:
+		return err
+	case <-ctx.Done():
+		s.terminate(ctx.Err())
+		return ctx.Err()
+	}
+}
+
+// Logout sends a Logout and waits for the counterparty to confirm before closing the connection.
+func (s *Session) Logout(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.loggedOn {
+		s.mu.Unlock()
+		s.terminate(nil)
+		return nil
+	}
+	s.loggingOut = true
+	err := s.sendLocked(NewMessage(MsgTypeLogout))
+	s.mu.Unlock()
+	if err != nil {
+		s.terminate(err)
+		return err
+	}
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		s.terminate(ctx.Err())
+	}
+	if errors.Is(s.Err(), ErrSessionClosed) {
+		return nil
+	}
+	return s.Err()
+}
+
+// Send sends an application message, stamping the standard header fields.
+// Returned is the MsgSeqNum assigned to the message.
+func (s *Session) Send(m Message) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedOn {
+		return 0, ErrNotLoggedOn
+	}
+	seq := s.nextOutSeq
+	return seq, s.sendLocked(m)
+}
+
+// Done is closed when the session terminates.
+func (s *Session) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the reason the session terminated, or nil if still active.
+func (s *Session) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// NextSenderMsgSeqNum returns the MsgSeqNum that will be used for the next outbound message.
+func (s *Session) NextSenderMsgSeqNum() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextOutSeq
+}
+
+// NextTargetMsgSeqNum returns the MsgSeqNum expected on the next inbound message.
+func (s *Session) NextTargetMsgSeqNum() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextInSeq
+}
+
+func (s *Session) start(conn net.Conn) {
+	s.mu.Lock()
+	s.conn = conn
+	s.lastRecvAt = time.Now()
+	s.lastSentAt = time.Now()
+	s.mu.Unlock()
+
+	go s.readLoop()
+	go s.heartbeatLoop()
+}
+
+func (s *Session) readLoop() {
+	reader := bufio.NewReader(s.conn)
+	for {
+		msg, err := Decode(reader)
+		if err != nil {
+			s.terminate(err)
+			return
+		}
+		if s.monitor != nil {
+			s.monitor(msg)
+		}
+		if app, ok := s.receive(msg); ok {
+			s.handler(app)
+		}
+	}
+}
+
+func (s *Session) heartbeatLoop() {
+	for {
+		s.mu.Lock()
+		interval := s.config.HeartBtInt
+		s.mu.Unlock()
+
+		tick := interval / 4
+		if tick < 10*time.Millisecond {
+			tick = 10 * time.Millisecond
+		}
+		select {
+		case <-s.done:
+			return
+		case <-time.After(tick):
+		}
+
+		s.mu.Lock()
+		if !s.loggedOn {
+			s.mu.Unlock()
+			continue
+		}
+		now := time.Now()
+		var err error
+		switch {
+		case s.testReqID != "" && now.Sub(s.lastRecvAt) >= 2*interval:
+			err = ErrHeartbeatTimeout
+		case s.testReqID == "" && now.Sub(s.lastRecvAt) >= interval+interval/5:
+			s.testReqID = strconv.FormatInt(now.UnixNano(), 10)
+			req := NewMessage(MsgTypeTestRequest)
+			req.Set(TagTestReqID, s.testReqID)
+			err = s.sendLocked(req)
+		case now.Sub(s.lastSentAt) >= interval:
+			err = s.sendLocked(NewMessage(MsgTypeHeartbeat))
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			s.terminate(err)
+			return
+		}
+	}
+}
+
+// receive applies session level processing to an inbound message.
+// Returns the message and true if it should be passed to the application handler.
+func (s *Session) receive(msg Message) (Message, bool) {
+	var empty Message
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastRecvAt = time.Now()
+	s.testReqID = ""
+
+	seq := msg.SeqNum()
+	msgType := msg.MsgType()
+	gapFill, _ := msg.Get(TagGapFillFlag)
+
+	// SequenceReset in reset mode ignores MsgSeqNum
+	if msgType == MsgTypeSequenceReset && gapFill != "Y" {
+		if newSeq, err := msg.GetInt(TagNewSeqNo); err == nil && newSeq > s.nextInSeq {
+			s.nextInSeq = newSeq
+		}
+		return empty, false
+	}
+
+	switch {
+	case seq > s.nextInSeq:
+		// Gap detected: the missing messages and this one are requested again
+		// A Logon or ResendRequest is still actioned so that recovery can complete in both directions
+		switch msgType {
+		case MsgTypeLogon:
+			s.onLogonLocked(msg)
+		case MsgTypeResendRequest:
+			s.onResendRequestLocked(msg)
+		case MsgTypeLogout:
+			s.onLogoutLocked(msg)
+			return empty, false
+		}
+		if s.gapEndSeq == 0 {
+			req := NewMessage(MsgTypeResendRequest)
+			req.SetInt(TagBeginSeqNo, s.nextInSeq)
+			req.SetInt(TagEndSeqNo, 0)
+			s.gapEndSeq = seq
+			s.failLocked(s.sendLocked(req))
+		}
+		if msgType == MsgTypeLogon {
+			// Logon is not replayed so it is the high-water mark of the gap
+			s.gapEndSeq = seq
+		}
+		return empty, false
+	case seq < s.nextInSeq:
+		if possDup, _ := msg.Get(TagPossDupFlag); possDup == "Y" {
+			return empty, false
+		}
+		s.failLocked(fmt.Errorf("%w: got %d want %d", ErrSeqNumTooLow, seq, s.nextInSeq))
+		return empty, false
+	}
+
+	s.nextInSeq++
+	if s.gapEndSeq != 0 && s.nextInSeq > s.gapEndSeq {
+		s.gapEndSeq = 0
+	}
+
+	switch msgType {
+	case MsgTypeLogon:
+		s.onLogonLocked(msg)
+	case MsgTypeHeartbeat:
+		// Receipt time already recorded
+	case MsgTypeTestRequest:
+		hb := NewMessage(MsgTypeHeartbeat)
+		if id, ok := msg.Get(TagTestReqID); ok {
+			hb.Set(TagTestReqID, id)
+		}
+		s.failLocked(s.sendLocked(hb))
+	case MsgTypeResendRequest:
+		s.onResendRequestLocked(msg)
+	case MsgTypeSequenceReset:
+		if newSeq, err := msg.GetInt(TagNewSeqNo); err == nil && newSeq > s.nextInSeq {
+			s.nextInSeq = newSeq
+		}
+	case MsgTypeLogout:
+		s.onLogoutLocked(msg)
+	default:
+		// Application messages and session level Reject are passed to the handler
+		return msg, true
+	}
+
+	return empty, false
+}
+
+func (s *Session) onLogonLocked(msg Message) {
+	if s.loggedOn {
+		return
+	}
+	if !s.initiator {
+		if hb, err := msg.GetInt(TagHeartBtInt); err == nil && hb > 0 {
+			s.config.HeartBtInt = time.Duration(hb) * time.Second
+		}
+		reply := NewMessage(MsgTypeLogon)
+		reply.Set(TagEncryptMethod, "0")
+		reply.SetInt(TagHeartBtInt, int(s.config.HeartBtInt.Seconds()))
+		if err := s.sendLocked(reply); err != nil {
+			s.failLocked(err)
+			return
+		}
+	}
+	s.loggedOn = true
+	s.logonCh <- nil
+}
+
+func (s *Session) onLogoutLocked(msg Message) {
+	if !s.loggedOn {
+		text, _ := msg.Get(TagText)
+		s.failLocked(fmt.Errorf("%w: %s", ErrLogonRejected, text))
+		return
+	}
+	if !s.loggingOut {
+		// Counterparty initiated logout so confirm before disconnecting
+		_ = s.sendLocked(NewMessage(MsgTypeLogout))
+	}
+	s.failLocked(ErrSessionClosed)
+}
+
+// onResendRequestLocked replays stored application messages with PossDupFlag set.
+// Session level messages are not replayed and are replaced by a SequenceReset in gap fill mode.
+func (s *Session) onResendRequestLocked(msg Message) {
+	begin, err := msg.GetInt(TagBeginSeqNo)
+	if err != nil {
+		return
+	}
+	end, err := msg.GetInt(TagEndSeqNo)
+	if err != nil || end == 0 || end >= s.nextOutSeq {
+		end = s.nextOutSeq - 1
+	}
+
+	gapStart := 0
+	flushGap := func(next int) error {
+		if gapStart == 0 {
+			return nil
+		}
+		reset := NewMessage(MsgTypeSequenceReset)
+		reset.Set(TagGapFillFlag, "Y")
+		reset.SetInt(TagNewSeqNo, next)
+		seq := gapStart
+		gapStart = 0
+		return s.writeLocked(reset, seq, true)
+	}
+
+	for seq := begin; seq <= end; seq++ {
+		stored, ok := s.store[seq]
+		if !ok || stored.IsAdmin() {
+			if gapStart == 0 {
+				gapStart = seq
+			}
+			continue
+		}
+		if err := flushGap(seq); err != nil {
+			s.failLocked(err)
+			return
+		}
+		if err := s.writeLocked(stored, seq, true); err != nil {
+			s.failLocked(err)
+			return
+		}
+	}
+	s.failLocked(flushGap(end + 1))
+}
+
+// sendLocked stamps the header with the next outbound MsgSeqNum and writes the message.
+func (s *Session) sendLocked(m Message) error {
+	seq := s.nextOutSeq
+	if err := s.writeLocked(m, seq, false); err != nil {
+		return err
+	}
+	s.nextOutSeq++
+	return nil
+}
+
+func (s *Session) writeLocked(m Message, seq int, possDup bool) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.conn == nil {
+		return ErrNotLoggedOn
+	}
+
+	out := NewMessage(m.MsgType())
+	out.Set(TagSenderCompID, s.config.SenderCompID)
+	out.Set(TagTargetCompID, s.config.TargetCompID)
+	out.SetInt(TagMsgSeqNum, seq)
+	now := time.Now()
+	if possDup {
+		out.Set(TagPossDupFlag, "Y")
+		orig, ok := m.Get(TagSendingTime)
+		if !ok {
+			orig = now.UTC().Format(UTCTimestampFormat)
+		}
+		out.Set(TagOrigSendingTime, orig)
+	}
+	out.SetTime(TagSendingTime, now)
+	for _, f := range m.Fields {
+		switch f.Tag {
+		case TagMsgType, TagSenderCompID, TagTargetCompID, TagMsgSeqNum,
+			TagPossDupFlag, TagOrigSendingTime, TagSendingTime:
+			continue
+		}
+		out.Fields = append(out.Fields, f)
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(_writeTimeout))
+	if _, err := s.conn.Write(Encode(s.config.BeginString, out)); err != nil {
+		return err
+	}
+	s.lastSentAt = now
+	if !possDup {
+		s.store[seq] = out
+	}
+	return nil
+}
+
+// failLocked records the first error and closes the connection, which in turn stops the read loop.
+// Must be called with the lock held. A nil error is ignored.
+func (s *Session) failLocked(err error) {
+	if err == nil || s.err != nil {
+		return
+	}
+	s.err = err
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+// terminate records the error (if first) and releases all resources and waiters.
+func (s *Session) terminate(err error) {
+	s.mu.Lock()
+	if err == nil {
+		err = ErrSessionClosed
+	}
+	s.failLocked(err)
+	err = s.err
+	s.mu.Unlock()
+
+	s.terminateOnce.Do(func() {
+		select {
+		case s.logonCh <- err:
+		default:
+		}
+		close(s.done)
+	})
+}
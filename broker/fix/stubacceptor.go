@@ -0,0 +1,296 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StubAcceptor is a minimal FIX 4.4 acceptor that stands in for a venue to enable offline integration tests.
+// It accepts a single session at a time on a local port.
+// Market orders are filled in full at MarketPrice. Limit orders are acknowledged and rest until cancelled,
+// or until FillRestingOrders is called. Orders for RejectSymbol are rejected.
+// For a discussion of various types of 'test double' see:
+// https://www.martinfowler.com/articles/mocksArentStubs.html
+type StubAcceptor struct {
+	// MarketPrice is the fill price for market orders.
+	MarketPrice decimal.Decimal
+
+	// CommissionPct is the fraction of the fill notional reported as Commission(12).
+	CommissionPct decimal.Decimal
+
+	// RejectSymbol is a symbol for which all orders are rejected.
+	RejectSymbol string
+
+	// IgnoreSymbol is a symbol for which orders are accepted at the session level but not answered
+	// until AnswerIgnoredOrders is called.
+	IgnoreSymbol string
+
+	config   SessionConfig
+	listener net.Listener
+
+	mu       sync.Mutex
+	session  *Session
+	resting  []Message
+	ignored  []Message
+	received []Message
+	execSeq  int
+}
+
+// NewStubAcceptor creates a new acceptor using the given CompIDs from its own point of view.
+// An initiator must use the reverse: SenderCompID = targetCompID and TargetCompID = senderCompID.
+func NewStubAcceptor(senderCompID, targetCompID string) *StubAcceptor {
+	return &StubAcceptor{
+		config: SessionConfig{
+			SenderCompID: senderCompID,
+			TargetCompID: targetCompID,
+		},
+	}
+}
+
+// Listen starts accepting connections on a free local port and returns the address.
+func (a *StubAcceptor) Listen() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	a.listener = listener
+	go a.acceptLoop()
+	return listener.Addr().String(), nil
+}
+
+// Close stops listening and closes the active session.
+func (a *StubAcceptor) Close() error {
+	if session := a.Session(); session != nil {
+		session.terminate(nil)
+	}
+	if a.listener == nil {
+		return nil
+	}
+	return a.listener.Close()
+}
+
+// Session returns the active session or nil if no initiator has connected.
+func (a *StubAcceptor) Session() *Session {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.session
+}
+
+// Received returns a copy of all messages received from the initiator, including session messages and duplicates.
+func (a *StubAcceptor) Received() []Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	copied := make([]Message, len(a.received))
+	copy(copied, a.received)
+	return copied
+}
+
+// SkipSeqNum increments the outbound MsgSeqNum without sending a message,
+// creating a gap that the initiator must recover with a ResendRequest.
+func (a *StubAcceptor) SkipSeqNum() {
+	session := a.Session()
+	if session == nil {
+		return
+	}
+	session.mu.Lock()
+	session.nextOutSeq++
+	session.mu.Unlock()
+}
+
+// RequestResend sends a ResendRequest to the initiator for the given range (end 0 is infinity).
+func (a *StubAcceptor) RequestResend(begin, end int) error {
+	session := a.Session()
+	if session == nil {
+		return ErrNotLoggedOn
+	}
+	req := NewMessage(MsgTypeResendRequest)
+	req.SetInt(TagBeginSeqNo, begin)
+	req.SetInt(TagEndSeqNo, end)
+	_, err := session.Send(req)
+	return err
+}
+
+// FillRestingOrders fills all resting limit orders at their limit price.
+func (a *StubAcceptor) FillRestingOrders() error {
+	a.mu.Lock()
+	resting := a.resting
+	a.resting = nil
+	a.mu.Unlock()
+
+	for _, order := range resting {
+		price, _ := order.Get(TagPrice)
+		if err := a.fill(order, decimal.RequireFromString(price)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnswerIgnoredOrders answers the orders held for IgnoreSymbol as if they had just been received.
+func (a *StubAcceptor) AnswerIgnoredOrders() {
+	a.mu.Lock()
+	ignored := a.ignored
+	a.ignored = nil
+	a.mu.Unlock()
+
+	for _, order := range ignored {
+		a.answerNewOrderSingle(order)
+	}
+}
+
+func (a *StubAcceptor) acceptLoop() {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		session := newAcceptorSession(a.config, a.handle, a.record)
+		a.mu.Lock()
+		a.session = session
+		a.mu.Unlock()
+		session.start(conn)
+	}
+}
+
+func (a *StubAcceptor) record(msg Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.received = append(a.received, msg)
+}
+
+func (a *StubAcceptor) handle(msg Message) {
+	switch msg.MsgType() {
+	case MsgTypeNewOrderSingle:
+		a.onNewOrderSingle(msg)
+	case MsgTypeOrderCancelRequest:
+		a.onOrderCancelRequest(msg)
+	}
+}
+
+func (a *StubAcceptor) onNewOrderSingle(order Message) {
+	symbol, _ := order.Get(TagSymbol)
+	if a.IgnoreSymbol != "" && symbol == a.IgnoreSymbol {
+		a.mu.Lock()
+		a.ignored = append(a.ignored, order)
+		a.mu.Unlock()
+		return
+	}
+	a.answerNewOrderSingle(order)
+}
+
+func (a *StubAcceptor) answerNewOrderSingle(order Message) {
+	symbol, _ := order.Get(TagSymbol)
+	if a.RejectSymbol != "" && symbol == a.RejectSymbol {
+		exec := a.newExecutionReport(order, ExecTypeRejected, OrdStatusRejected)
+		exec.Set(TagText, "symbol not permitted")
+		_ = a.send(exec)
+		return
+	}
+
+	if err := a.send(a.newExecutionReport(order, ExecTypeNew, OrdStatusNew)); err != nil {
+		return
+	}
+
+	ordType, _ := order.Get(TagOrdType)
+	if ordType == OrdTypeMarket {
+		_ = a.fill(order, a.MarketPrice)
+		return
+	}
+
+	a.mu.Lock()
+	a.resting = append(a.resting, order)
+	a.mu.Unlock()
+}
+
+func (a *StubAcceptor) onOrderCancelRequest(req Message) {
+	orig, _ := req.Get(TagOrigClOrdID)
+	clOrdID, _ := req.Get(TagClOrdID)
+
+	a.mu.Lock()
+	var order Message
+	var found bool
+	for i := range a.resting {
+		if id, _ := a.resting[i].Get(TagClOrdID); id == orig {
+			order, found = a.resting[i], true
+			a.resting = append(a.resting[:i], a.resting[i+1:]...)
+			break
+		}
+	}
+	a.mu.Unlock()
+
+	if !found {
+		reject := NewMessage(MsgTypeOrderCancelReject)
+		reject.Set(TagOrderID, orig)
+		reject.Set(TagClOrdID, clOrdID)
+		reject.Set(TagOrigClOrdID, orig)
+		reject.Set(TagOrdStatus, OrdStatusRejected)
+		reject.Set(TagCxlRejResponseTo, "1")
+		reject.Set(TagText, "unknown order")
+		_ = a.send(reject)
+		return
+	}
+
+	exec := a.newExecutionReport(order, ExecTypeCanceled, OrdStatusCanceled)
+	exec.Set(TagClOrdID, clOrdID)
+	exec.Set(TagOrigClOrdID, orig)
+	_ = a.send(exec)
+}
+
+func (a *StubAcceptor) fill(order Message, price decimal.Decimal) error {
+	qty, _ := order.Get(TagOrderQty)
+	size := decimal.RequireFromString(qty)
+
+	exec := a.newExecutionReport(order, ExecTypeTrade, OrdStatusFilled)
+	exec.Set(TagLastQty, size.String())
+	exec.Set(TagLastPx, price.String())
+	exec.Set(TagCumQty, size.String())
+	exec.Set(TagAvgPx, price.String())
+	exec.Set(TagLeavesQty, "0")
+	exec.Set(TagCommission, price.Mul(size).Mul(a.CommissionPct).String())
+
+	return a.send(exec)
+}
+
+func (a *StubAcceptor) newExecutionReport(order Message, execType, ordStatus string) Message {
+	a.mu.Lock()
+	a.execSeq++
+	execID := strconv.Itoa(a.execSeq)
+	a.mu.Unlock()
+
+	clOrdID, _ := order.Get(TagClOrdID)
+	symbol, _ := order.Get(TagSymbol)
+	side, _ := order.Get(TagSide)
+	qty, _ := order.Get(TagOrderQty)
+
+	exec := NewMessage(MsgTypeExecutionReport)
+	exec.Set(TagOrderID, clOrdID)
+	exec.Set(TagClOrdID, clOrdID)
+	exec.Set(TagExecID, execID)
+	exec.Set(TagExecType, execType)
+	exec.Set(TagOrdStatus, ordStatus)
+	exec.Set(TagSymbol, symbol)
+	exec.Set(TagSide, side)
+	exec.Set(TagOrderQty, qty)
+	exec.Set(TagLeavesQty, qty)
+	exec.Set(TagCumQty, "0")
+	exec.Set(TagAvgPx, "0")
+	exec.SetTime(TagTransactTime, time.Now())
+	return exec
+}
+
+func (a *StubAcceptor) send(msg Message) error {
+	session := a.Session()
+	if session == nil {
+		return errors.New("stub acceptor has no session")
+	}
+	_, err := session.Send(msg)
+	return err
+}
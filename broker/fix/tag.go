@@ -0,0 +1,112 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package fix
+
+// Tag is the numeric identifier of a FIX field.
+type Tag int
+
+// Tags used by the session and application layers of the adapter.
+const (
+	TagAvgPx            Tag = 6
+	TagBeginSeqNo       Tag = 7
+	TagBeginString      Tag = 8
+	TagBodyLength       Tag = 9
+	TagCheckSum         Tag = 10
+	TagClOrdID          Tag = 11
+	TagCommission       Tag = 12
+	TagCumQty           Tag = 14
+	TagEndSeqNo         Tag = 16
+	TagExecID           Tag = 17
+	TagExecInst         Tag = 18
+	TagLastPx           Tag = 31
+	TagLastQty          Tag = 32
+	TagMsgSeqNum        Tag = 34
+	TagMsgType          Tag = 35
+	TagNewSeqNo         Tag = 36
+	TagOrderID          Tag = 37
+	TagOrderQty         Tag = 38
+	TagOrdStatus        Tag = 39
+	TagOrdType          Tag = 40
+	TagOrigClOrdID      Tag = 41
+	TagPossDupFlag      Tag = 43
+	TagPrice            Tag = 44
+	TagRefSeqNum        Tag = 45
+	TagSenderCompID     Tag = 49
+	TagSendingTime      Tag = 52
+	TagSide             Tag = 54
+	TagSymbol           Tag = 55
+	TagTargetCompID     Tag = 56
+	TagText             Tag = 58
+	TagTimeInForce      Tag = 59
+	TagTransactTime     Tag = 60
+	TagEncryptMethod    Tag = 98
+	TagHeartBtInt       Tag = 108
+	TagTestReqID        Tag = 112
+	TagOrigSendingTime  Tag = 122
+	TagGapFillFlag      Tag = 123
+	TagResetSeqNumFlag  Tag = 141
+	TagExecType         Tag = 150
+	TagLeavesQty        Tag = 151
+	TagCxlRejResponseTo Tag = 434
+)
+
+// MsgType(35) values supported by the adapter.
+const (
+	MsgTypeHeartbeat          = "0"
+	MsgTypeTestRequest        = "1"
+	MsgTypeResendRequest      = "2"
+	MsgTypeReject             = "3"
+	MsgTypeSequenceReset      = "4"
+	MsgTypeLogout             = "5"
+	MsgTypeExecutionReport    = "8"
+	MsgTypeOrderCancelReject  = "9"
+	MsgTypeLogon              = "A"
+	MsgTypeNewOrderSingle     = "D"
+	MsgTypeOrderCancelRequest = "F"
+)
+
+// ExecType(150) values.
+const (
+	ExecTypeNew         = "0"
+	ExecTypeCanceled    = "4"
+	ExecTypeReplaced    = "5"
+	ExecTypePendingCxl  = "6"
+	ExecTypeRejected    = "8"
+	ExecTypeExpired     = "C"
+	ExecTypeTrade       = "F"
+	ExecTypeOrderStatus = "I"
+)
+
+// OrdStatus(39) values.
+const (
+	OrdStatusNew             = "0"
+	OrdStatusPartiallyFilled = "1"
+	OrdStatusFilled          = "2"
+	OrdStatusCanceled        = "4"
+	OrdStatusPendingCancel   = "6"
+	OrdStatusRejected        = "8"
+	OrdStatusExpired         = "C"
+)
+
+// Side(54) values.
+const (
+	SideBuy  = "1"
+	SideSell = "2"
+)
+
+// OrdType(40) values.
+const (
+	OrdTypeMarket = "1"
+	OrdTypeLimit  = "2"
+)
+
+// TimeInForce(59) values.
+const (
+	TimeInForceDay = "0"
+	TimeInForceGTC = "1"
+	TimeInForceIOC = "3"
+)
+
+// ExecInstReduceOnly is the ExecInst(18) value 'Do not increase' used to flag reduce-only orders.
+const ExecInstReduceOnly = "E"
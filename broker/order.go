@@ -4,12 +4,19 @@
 package broker
 
 import (
+	"errors"
 	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/thecolngroup/alphakit/market"
 )
 
+// ErrUnknownOrderSide is returned when text cannot be parsed as an OrderSide.
+var ErrUnknownOrderSide = errors.New("unknown order side")
+
+// ErrUnknownOrderType is returned when text cannot be parsed as an OrderType.
+var ErrUnknownOrderType = errors.New("unknown order type")
+
 // OrderSide represents the side of an order: Buy (long) or Sell (short).
 type OrderSide int
 
@@ -30,6 +37,21 @@ func (s OrderSide) MarshalText() ([]byte, error) {
 	return []byte(s.String()), nil
 }
 
+// UnmarshalText parses the string output of MarshalText.
+func (s *OrderSide) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "None":
+		*s = 0
+	case "Buy":
+		*s = Buy
+	case "Sell":
+		*s = Sell
+	default:
+		return ErrUnknownOrderSide
+	}
+	return nil
+}
+
 // Opposite returns the opposite side of the order.
 func (s OrderSide) Opposite() OrderSide {
 	switch s {
@@ -61,6 +83,21 @@ func (t OrderType) MarshalText() ([]byte, error) {
 	return []byte(t.String()), nil
 }
 
+// UnmarshalText parses the string output of MarshalText.
+func (t *OrderType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "None":
+		*t = 0
+	case "Market":
+		*t = Market
+	case "Limit":
+		*t = Limit
+	default:
+		return ErrUnknownOrderType
+	}
+	return nil
+}
+
 // OrderState represents the state of an order as it is processed by a dealer.
 type OrderState int
 
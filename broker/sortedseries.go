@@ -0,0 +1,272 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrSeriesLengthMismatch is returned when the number of timestamps and values differ.
+var ErrSeriesLengthMismatch = errors.New("timestamps and values have different lengths")
+
+// ErrSeriesNotSorted is returned when timestamps are not strictly ascending.
+var ErrSeriesNotSorted = errors.New("timestamps are not in strictly ascending order")
+
+// SortedSeries is an immutable time series ordered by time in ascending order.
+// Sort once with TimeSeries.Sorted and then access, search and transform without re-sorting.
+// All methods that return a series return a new series; the receiver is never modified.
+type SortedSeries[V any] struct {
+	keys   []Timestamp
+	values []V
+}
+
+// Joined is a pair of values from two series aligned at the same timestamp.
+type Joined[L, R any] struct {
+	Left  L
+	Right R
+}
+
+// Sorted returns an immutable sorted copy of the time series.
+func (ts TimeSeries[V]) Sorted() SortedSeries[V] {
+	keys := ts.SortKeys()
+	values := make([]V, len(keys))
+	for i := range keys {
+		values[i] = ts[keys[i]]
+	}
+	return SortedSeries[V]{keys: keys, values: values}
+}
+
+// NewSortedSeries creates a series from parallel slices of timestamps and values.
+// Timestamps must be strictly ascending. The slices are copied.
+func NewSortedSeries[V any](keys []Timestamp, values []V) (SortedSeries[V], error) {
+	var empty SortedSeries[V]
+	if len(keys) != len(values) {
+		return empty, ErrSeriesLengthMismatch
+	}
+	for i := 1; i < len(keys); i++ {
+		if keys[i] <= keys[i-1] {
+			return empty, ErrSeriesNotSorted
+		}
+	}
+	s := SortedSeries[V]{
+		keys:   make([]Timestamp, len(keys)),
+		values: make([]V, len(values)),
+	}
+	copy(s.keys, keys)
+	copy(s.values, values)
+	return s, nil
+}
+
+// Len returns the number of observations in the series.
+func (s SortedSeries[V]) Len() int {
+	return len(s.keys)
+}
+
+// At returns the timestamp and value of the i'th observation.
+func (s SortedSeries[V]) At(i int) (Timestamp, V) {
+	return s.keys[i], s.values[i]
+}
+
+// Keys returns a copy of the timestamps in ascending order.
+func (s SortedSeries[V]) Keys() []Timestamp {
+	keys := make([]Timestamp, len(s.keys))
+	copy(keys, s.keys)
+	return keys
+}
+
+// Values returns a copy of the values in ascending chronological order.
+func (s SortedSeries[V]) Values() []V {
+	values := make([]V, len(s.values))
+	copy(values, s.values)
+	return values
+}
+
+// Map returns the series as an unordered TimeSeries.
+func (s SortedSeries[V]) Map() TimeSeries[V] {
+	ts := make(TimeSeries[V], len(s.keys))
+	for i := range s.keys {
+		ts[s.keys[i]] = s.values[i]
+	}
+	return ts
+}
+
+// Get returns the value at exactly the given timestamp.
+func (s SortedSeries[V]) Get(t Timestamp) (V, bool) {
+	i := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= t })
+	if i < len(s.keys) && s.keys[i] == t {
+		return s.values[i], true
+	}
+	var empty V
+	return empty, false
+}
+
+// AsOf returns the most recent value at or before the given timestamp.
+// False is returned if the series has no observation at or before t.
+func (s SortedSeries[V]) AsOf(t Timestamp) (V, bool) {
+	i := s.asOfIndex(t)
+	if i < 0 {
+		var empty V
+		return empty, false
+	}
+	return s.values[i], true
+}
+
+// Between returns the observations in the half-open interval [from, to).
+func (s SortedSeries[V]) Between(from, to Timestamp) SortedSeries[V] {
+	start := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= from })
+	end := sort.Search(len(s.keys), func(i int) bool { return s.keys[i] >= to })
+	if end < start {
+		end = start
+	}
+	return SortedSeries[V]{keys: s.keys[start:end:end], values: s.values[start:end:end]}
+}
+
+// ForwardFill returns the as-of value of the series at each of the given ascending timestamps.
+// Timestamps that precede the first observation are omitted.
+func (s SortedSeries[V]) ForwardFill(keys []Timestamp) SortedSeries[V] {
+	var filled SortedSeries[V]
+	for _, k := range keys {
+		v, ok := s.AsOf(k)
+		if !ok {
+			continue
+		}
+		filled.keys = append(filled.keys, k)
+		filled.values = append(filled.values, v)
+	}
+	return filled
+}
+
+// Rolling applies reduce to each trailing window of n observations.
+// The result is labelled with the timestamp of the last observation in each window,
+// and has n-1 fewer observations than the series.
+func (s SortedSeries[V]) Rolling(n int, reduce func(window []V) V) SortedSeries[V] {
+	var rolled SortedSeries[V]
+	if n <= 0 {
+		return rolled
+	}
+	for i := n - 1; i < len(s.values); i++ {
+		window := make([]V, n)
+		copy(window, s.values[i-n+1:i+1])
+		rolled.keys = append(rolled.keys, s.keys[i])
+		rolled.values = append(rolled.values, reduce(window))
+	}
+	return rolled
+}
+
+// Resample groups observations into fixed intervals aligned to the unix epoch and applies reduce to each group.
+// Each group is labelled with the start time of its interval. Empty intervals are omitted;
+// use ForwardFill with ResampleKeys to fill them.
+func (s SortedSeries[V]) Resample(interval time.Duration, reduce func(group []V) V) SortedSeries[V] {
+	var resampled SortedSeries[V]
+	step := Timestamp(interval.Milliseconds())
+	if step <= 0 {
+		return resampled
+	}
+	for i := 0; i < len(s.keys); {
+		bucket := floorTimestamp(s.keys[i], step)
+		j := i
+		for j < len(s.keys) && floorTimestamp(s.keys[j], step) == bucket {
+			j++
+		}
+		group := make([]V, j-i)
+		copy(group, s.values[i:j])
+		resampled.keys = append(resampled.keys, bucket)
+		resampled.values = append(resampled.values, reduce(group))
+		i = j
+	}
+	return resampled
+}
+
+// ResampleKeys returns every interval start between the first and last observation of the series inclusive.
+func (s SortedSeries[V]) ResampleKeys(interval time.Duration) []Timestamp {
+	step := Timestamp(interval.Milliseconds())
+	if step <= 0 || len(s.keys) == 0 {
+		return nil
+	}
+	var keys []Timestamp
+	for k := floorTimestamp(s.keys[0], step); k <= s.keys[len(s.keys)-1]; k += step {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (s SortedSeries[V]) asOfIndex(t Timestamp) int {
+	return sort.Search(len(s.keys), func(i int) bool { return s.keys[i] > t }) - 1
+}
+
+// MapSeries applies fn to each value of the series, preserving timestamps.
+func MapSeries[V, W any](s SortedSeries[V], fn func(V) W) SortedSeries[W] {
+	mapped := SortedSeries[W]{
+		keys:   s.keys,
+		values: make([]W, len(s.values)),
+	}
+	for i := range s.values {
+		mapped.values[i] = fn(s.values[i])
+	}
+	return mapped
+}
+
+// AsOfJoin pairs each observation in left with the most recent observation in right at or before the same time.
+// Observations in left that precede the first observation in right are omitted.
+func AsOfJoin[L, R any](left SortedSeries[L], right SortedSeries[R]) SortedSeries[Joined[L, R]] {
+	var joined SortedSeries[Joined[L, R]]
+	for i := range left.keys {
+		r, ok := right.AsOf(left.keys[i])
+		if !ok {
+			continue
+		}
+		joined.keys = append(joined.keys, left.keys[i])
+		joined.values = append(joined.values, Joined[L, R]{Left: left.values[i], Right: r})
+	}
+	return joined
+}
+
+// Align forward-fills each series onto the union of all their timestamps.
+// The aligned series start from the first timestamp at which every series has an observation,
+// so all returned series have equal length and identical timestamps.
+func Align[V any](series ...SortedSeries[V]) []SortedSeries[V] {
+	if len(series) == 0 {
+		return nil
+	}
+
+	var start Timestamp
+	for _, s := range series {
+		if s.Len() == 0 {
+			return make([]SortedSeries[V], len(series))
+		}
+		if s.keys[0] > start {
+			start = s.keys[0]
+		}
+	}
+
+	union := make(map[Timestamp]struct{})
+	for _, s := range series {
+		for _, k := range s.keys {
+			if k >= start {
+				union[k] = struct{}{}
+			}
+		}
+	}
+	keys := make([]Timestamp, 0, len(union))
+	for k := range union {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	aligned := make([]SortedSeries[V], len(series))
+	for i := range series {
+		aligned[i] = series[i].ForwardFill(keys)
+	}
+	return aligned
+}
+
+func floorTimestamp(t, step Timestamp) Timestamp {
+	floored := t - t%step
+	if t%step < 0 {
+		floored -= step
+	}
+	return floored
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package broker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func sum(vs []int) int {
+	var total int
+	for _, v := range vs {
+		total += v
+	}
+	return total
+}
+
+func TestNewSortedSeries(t *testing.T) {
+	tests := []struct {
+		name       string
+		giveKeys   []Timestamp
+		giveValues []int
+		err        error
+	}{
+		{
+			name:       "valid",
+			giveKeys:   []Timestamp{1, 2, 3},
+			giveValues: []int{1, 2, 3},
+		},
+		{
+			name:       "length mismatch",
+			giveKeys:   []Timestamp{1, 2},
+			giveValues: []int{1},
+			err:        ErrSeriesLengthMismatch,
+		},
+		{
+			name:       "not sorted",
+			giveKeys:   []Timestamp{1, 3, 2},
+			giveValues: []int{1, 2, 3},
+			err:        ErrSeriesNotSorted,
+		},
+		{
+			name:       "duplicate timestamp",
+			giveKeys:   []Timestamp{1, 1},
+			giveValues: []int{1, 2},
+			err:        ErrSeriesNotSorted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := NewSortedSeries(tt.giveKeys, tt.giveValues)
+			assert.ErrorIs(t, err, tt.err)
+			if err == nil {
+				assert.Equal(t, tt.giveKeys, act.Keys())
+				assert.Equal(t, tt.giveValues, act.Values())
+			}
+		})
+	}
+}
+
+func TestTimeSeries_Sorted(t *testing.T) {
+	give := TimeSeries[int]{3: 30, 1: 10, 2: 20}
+	act := give.Sorted()
+	assert.Equal(t, []Timestamp{1, 2, 3}, act.Keys())
+	assert.Equal(t, []int{10, 20, 30}, act.Values())
+	assert.Equal(t, give, act.Map())
+
+	// Mutating a copy does not modify the series
+	act.Values()[0] = 99
+	k, v := act.At(0)
+	assert.Equal(t, Timestamp(1), k)
+	assert.Equal(t, 10, v)
+}
+
+func TestSortedSeries_GetAndAsOf(t *testing.T) {
+	s := TimeSeries[int]{10: 1, 20: 2, 30: 3}.Sorted()
+
+	tests := []struct {
+		name      string
+		give      Timestamp
+		wantGet   bool
+		wantAsOf  int
+		wantFound bool
+	}{
+		{name: "before first", give: 5},
+		{name: "exact", give: 20, wantGet: true, wantAsOf: 2, wantFound: true},
+		{name: "between", give: 25, wantAsOf: 2, wantFound: true},
+		{name: "after last", give: 40, wantAsOf: 3, wantFound: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := s.Get(tt.give)
+			assert.Equal(t, tt.wantGet, ok)
+			act, ok := s.AsOf(tt.give)
+			assert.Equal(t, tt.wantFound, ok)
+			assert.Equal(t, tt.wantAsOf, act)
+		})
+	}
+}
+
+func TestSortedSeries_Between(t *testing.T) {
+	s := TimeSeries[int]{10: 1, 20: 2, 30: 3, 40: 4}.Sorted()
+	assert.Equal(t, []int{2, 3}, s.Between(20, 40).Values())
+	assert.Equal(t, 0, s.Between(40, 20).Len())
+}
+
+func TestSortedSeries_ForwardFill(t *testing.T) {
+	s := TimeSeries[int]{10: 1, 30: 3}.Sorted()
+	act := s.ForwardFill([]Timestamp{5, 10, 20, 30, 40})
+	assert.Equal(t, []Timestamp{10, 20, 30, 40}, act.Keys())
+	assert.Equal(t, []int{1, 1, 3, 3}, act.Values())
+}
+
+func TestSortedSeries_Rolling(t *testing.T) {
+	s := TimeSeries[int]{1: 1, 2: 2, 3: 3, 4: 4}.Sorted()
+	act := s.Rolling(3, sum)
+	assert.Equal(t, []Timestamp{3, 4}, act.Keys())
+	assert.Equal(t, []int{6, 9}, act.Values())
+	assert.Equal(t, 0, s.Rolling(5, sum).Len())
+}
+
+func TestSortedSeries_Resample(t *testing.T) {
+	hour := Timestamp(time.Hour.Milliseconds())
+	s := TimeSeries[int]{
+		0:            1,
+		hour / 2:     2,
+		hour:         3,
+		3*hour + 100: 4,
+	}.Sorted()
+
+	act := s.Resample(time.Hour, sum)
+	assert.Equal(t, []Timestamp{0, hour, 3 * hour}, act.Keys())
+	assert.Equal(t, []int{3, 3, 4}, act.Values())
+
+	filled := act.ForwardFill(s.ResampleKeys(time.Hour))
+	assert.Equal(t, []Timestamp{0, hour, 2 * hour, 3 * hour}, filled.Keys())
+	assert.Equal(t, []int{3, 3, 3, 4}, filled.Values())
+}
+
+func TestAsOfJoin(t *testing.T) {
+	left := TimeSeries[int]{10: 1, 20: 2, 30: 3}.Sorted()
+	right := TimeSeries[string]{15: "a", 30: "b"}.Sorted()
+
+	act := AsOfJoin(left, right)
+	assert.Equal(t, []Timestamp{20, 30}, act.Keys())
+	assert.Equal(t, []Joined[int, string]{{2, "a"}, {3, "b"}}, act.Values())
+}
+
+func TestAlign(t *testing.T) {
+	a := TimeSeries[int]{10: 1, 20: 2, 40: 4}.Sorted()
+	b := TimeSeries[int]{15: 10, 30: 30}.Sorted()
+
+	act := Align(a, b)
+	require.Len(t, act, 2)
+	want := []Timestamp{15, 20, 30, 40}
+	assert.Equal(t, want, act[0].Keys())
+	assert.Equal(t, want, act[1].Keys())
+	assert.Equal(t, []int{1, 2, 2, 4}, act[0].Values())
+	assert.Equal(t, []int{10, 10, 30, 30}, act[1].Values())
+}
+
+func TestMapSeries(t *testing.T) {
+	s := TimeSeries[int]{1: 1, 2: 2}.Sorted()
+	act := MapSeries(s, func(v int) float64 { return float64(v) / 2 })
+	assert.Equal(t, []float64{0.5, 1}, act.Values())
+}
+
+func TestEquitySeries_Float64s(t *testing.T) {
+	give := EquitySeries{2: dec.New(2.5), 1: dec.New(1)}
+	keys, values := give.Float64s()
+	assert.Equal(t, []Timestamp{1, 2}, keys)
+	assert.Equal(t, []float64{1, 2.5}, values)
+
+	act, err := NewEquitySeriesFromFloat64s(keys, values)
+	assert.NoError(t, err)
+	assert.Equal(t, give.SortKeys(), act.SortKeys())
+	assert.True(t, act[2].Equal(give[2]))
+
+	_, err = NewEquitySeriesFromFloat64s(keys, values[:1])
+	assert.ErrorIs(t, err, ErrSeriesLengthMismatch)
+}
@@ -54,3 +54,8 @@ func (d *StubDealer) ListRoundTurns(ctx context.Context, opts *web.ListOpts) ([]
 func (d *StubDealer) EquityHistory() EquitySeries {
 	return nil
 }
+
+// AccountHistory not implemented.
+func (d *StubDealer) AccountHistory() AccountSeries {
+	return nil
+}
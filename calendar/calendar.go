@@ -0,0 +1,165 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package calendar provides trading calendars that define the sessions and holidays of a market.
+// A calendar filters and annotates prices by session, tells a market closure from missing data,
+// and aligns daily bars and performance metrics to trading days rather than UTC days.
+package calendar
+
+import (
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// _maxClosedDays is the longest run of days without a session searched for by NextSessionOpen.
+const _maxClosedDays = 31
+
+// Holiday is a date on which a market is closed or, if Close is non-zero, closes early.
+type Holiday struct {
+	Year  int
+	Month time.Month
+	Day   int
+
+	// Close is the early close as an offset from midnight of the date. Zero if closed all day.
+	Close time.Duration
+}
+
+// Session is a single trading session.
+type Session struct {
+	// Date is the trading day, at midnight in the calendar location.
+	// A session that opens the evening before belongs to the date on which it closes.
+	Date time.Time
+
+	Open  time.Time
+	Close time.Time
+}
+
+// Calendar defines the regular daily sessions and the holidays of a market.
+// Session times are wall clock times in Location so are correct across daylight saving changes.
+type Calendar struct {
+	Name     string
+	Location *time.Location
+
+	// Weekdays are the days of the week with a session, by the date of the session.
+	Weekdays []time.Weekday
+
+	// Open is the session open as an offset from midnight of the trading day.
+	// A negative offset opens the session the evening before, e.g. -7h is 17:00 the previous day.
+	Open time.Duration
+
+	// Close is the session close as an offset from midnight of the trading day.
+	Close time.Duration
+
+	// Holidays returns the closures and early closes in a year. Nil if the market has no holidays.
+	Holidays func(year int) []Holiday
+
+	// SessionsPerYear is the typical number of sessions in a year, used to annualize daily metrics.
+	SessionsPerYear float64
+}
+
+// SessionOn returns the session of the trading day of date, or false if there is no session on that day.
+func (c *Calendar) SessionOn(date time.Time) (Session, bool) {
+	y, m, d := date.In(c.location()).Date()
+	return c.session(y, m, d)
+}
+
+// Session returns the session that contains t, or false if the market is closed at t.
+// A session includes its open and excludes its close.
+func (c *Calendar) Session(t time.Time) (Session, bool) {
+	y, m, d := t.In(c.location()).Date()
+	for _, offset := range []int{-1, 0, 1} {
+		s, ok := c.session(y, m, d+offset)
+		if ok && !t.Before(s.Open) && t.Before(s.Close) {
+			return s, true
+		}
+	}
+	return Session{}, false
+}
+
+// IsSessionOpen returns true if the market is open at t.
+func (c *Calendar) IsSessionOpen(t time.Time) bool {
+	_, ok := c.Session(t)
+	return ok
+}
+
+// NextSessionOpen returns the open of the first session that opens at or after t.
+// Returns the zero time if no session opens within a month of t.
+func (c *Calendar) NextSessionOpen(t time.Time) time.Time {
+	y, m, d := t.In(c.location()).Date()
+	for offset := -1; offset <= _maxClosedDays; offset++ {
+		s, ok := c.session(y, m, d+offset)
+		if ok && !s.Open.Before(t) {
+			return s.Open
+		}
+	}
+	return time.Time{}
+}
+
+// Sessions returns the sessions that open in the interval [from, to).
+func (c *Calendar) Sessions(from, to time.Time) []Session {
+	var sessions []Session
+	y, m, d := from.In(c.location()).Date()
+	for day := time.Date(y, m, d-1, 0, 0, 0, 0, c.location()); day.Before(to.Add(24 * time.Hour)); day = day.AddDate(0, 0, 1) {
+		s, ok := c.SessionOn(day)
+		if ok && !s.Open.Before(from) && s.Open.Before(to) {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions
+}
+
+// Timeframe returns a daily timeframe with bars aligned to the session open.
+func (c *Calendar) Timeframe() market.Timeframe {
+	return market.Timeframe{
+		Unit:     market.Day,
+		Count:    1,
+		Location: c.location(),
+		Offset:   c.Open,
+	}
+}
+
+func (c *Calendar) session(y int, m time.Month, d int) (Session, bool) {
+	loc := c.location()
+	date := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	if !c.isWeekday(date.Weekday()) {
+		return Session{}, false
+	}
+
+	closing := c.Close
+	if c.Holidays != nil {
+		y, m, d = date.Date()
+		for _, h := range c.Holidays(y) {
+			if h.Month != m || h.Day != d {
+				continue
+			}
+			if h.Close == 0 {
+				return Session{}, false
+			}
+			closing = h.Close
+		}
+	}
+
+	// Normalizing seconds from midnight by time.Date gives the wall clock time in loc
+	return Session{
+		Date:  date,
+		Open:  time.Date(y, m, d, 0, 0, int(c.Open/time.Second), 0, loc),
+		Close: time.Date(y, m, d, 0, 0, int(closing/time.Second), 0, loc),
+	}, true
+}
+
+func (c *Calendar) isWeekday(day time.Weekday) bool {
+	for _, w := range c.Weekdays {
+		if w == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
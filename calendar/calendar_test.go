@@ -0,0 +1,180 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_IsSessionOpen(t *testing.T) {
+	nyse, cme, fx, crypto := NewNYSECalendar(), NewCMEGlobexCalendar(), NewFXCalendar(), NewCryptoCalendar()
+	newYork, chicago := nyse.Location, cme.Location
+
+	tests := []struct {
+		name     string
+		giveCal  *Calendar
+		giveTime time.Time
+		want     bool
+	}{
+		{
+			name:     "nyse open after dst change",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 14, 9, 30, 0, 0, newYork),
+			want:     true,
+		},
+		{
+			name:     "nyse before open",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 14, 9, 29, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "nyse at close",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 14, 16, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "nyse weekend",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 12, 12, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "nyse good friday",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.April, 15, 12, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "nyse juneteenth observed",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.June, 20, 12, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "nyse new year on saturday not observed",
+			giveCal:  nyse,
+			giveTime: time.Date(2021, time.December, 31, 12, 0, 0, 0, newYork),
+			want:     true,
+		},
+		{
+			name:     "nyse before early close",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.November, 25, 12, 59, 0, 0, newYork),
+			want:     true,
+		},
+		{
+			name:     "nyse after early close",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.November, 25, 13, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "cme sunday evening",
+			giveCal:  cme,
+			giveTime: time.Date(2022, time.March, 13, 17, 0, 0, 0, chicago),
+			want:     true,
+		},
+		{
+			name:     "cme daily maintenance",
+			giveCal:  cme,
+			giveTime: time.Date(2022, time.March, 14, 16, 30, 0, 0, chicago),
+			want:     false,
+		},
+		{
+			name:     "cme friday evening",
+			giveCal:  cme,
+			giveTime: time.Date(2022, time.March, 18, 17, 0, 0, 0, chicago),
+			want:     false,
+		},
+		{
+			name:     "cme early close on thanksgiving",
+			giveCal:  cme,
+			giveTime: time.Date(2022, time.November, 24, 12, 0, 0, 0, chicago),
+			want:     false,
+		},
+		{
+			name:     "fx friday before close",
+			giveCal:  fx,
+			giveTime: time.Date(2022, time.March, 18, 16, 59, 0, 0, newYork),
+			want:     true,
+		},
+		{
+			name:     "fx saturday",
+			giveCal:  fx,
+			giveTime: time.Date(2022, time.March, 19, 12, 0, 0, 0, newYork),
+			want:     false,
+		},
+		{
+			name:     "crypto sunday",
+			giveCal:  crypto,
+			giveTime: time.Date(2022, time.March, 13, 3, 0, 0, 0, time.UTC),
+			want:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.giveCal.IsSessionOpen(tt.giveTime))
+		})
+	}
+}
+
+func TestCalendar_NextSessionOpen(t *testing.T) {
+	nyse, cme := NewNYSECalendar(), NewCMEGlobexCalendar()
+	newYork, chicago := nyse.Location, cme.Location
+
+	tests := []struct {
+		name     string
+		giveCal  *Calendar
+		giveTime time.Time
+		want     time.Time
+	}{
+		{
+			name:     "nyse at open",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 14, 9, 30, 0, 0, newYork),
+			want:     time.Date(2022, time.March, 14, 9, 30, 0, 0, newYork),
+		},
+		{
+			name:     "nyse during session",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.March, 14, 10, 0, 0, 0, newYork),
+			want:     time.Date(2022, time.March, 15, 9, 30, 0, 0, newYork),
+		},
+		{
+			name:     "nyse over easter weekend",
+			giveCal:  nyse,
+			giveTime: time.Date(2022, time.April, 14, 17, 0, 0, 0, newYork),
+			want:     time.Date(2022, time.April, 18, 9, 30, 0, 0, newYork),
+		},
+		{
+			name:     "cme over weekend",
+			giveCal:  cme,
+			giveTime: time.Date(2022, time.March, 18, 16, 30, 0, 0, chicago),
+			want:     time.Date(2022, time.March, 20, 17, 0, 0, 0, chicago),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, tt.want.Equal(tt.giveCal.NextSessionOpen(tt.giveTime)), tt.giveCal.NextSessionOpen(tt.giveTime))
+		})
+	}
+}
+
+func TestCalendar_Sessions(t *testing.T) {
+	nyse := NewNYSECalendar()
+	from := time.Date(2022, time.January, 1, 0, 0, 0, 0, nyse.Location)
+	act := nyse.Sessions(from, from.AddDate(1, 0, 0))
+	// 260 weekdays less 9 holidays, as New Year's Day on a Saturday is not observed
+	assert.Equal(t, 251, len(act))
+}
+
+func TestEasterSunday(t *testing.T) {
+	assert.Equal(t, time.Date(2022, time.April, 17, 0, 0, 0, 0, time.UTC), easterSunday(2022))
+	assert.Equal(t, time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC), easterSunday(2024))
+}
@@ -0,0 +1,116 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package calendar
+
+import (
+	"context"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ market.Receiver = (*SessionFilter)(nil)
+
+// SessionKline is a kline annotated with the session in which it starts.
+type SessionKline struct {
+	market.Kline
+
+	// Session is the session that contains the kline start. Zero if InSession is false.
+	Session Session
+
+	// InSession is true if the kline starts while the market is open.
+	InSession bool
+
+	// SessionOpen is true for the first kline of a session.
+	// The time since the previous kline is then a market closure rather than missing data.
+	SessionOpen bool
+}
+
+// Filter returns the klines that start while the market is open.
+func (c *Calendar) Filter(klines []market.Kline) []market.Kline {
+	filtered := make([]market.Kline, 0, len(klines))
+	for _, k := range klines {
+		if c.IsSessionOpen(k.Start) {
+			filtered = append(filtered, k)
+		}
+	}
+	return filtered
+}
+
+// Annotate returns each kline with the session in which it starts.
+// Klines must be in ascending chronological order.
+func (c *Calendar) Annotate(klines []market.Kline) []SessionKline {
+	annotated := make([]SessionKline, len(klines))
+	var prev time.Time
+	for i, k := range klines {
+		s, ok := c.Session(k.Start)
+		annotated[i] = SessionKline{
+			Kline:       k,
+			Session:     s,
+			InSession:   ok,
+			SessionOpen: ok && !s.Date.Equal(prev),
+		}
+		if ok {
+			prev = s.Date
+		}
+	}
+	return annotated
+}
+
+// MissingKlines returns the start times of the klines missing from sessions between the first and last kline,
+// given the interval of the klines. Times when the market is closed are not reported.
+// Klines must be in ascending chronological order.
+func (c *Calendar) MissingKlines(klines []market.Kline, interval time.Duration) []time.Time {
+	if len(klines) == 0 || interval <= 0 {
+		return nil
+	}
+	first, last := klines[0].Start, klines[len(klines)-1].Start
+
+	present := make(map[int64]bool, len(klines))
+	for _, k := range klines {
+		present[k.Start.UnixNano()] = true
+	}
+
+	var missing []time.Time
+	for _, s := range c.Sessions(first.Add(-c.Close+c.Open), last.Add(interval)) {
+		for t := s.Open; t.Before(s.Close); t = t.Add(interval) {
+			if t.Before(first) || t.After(last) {
+				continue
+			}
+			if !present[t.UnixNano()] {
+				missing = append(missing, t)
+			}
+		}
+	}
+	return missing
+}
+
+// ResampleSessions aggregates the klines that start while the market is open into one bar per session.
+// Each bar starts at the session open.
+func (c *Calendar) ResampleSessions(klines []market.Kline) []market.Kline {
+	return market.Resample(c.Filter(klines), c.Timeframe())
+}
+
+// SessionFilter is a Receiver that forwards only the klines that start while the market is open.
+type SessionFilter struct {
+	Calendar *Calendar
+
+	next market.Receiver
+}
+
+// NewSessionFilter creates a new SessionFilter that forwards klines to next.
+func NewSessionFilter(cal *Calendar, next market.Receiver) *SessionFilter {
+	return &SessionFilter{
+		Calendar: cal,
+		next:     next,
+	}
+}
+
+// ReceivePrice forwards the kline to the next Receiver if the market is open.
+func (f *SessionFilter) ReceivePrice(ctx context.Context, k market.Kline) error {
+	if !f.Calendar.IsSessionOpen(k.Start) {
+		return nil
+	}
+	return f.next.ReceivePrice(ctx, k)
+}
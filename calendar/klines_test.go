@@ -0,0 +1,97 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package calendar
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// klinesForCalendarTest returns 30 minute klines around the clock from Thursday 14 to Tuesday 19 April 2022,
+// spanning the NYSE Good Friday holiday and a weekend.
+func klinesForCalendarTest() []market.Kline {
+	start := time.Date(2022, time.April, 14, 0, 0, 0, 0, time.UTC)
+	var klines []market.Kline
+	for t := start; t.Before(start.AddDate(0, 0, 6)); t = t.Add(30 * time.Minute) {
+		klines = append(klines, market.Kline{Start: t, O: dec.New(1), H: dec.New(2), L: dec.New(1), C: dec.New(2), Volume: 1})
+	}
+	return klines
+}
+
+func TestCalendar_Filter(t *testing.T) {
+	nyse := NewNYSECalendar()
+	act := nyse.Filter(klinesForCalendarTest())
+
+	// Thursday, Monday and Tuesday sessions of 13 klines each
+	require.Len(t, act, 3*13)
+	assert.Equal(t, time.Date(2022, time.April, 14, 9, 30, 0, 0, nyse.Location).Unix(), act[0].Start.Unix())
+	assert.Equal(t, time.Date(2022, time.April, 19, 15, 30, 0, 0, nyse.Location).Unix(), act[len(act)-1].Start.Unix())
+}
+
+func TestCalendar_Annotate(t *testing.T) {
+	nyse := NewNYSECalendar()
+	act := nyse.Annotate(nyse.Filter(klinesForCalendarTest()))
+
+	var opens []int
+	for i := range act {
+		assert.True(t, act[i].InSession)
+		if act[i].SessionOpen {
+			opens = append(opens, i)
+		}
+	}
+	assert.Equal(t, []int{0, 13, 26}, opens)
+	assert.Equal(t, 18, act[13].Session.Date.Day())
+}
+
+func TestCalendar_MissingKlines(t *testing.T) {
+	nyse := NewNYSECalendar()
+	klines := nyse.Filter(klinesForCalendarTest())
+	missing := klines[15].Start
+	klines = append(klines[:15], klines[16:]...)
+
+	act := nyse.MissingKlines(klines, 30*time.Minute)
+
+	// The overnight, holiday and weekend closures are not missing data
+	require.Len(t, act, 1)
+	assert.True(t, missing.Equal(act[0]))
+}
+
+func TestCalendar_ResampleSessions(t *testing.T) {
+	cme := NewCMEGlobexCalendar()
+	act := cme.ResampleSessions(klinesForCalendarTest())
+
+	// Thursday from midnight UTC, Monday from Sunday evening, Tuesday and the start of Wednesday
+	require.Len(t, act, 4)
+	assert.Equal(t, time.Date(2022, time.April, 17, 17, 0, 0, 0, cme.Location).Unix(), act[1].Start.Unix())
+	// 17:00 to 16:00 is 23 hours of 30 minute klines
+	assert.Equal(t, 46.0, act[1].Volume)
+}
+
+type receiverForCalendarTest struct {
+	klines []market.Kline
+}
+
+func (r *receiverForCalendarTest) ReceivePrice(ctx context.Context, k market.Kline) error {
+	r.klines = append(r.klines, k)
+	return nil
+}
+
+func TestSessionFilter(t *testing.T) {
+	nyse := NewNYSECalendar()
+	klines := klinesForCalendarTest()
+
+	var receiver receiverForCalendarTest
+	filter := NewSessionFilter(nyse, &receiver)
+	for _, k := range klines {
+		require.NoError(t, filter.ReceivePrice(context.Background(), k))
+	}
+
+	assert.Equal(t, nyse.Filter(klines), receiver.klines)
+}
@@ -0,0 +1,202 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package calendar
+
+import (
+	"time"
+
+	// Embed the timezone database so the presets do not depend on the host
+	_ "time/tzdata"
+)
+
+// NewCryptoCalendar creates a calendar for crypto markets that trade 24/7 with daily sessions from UTC midnight.
+func NewCryptoCalendar() *Calendar {
+	return &Calendar{
+		Name:     "crypto",
+		Location: time.UTC,
+		Weekdays: []time.Weekday{
+			time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday,
+		},
+		Open:            0,
+		Close:           24 * time.Hour,
+		SessionsPerYear: 365,
+	}
+}
+
+// NewNYSECalendar creates a calendar for the regular trading hours of the New York Stock Exchange,
+// 09:30 to 16:00 New York time, with the NYSE holidays and 13:00 early closes.
+func NewNYSECalendar() *Calendar {
+	return &Calendar{
+		Name:            "nyse",
+		Location:        mustLoadLocation("America/New_York"),
+		Weekdays:        mondayToFriday(),
+		Open:            9*time.Hour + 30*time.Minute,
+		Close:           16 * time.Hour,
+		Holidays:        nyseHolidays,
+		SessionsPerYear: 252,
+	}
+}
+
+// NewCMEGlobexCalendar creates a calendar for CME Globex equity and interest rate futures,
+// with sessions from 17:00 Chicago time the previous day to 16:00.
+// The market is closed on New Year's Day, Good Friday and Christmas Day and closes at 12:00 on other US holidays.
+func NewCMEGlobexCalendar() *Calendar {
+	return &Calendar{
+		Name:            "cme",
+		Location:        mustLoadLocation("America/Chicago"),
+		Weekdays:        mondayToFriday(),
+		Open:            -7 * time.Hour,
+		Close:           16 * time.Hour,
+		Holidays:        cmeHolidays,
+		SessionsPerYear: 252,
+	}
+}
+
+// NewFXCalendar creates a calendar for the spot FX market, with sessions from 17:00 New York time
+// the previous day to 17:00, so the week runs from Sunday evening to Friday evening.
+// The market is closed on Christmas Day and New Year's Day.
+func NewFXCalendar() *Calendar {
+	return &Calendar{
+		Name:            "fx",
+		Location:        mustLoadLocation("America/New_York"),
+		Weekdays:        mondayToFriday(),
+		Open:            -7 * time.Hour,
+		Close:           17 * time.Hour,
+		Holidays:        fxHolidays,
+		SessionsPerYear: 260,
+	}
+}
+
+func nyseHolidays(year int) []Holiday {
+	holidays := usHolidays(year)
+	for i := range holidays {
+		holidays[i].Close = 0
+	}
+
+	// Early closes at 13:00 before Independence Day, after Thanksgiving and on Christmas Eve
+	earlyClose := 13 * time.Hour
+	if wd := weekday(year, time.July, 3); wd >= time.Monday && wd <= time.Thursday {
+		holidays = append(holidays, Holiday{Year: year, Month: time.July, Day: 3, Close: earlyClose})
+	}
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	holidays = append(holidays, Holiday{Year: year, Month: time.November, Day: thanksgiving + 1, Close: earlyClose})
+	if wd := weekday(year, time.December, 24); wd >= time.Monday && wd <= time.Thursday {
+		holidays = append(holidays, Holiday{Year: year, Month: time.December, Day: 24, Close: earlyClose})
+	}
+
+	return holidays
+}
+
+func cmeHolidays(year int) []Holiday {
+	holidays := usHolidays(year)
+	easter := easterSunday(year)
+	goodFriday := easter.AddDate(0, 0, -2)
+	for i := range holidays {
+		h := &holidays[i]
+		switch {
+		case h.Month == time.January && h.Day <= 2,
+			h.Month == time.December && h.Day >= 24,
+			h.Month == goodFriday.Month() && h.Day == goodFriday.Day():
+			h.Close = 0
+		default:
+			h.Close = 12 * time.Hour
+		}
+	}
+	return holidays
+}
+
+func fxHolidays(year int) []Holiday {
+	return []Holiday{
+		{Year: year, Month: time.January, Day: 1},
+		{Year: year, Month: time.December, Day: 25},
+	}
+}
+
+// usHolidays returns the US market holidays observed in a year, on the weekday they are observed.
+// New Year's Day is not observed on the preceding Friday when it falls on a Saturday.
+func usHolidays(year int) []Holiday {
+	var holidays []Holiday
+	add := func(m time.Month, d int) {
+		holidays = append(holidays, Holiday{Year: year, Month: m, Day: d})
+	}
+
+	if wd := weekday(year, time.January, 1); wd == time.Sunday {
+		add(time.January, 2)
+	} else if wd != time.Saturday {
+		add(time.January, 1)
+	}
+	add(time.January, nthWeekday(year, time.January, time.Monday, 3))
+	add(time.February, nthWeekday(year, time.February, time.Monday, 3))
+	goodFriday := easterSunday(year).AddDate(0, 0, -2)
+	add(goodFriday.Month(), goodFriday.Day())
+	add(time.May, lastWeekday(year, time.May, time.Monday))
+	if year >= 2022 {
+		add(observed(year, time.June, 19))
+	}
+	add(observed(year, time.July, 4))
+	add(time.September, nthWeekday(year, time.September, time.Monday, 1))
+	add(time.November, nthWeekday(year, time.November, time.Thursday, 4))
+	add(observed(year, time.December, 25))
+
+	return holidays
+}
+
+// observed moves a holiday that falls on a Saturday to the Friday before and on a Sunday to the Monday after.
+func observed(year int, m time.Month, d int) (time.Month, int) {
+	date := time.Date(year, m, d, 0, 0, 0, 0, time.UTC)
+	switch date.Weekday() {
+	case time.Saturday:
+		date = date.AddDate(0, 0, -1)
+	case time.Sunday:
+		date = date.AddDate(0, 0, 1)
+	}
+	return date.Month(), date.Day()
+}
+
+// nthWeekday returns the day of the month of the nth weekday, e.g. the 3rd Monday.
+func nthWeekday(year int, m time.Month, wd time.Weekday, n int) int {
+	first := weekday(year, m, 1)
+	return 1 + (int(wd)-int(first)+7)%7 + 7*(n-1)
+}
+
+// lastWeekday returns the day of the month of the last weekday, e.g. the last Monday.
+func lastWeekday(year int, m time.Month, wd time.Weekday) int {
+	last := time.Date(year, m+1, 0, 0, 0, 0, 0, time.UTC)
+	return last.Day() - (int(last.Weekday())-int(wd)+7)%7
+}
+
+func weekday(year int, m time.Month, d int) time.Weekday {
+	return time.Date(year, m, d, 0, 0, 0, 0, time.UTC).Weekday()
+}
+
+// easterSunday returns the date of Easter Sunday in the Gregorian calendar (anonymous Gregorian algorithm).
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func mondayToFriday() []time.Weekday {
+	return []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Command klinecache converts a directory of CSV price files (or a single file) into a binary kline cache.
+// Once written, market.ReadKlinesFromCSV (for the binance decoder) and the studyrun app (for the same decoder)
+// read the cache instead of parsing the CSV files, until a CSV file is changed.
+// The decoder is named by a key of market.CSVDecoders, e.g. binance or yahoo.adjusted.
+//
+// Usage:
+//
+//	klinecache [-decoder name] [-out filename] path
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("klinecache", flag.ContinueOnError)
+	decoder := flags.String("decoder", "binance", "CSV decoder: "+strings.Join(market.CSVDecoderNames(), ", "))
+	out := flags.String("out", "", "cache filename (default: alongside the CSV path)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("expect args: [flags] [csv path]")
+	}
+	path := flags.Arg(0)
+
+	maker, ok := market.CSVDecoders[*decoder]
+	if !ok {
+		return fmt.Errorf("'%s' is not a valid decoder", *decoder)
+	}
+
+	filename := *out
+	if filename == "" {
+		filename = market.KlineCacheFilename(path)
+	}
+
+	klines, err := market.ReadKlinesFromCSVWithDecoder(path, maker, "")
+	if err != nil {
+		return err
+	}
+
+	if err := market.WriteKlineCache(filename, klines, *decoder); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d klines to '%s'\n", len(klines), filename)
+
+	return nil
+}
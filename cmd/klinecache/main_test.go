@@ -0,0 +1,29 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func Test(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "btcusdt-h1.klc")
+	require.NoError(t, run([]string{"-out", out, "../studyrun/testdata/btcusdt-h1/"}))
+
+	want, err := market.ReadKlinesFromCSV("../studyrun/testdata/btcusdt-h1/")
+	require.NoError(t, err)
+	act, err := market.ReadKlineCache(out)
+	require.NoError(t, err)
+	assert.Len(t, act, len(want))
+	decoder, err := market.ReadKlineCacheDecoder(out)
+	require.NoError(t, err)
+	assert.Equal(t, "binance", decoder)
+
+	assert.Error(t, run([]string{"-decoder", "unknown", "../studyrun/testdata/btcusdt-h1/"}))
+}
@@ -0,0 +1,329 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Command marketdownload downloads kline archives from a public data archive with the layout of
+// Binance public data (https://data.binance.vision) and extracts them into a directory of CSV files
+// that market.ReadKlinesFromCSV can read, e.g. ./data/btcusdt-1h/.
+// Whole months in the date range are downloaded as monthly archives and the remaining days as daily archives.
+// A month without a monthly archive, such as the current month, is downloaded as daily archives instead.
+// Each archive is verified against its SHA-256 checksum file before extraction.
+// Extracted files are normalized to the format of the Binance decoder: the header row of futures archives is dropped,
+// and the microsecond open and close times of spot archives from 2025 onwards are converted to milliseconds.
+// Archives already extracted are skipped, so the command can be re-run to extend a directory.
+//
+// Usage:
+//
+//	marketdownload [-url base] [-out dir] -symbol symbol -interval interval -from date [-to date]
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const _dateFormat = "2006-01-02"
+
+// _microsThreshold is the smallest open time taken to be in microseconds rather than milliseconds.
+// Millisecond times do not reach it until the year 33658.
+const _microsThreshold = 1e15
+
+// errNotFound is returned when an archive does not exist.
+var errNotFound = errors.New("archive not found")
+
+// errChecksum is returned when an archive does not match its checksum.
+var errChecksum = errors.New("archive checksum mismatch")
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("marketdownload", flag.ContinueOnError)
+	baseURL := flags.String("url", "https://data.binance.vision/data/spot", "archive base URL, e.g. https://data.binance.vision/data/futures/um")
+	out := flags.String("out", "./data", "output root directory")
+	symbol := flags.String("symbol", "", "symbol, e.g. BTCUSDT")
+	interval := flags.String("interval", "", "kline interval in archive notation, e.g. 1m, 1h or 1d")
+	from := flags.String("from", "", "first date to download, e.g. 2021-01-01")
+	to := flags.String("to", "", "last date to download, defaults to yesterday")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *symbol == "" || *interval == "" || *from == "" {
+		return errors.New("expect args: [flags] -symbol [symbol] -interval [interval] -from [date]")
+	}
+
+	first, err := time.Parse(_dateFormat, *from)
+	if err != nil {
+		return err
+	}
+	last := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+	if *to != "" {
+		if last, err = time.Parse(_dateFormat, *to); err != nil {
+			return err
+		}
+	}
+	if last.Before(first) {
+		return fmt.Errorf("-to %s is before -from %s", last.Format(_dateFormat), first.Format(_dateFormat))
+	}
+
+	d := downloader{
+		client:   &http.Client{Timeout: 10 * time.Minute},
+		baseURL:  strings.TrimSuffix(*baseURL, "/"),
+		symbol:   strings.ToUpper(*symbol),
+		interval: *interval,
+		dir:      filepath.Join(*out, strings.ToLower(*symbol)+"-"+*interval),
+	}
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+
+	var downloaded, skipped int
+	for _, a := range archives(first, last) {
+		ok, err := d.fetch(a)
+		if errors.Is(err, errNotFound) && a.monthly {
+			// Monthly archives are published after the month ends, so fall back to daily archives
+			n, m, err := d.fetchDays(a.start)
+			if err != nil {
+				return err
+			}
+			downloaded, skipped = downloaded+n, skipped+m
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			downloaded++
+		} else {
+			skipped++
+		}
+	}
+
+	fmt.Printf("Downloaded %d archives to '%s' (%d already present)\n", downloaded, d.dir, skipped)
+	return nil
+}
+
+// archive is a monthly or daily kline archive.
+type archive struct {
+	start   time.Time
+	monthly bool
+}
+
+// archives returns the archives that cover the dates from first to last inclusive:
+// a monthly archive for each whole month and a daily archive for each other day.
+func archives(first, last time.Time) []archive {
+	var list []archive
+	for day := first; !day.After(last); {
+		month := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		next := month.AddDate(0, 1, 0)
+		if day.Equal(month) && !next.AddDate(0, 0, -1).After(last) {
+			list = append(list, archive{start: month, monthly: true})
+			day = next
+			continue
+		}
+		list = append(list, archive{start: day})
+		day = day.AddDate(0, 0, 1)
+	}
+	return list
+}
+
+// downloader downloads the archives of a symbol and interval into a directory.
+type downloader struct {
+	client   *http.Client
+	baseURL  string
+	symbol   string
+	interval string
+	dir      string
+}
+
+// name returns the archive filename without extension, e.g. BTCUSDT-1h-2021-01.
+func (d *downloader) name(a archive) string {
+	if a.monthly {
+		return fmt.Sprintf("%s-%s-%s", d.symbol, d.interval, a.start.Format("2006-01"))
+	}
+	return fmt.Sprintf("%s-%s-%s", d.symbol, d.interval, a.start.Format(_dateFormat))
+}
+
+// url returns the archive URL, e.g. base/monthly/klines/BTCUSDT/1h/BTCUSDT-1h-2021-01.zip.
+func (d *downloader) url(a archive) string {
+	period := "daily"
+	if a.monthly {
+		period = "monthly"
+	}
+	return d.baseURL + "/" + path.Join(period, "klines", d.symbol, d.interval, d.name(a)+".zip")
+}
+
+// fetchDays fetches the daily archives of a month that exist, up to the current day.
+// Returns the number of archives downloaded and skipped as already extracted.
+func (d *downloader) fetchDays(month time.Time) (int, int, error) {
+	var downloaded, skipped int
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for day := month; day.Month() == month.Month() && day.Before(today); day = day.AddDate(0, 0, 1) {
+		ok, err := d.fetch(archive{start: day})
+		switch {
+		case errors.Is(err, errNotFound):
+			continue
+		case err != nil:
+			return downloaded, skipped, err
+		case ok:
+			downloaded++
+		default:
+			skipped++
+		}
+	}
+	return downloaded, skipped, nil
+}
+
+// fetch downloads, verifies and extracts an archive. Returns false if the archive was already extracted.
+// A monthly archive replaces any daily archives of the month extracted before it was published.
+func (d *downloader) fetch(a archive) (bool, error) {
+	csvPath := filepath.Join(d.dir, d.name(a)+".csv")
+	if _, err := os.Stat(csvPath); err == nil {
+		return false, nil
+	}
+
+	url := d.url(a)
+	data, err := d.get(url)
+	if err != nil {
+		return false, err
+	}
+	checksum, err := d.get(url + ".CHECKSUM")
+	if err != nil {
+		return false, err
+	}
+	if err := verify(data, checksum); err != nil {
+		return false, fmt.Errorf("%w: %s", err, url)
+	}
+	if err := extract(data, d.dir); err != nil {
+		return false, fmt.Errorf("%s: %w", url, err)
+	}
+	if a.monthly {
+		days, err := filepath.Glob(filepath.Join(d.dir, d.name(a)+"-[0-9][0-9].csv"))
+		if err != nil {
+			return false, err
+		}
+		for _, day := range days {
+			if err := os.Remove(day); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+func (d *downloader) get(url string) ([]byte, error) {
+	resp, err := d.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", errNotFound, url)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("download failed with status %d: %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verify checks the SHA-256 hash of data against a checksum file in sha256sum format: a hex hash then the filename.
+func verify(data, checksum []byte) error {
+	fields := strings.Fields(string(checksum))
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: empty checksum", errChecksum)
+	}
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return errChecksum
+	}
+	return nil
+}
+
+// extract writes the CSV files in a zip archive to the directory.
+// Files are written to a temporary name and renamed so that an interrupted download is not mistaken for complete.
+func extract(data []byte, dir string) error {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range reader.File {
+		name := filepath.Base(f.Name)
+		if f.FileInfo().IsDir() || filepath.Ext(name) != ".csv" {
+			continue
+		}
+		if err := extractFile(f, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, filename string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := filename + ".tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := normalize(dst, src); err != nil {
+		//nolint:errcheck // Normalize error takes precedence
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
+// normalize copies the lines of an archive CSV file in the format of market.BinanceCSVKlineDecoder.
+// A first line that does not start with an open time is a header row and is dropped.
+// Open and close times in microseconds are converted to milliseconds.
+func normalize(w io.Writer, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	bw := bufio.NewWriter(w)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		fields := strings.Split(line, ",")
+		start, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil && first {
+			continue
+		}
+		if err == nil && start >= _microsThreshold && len(fields) > 6 {
+			fields[0] = strconv.FormatInt(start/1000, 10)
+			if end, err := strconv.ParseInt(fields[6], 10, 64); err == nil {
+				fields[6] = strconv.FormatInt(end/1000, 10)
+			}
+			line = strings.Join(fields, ",")
+		}
+		if _, err := bw.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
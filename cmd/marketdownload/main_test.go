@@ -0,0 +1,195 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+const _testdataForDownloadTest = "../studyrun/testdata/btcusdt-h1/"
+
+// archiveServerForDownloadTest serves a stand-in for the Binance public data archive from a temporary directory.
+// Returns the base URL and the root directory of the archive.
+func archiveServerForDownloadTest(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	server := httptest.NewServer(http.FileServer(http.Dir(root)))
+	t.Cleanup(server.Close)
+	return server.URL, root
+}
+
+// addArchiveForDownloadTest writes a zip archive containing the CSV lines and its checksum file to the archive root.
+func addArchiveForDownloadTest(t *testing.T, root, period, name string, lines []string) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name + ".csv")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	dir := filepath.Join(root, period, "klines", "BTCUSDT", "1h")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".zip"), buf.Bytes(), 0o600))
+	sum := sha256.Sum256(buf.Bytes())
+	checksum := hex.EncodeToString(sum[:]) + "  " + name + ".zip\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".zip.CHECKSUM"), []byte(checksum), 0o600))
+}
+
+// linesForDownloadTest returns the lines of a testdata CSV file that start on the day, or every line if day is zero.
+func linesForDownloadTest(t *testing.T, filename string, day time.Time) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(_testdataForDownloadTest, filename))
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		ms, err := strconv.ParseInt(strings.SplitN(line, ",", 2)[0], 10, 64)
+		require.NoError(t, err)
+		if day.IsZero() || time.UnixMilli(ms).UTC().Truncate(24*time.Hour).Equal(day) {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func Test(t *testing.T) {
+	url, root := archiveServerForDownloadTest(t)
+	nov := func(day int) time.Time { return time.Date(2021, time.November, day, 0, 0, 0, 0, time.UTC) }
+	october := linesForDownloadTest(t, "BTCUSDT-1h-2021-10.csv", time.Time{})
+	addArchiveForDownloadTest(t, root, "monthly", "BTCUSDT-1h-2021-10", october)
+	for day := 1; day <= 3; day++ {
+		addArchiveForDownloadTest(t, root, "daily", "BTCUSDT-1h-2021-11-0"+strconv.Itoa(day),
+			linesForDownloadTest(t, "BTCUSDT-1h-2021-11.csv", nov(day)))
+	}
+
+	out := t.TempDir()
+	args := []string{"-url", url, "-out", out, "-symbol", "btcusdt", "-interval", "1h", "-from", "2021-10-01", "-to", "2021-11-02"}
+	require.NoError(t, run(args))
+
+	// A monthly archive for October and daily archives for the first two days of November
+	dir := filepath.Join(out, "btcusdt-1h")
+	files, err := filepath.Glob(filepath.Join(dir, "*.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "BTCUSDT-1h-2021-10.csv"),
+		filepath.Join(dir, "BTCUSDT-1h-2021-11-01.csv"),
+		filepath.Join(dir, "BTCUSDT-1h-2021-11-02.csv"),
+	}, files)
+
+	act, err := market.ReadKlinesFromCSV(dir)
+	require.NoError(t, err)
+	assert.Len(t, act, len(october)+48)
+
+	// Re-running skips the extracted archives
+	require.NoError(t, run(args))
+}
+
+func Test_DailyFallback(t *testing.T) {
+	url, root := archiveServerForDownloadTest(t)
+	nov := func(day int) time.Time { return time.Date(2021, time.November, day, 0, 0, 0, 0, time.UTC) }
+	for day := 1; day <= 2; day++ {
+		addArchiveForDownloadTest(t, root, "daily", "BTCUSDT-1h-2021-11-0"+strconv.Itoa(day),
+			linesForDownloadTest(t, "BTCUSDT-1h-2021-11.csv", nov(day)))
+	}
+
+	// No monthly archive for November so the published daily archives are downloaded
+	out := t.TempDir()
+	args := []string{"-url", url, "-out", out, "-symbol", "BTCUSDT", "-interval", "1h", "-from", "2021-11-01", "-to", "2021-11-30"}
+	require.NoError(t, run(args))
+	act, err := market.ReadKlinesFromCSV(filepath.Join(out, "btcusdt-1h"))
+	require.NoError(t, err)
+	assert.Len(t, act, 48)
+
+	// Once published, the monthly archive replaces the daily archives
+	november := linesForDownloadTest(t, "BTCUSDT-1h-2021-11.csv", time.Time{})
+	addArchiveForDownloadTest(t, root, "monthly", "BTCUSDT-1h-2021-11", november)
+	require.NoError(t, run(args))
+	act, err = market.ReadKlinesFromCSV(filepath.Join(out, "btcusdt-1h"))
+	require.NoError(t, err)
+	assert.Len(t, act, len(november))
+}
+
+func Test_Normalize(t *testing.T) {
+	url, root := archiveServerForDownloadTest(t)
+	nov := func(day int) time.Time { return time.Date(2021, time.November, day, 0, 0, 0, 0, time.UTC) }
+
+	// Futures archives have a header row
+	header := "open_time,open,high,low,close,volume,close_time,quote_volume,count,taker_buy_volume,taker_buy_quote_volume,ignore"
+	first := linesForDownloadTest(t, "BTCUSDT-1h-2021-11.csv", nov(1))
+	addArchiveForDownloadTest(t, root, "daily", "BTCUSDT-1h-2021-11-01", append([]string{header}, first...))
+
+	// Spot archives from 2025 have open and close times in microseconds
+	second := linesForDownloadTest(t, "BTCUSDT-1h-2021-11.csv", nov(2))
+	micros := make([]string, len(second))
+	for i, line := range second {
+		fields := strings.Split(line, ",")
+		fields[0] += "000"
+		fields[6] += "999"
+		micros[i] = strings.Join(fields, ",")
+	}
+	addArchiveForDownloadTest(t, root, "daily", "BTCUSDT-1h-2021-11-02", micros)
+
+	out := t.TempDir()
+	require.NoError(t, run([]string{"-url", url, "-out", out, "-symbol", "BTCUSDT", "-interval", "1h", "-from", "2021-11-01", "-to", "2021-11-02"}))
+
+	dir := filepath.Join(out, "btcusdt-1h")
+	for name, want := range map[string][]string{"BTCUSDT-1h-2021-11-01.csv": first, "BTCUSDT-1h-2021-11-02.csv": second} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Join(want, "\n")+"\n", string(data), name)
+	}
+	act, err := market.ReadKlinesFromCSV(dir)
+	require.NoError(t, err)
+	require.Len(t, act, 48)
+	assert.Equal(t, nov(1), act[0].Start)
+	assert.Equal(t, nov(2), act[24].Start)
+}
+
+func Test_Invalid(t *testing.T) {
+	url, root := archiveServerForDownloadTest(t)
+	addArchiveForDownloadTest(t, root, "daily", "BTCUSDT-1h-2021-11-01", []string{"1635724800000,1,1,1,1,1,1635728399999,1,1,1,1,0"})
+	checksum := filepath.Join(root, "daily", "klines", "BTCUSDT", "1h", "BTCUSDT-1h-2021-11-01.zip.CHECKSUM")
+	require.NoError(t, os.WriteFile(checksum, []byte(strings.Repeat("0", 64)+"  BTCUSDT-1h-2021-11-01.zip\n"), 0o600))
+
+	out := t.TempDir()
+	err := run([]string{"-url", url, "-out", out, "-symbol", "BTCUSDT", "-interval", "1h", "-from", "2021-11-01", "-to", "2021-11-01"})
+	assert.ErrorIs(t, err, errChecksum)
+	_, err = os.Stat(filepath.Join(out, "btcusdt-1h", "BTCUSDT-1h-2021-11-01.csv"))
+	assert.True(t, os.IsNotExist(err))
+
+	err = run([]string{"-url", url, "-out", out, "-symbol", "BTCUSDT", "-interval", "1h", "-from", "2021-11-02", "-to", "2021-11-02"})
+	assert.ErrorIs(t, err, errNotFound)
+
+	assert.Error(t, run([]string{"-url", url, "-symbol", "BTCUSDT", "-interval", "1h"}))
+	assert.Error(t, run([]string{"-url", url, "-symbol", "BTCUSDT", "-interval", "1h", "-from", "2021-11-02", "-to", "2021-11-01"}))
+}
+
+func TestArchives(t *testing.T) {
+	date := func(month time.Month, day int) time.Time { return time.Date(2021, month, day, 0, 0, 0, 0, time.UTC) }
+
+	act := archives(date(time.January, 30), date(time.March, 2))
+	assert.Equal(t, []archive{
+		{start: date(time.January, 30)},
+		{start: date(time.January, 31)},
+		{start: date(time.February, 1), monthly: true},
+		{start: date(time.March, 1)},
+		{start: date(time.March, 2)},
+	}, act)
+}
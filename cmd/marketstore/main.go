@@ -0,0 +1,82 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Command marketstore ingests a directory of CSV price files (or a single file) into a market data store.
+// Klines already in the store are skipped, so the command can be re-run as new files arrive.
+// Studyrun samples then reference the series with a store URI such as store://btcusdt/1h?from=2021-01-01.
+// The decoder is named by a key of market.CSVDecoders, e.g. binance or yahoo.adjusted.
+//
+// Usage:
+//
+//	marketstore [-db filename] [-decoder name] -asset symbol -interval duration path
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/store"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("marketstore", flag.ContinueOnError)
+	db := flags.String("db", "market.db", "store database filename")
+	decoder := flags.String("decoder", "binance", "CSV decoder: "+strings.Join(market.CSVDecoderNames(), ", "))
+	symbol := flags.String("asset", "", "asset symbol, e.g. btcusdt")
+	interval := flags.String("interval", "", "kline interval, e.g. 1m, 1h or 1d")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 || *symbol == "" || *interval == "" {
+		return errors.New("expect args: [flags] -asset [symbol] -interval [duration] [csv path]")
+	}
+	path := flags.Arg(0)
+
+	maker, ok := market.CSVDecoders[*decoder]
+	if !ok {
+		return fmt.Errorf("'%s' is not a valid decoder", *decoder)
+	}
+
+	// Parse the series as a store URI so that the interval has the same syntax as in a study config
+	q, err := store.ParseURI(fmt.Sprintf("%s://%s/%s", store.URIScheme, *symbol, *interval))
+	if err != nil {
+		return err
+	}
+
+	s, err := store.Open(*db)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	reader, err := market.NewCSVDirKlineReader(path, maker)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	added, err := s.Ingest(q.Asset, q.Interval, reader)
+	if err != nil {
+		return err
+	}
+	span, err := s.Span(q.Asset, q.Interval)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %d klines to %s %s in '%s' (%d klines from %s to %s)\n",
+		added, *symbol, *interval, *db, span.Count, span.First.Format(time.RFC3339), span.Last.Format(time.RFC3339))
+
+	return nil
+}
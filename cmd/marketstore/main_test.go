@@ -0,0 +1,34 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/store"
+)
+
+func Test(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "market.db")
+	args := []string{"-db", db, "-asset", "btcusdt", "-interval", "1h", "../studyrun/testdata/btcusdt-h1/"}
+	require.NoError(t, run(args))
+	require.NoError(t, run(args))
+
+	want, err := market.ReadKlinesFromCSV("../studyrun/testdata/btcusdt-h1/")
+	require.NoError(t, err)
+	s, err := store.Open(db)
+	require.NoError(t, err)
+	defer s.Close()
+	span, err := s.Span(market.NewAsset("btcusdt"), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, len(want), span.Count)
+
+	assert.Error(t, run([]string{"-db", db, "-asset", "btcusdt", "../studyrun/testdata/btcusdt-h1/"}))
+	assert.Error(t, run([]string{"-db", db, "-decoder", "unknown", "-asset", "btcusdt", "-interval", "1h", "../studyrun/testdata/btcusdt-h1/"}))
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Command shortfall reconciles recorded live (or paper) fills with the fills of a backtest over the same period.
+// It prints the implementation shortfall report of perf.NewShortfallReport
+// and the PerpCoster parameters suggested by perf.EstimatePerpCost.
+//
+// Each fills file is a CSV file with a header row and the columns:
+//
+//	filled_at,symbol,side,filled_price,filled_size,fee
+//
+// where filled_at is in RFC3339 format and side is Buy or Sell.
+// The spread and slippage flags are those of the cost model used to generate the backtest fills.
+//
+// Usage:
+//
+//	shortfall [-tolerance duration] [-spread pct] [-slippage pct] live.csv backtest.csv
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/perf"
+)
+
+var _fillsHeader = []string{"filled_at", "symbol", "side", "filled_price", "filled_size", "fee"}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("shortfall", flag.ContinueOnError)
+	tolerance := flags.Duration("tolerance", time.Minute, "max time between a live fill and its matching backtest fill")
+	spread := flags.Float64("spread", 0, "spread pct of the backtest cost model, e.g. 0.0002")
+	slippage := flags.Float64("slippage", 0, "slippage pct of the backtest cost model, e.g. 0.0005")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return errors.New("expect args: [flags] [live fills csv] [backtest fills csv]")
+	}
+
+	live, err := readFills(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	simulated, err := readFills(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report := perf.NewShortfallReport(live, simulated, *tolerance)
+	perf.PrintShortfallSummary(report)
+
+	estimate := perf.EstimatePerpCost(report, decimal.NewFromFloat(*spread), decimal.NewFromFloat(*slippage))
+	fmt.Printf("Suggested PerpCoster: SpreadPct=%s SlippagePct=%s TransactionPct=%s\n",
+		estimate.SpreadPct.StringFixed(6), estimate.SlippagePct.StringFixed(6), estimate.TransactionPct.StringFixed(6))
+
+	return nil
+}
+
+func readFills(filename string) ([]broker.Order, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(_fillsHeader)
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("'%s': %w", filename, err)
+	}
+
+	var fills []broker.Order
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("'%s': %w", filename, err)
+		}
+		fill, err := parseFill(rec)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' line %d: %w", filename, len(fills)+2, err)
+		}
+		fills = append(fills, fill)
+	}
+	return fills, nil
+}
+
+func parseFill(rec []string) (broker.Order, error) {
+	var fill broker.Order
+	var err error
+
+	if fill.FilledAt, err = time.Parse(time.RFC3339, rec[0]); err != nil {
+		return fill, err
+	}
+	fill.Asset = market.NewAsset(rec[1])
+	if err := fill.Side.UnmarshalText([]byte(rec[2])); err != nil {
+		return fill, err
+	}
+	if fill.FilledPrice, err = decimal.NewFromString(rec[3]); err != nil {
+		return fill, err
+	}
+	if fill.FilledSize, err = decimal.NewFromString(rec[4]); err != nil {
+		return fill, err
+	}
+	if fill.Fee, err = decimal.NewFromString(rec[5]); err != nil {
+		return fill, err
+	}
+
+	fill.Type = broker.Market
+	fill.OpenedAt = fill.FilledAt
+	fill.Size = fill.FilledSize
+	return fill, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func Test(t *testing.T) {
+	require.NoError(t, run([]string{"-tolerance", "10s", "-spread", "0.0002", "testdata/live.csv", "testdata/backtest.csv"}))
+
+	assert.Error(t, run([]string{"testdata/live.csv"}))
+	assert.Error(t, run([]string{"testdata/live.csv", "testdata/missing.csv"}))
+}
+
+func TestReadFills(t *testing.T) {
+	fills, err := readFills("testdata/live.csv")
+	require.NoError(t, err)
+	require.Len(t, fills, 3)
+
+	assert.Equal(t, time.Date(2022, 1, 3, 10, 0, 2, 0, time.UTC), fills[0].FilledAt.UTC())
+	assert.Equal(t, market.NewAsset("btcusdt"), fills[0].Asset)
+	assert.Equal(t, broker.Buy, fills[0].Side)
+	assert.True(t, decimal.RequireFromString("100.10").Equal(fills[0].FilledPrice))
+	assert.True(t, decimal.NewFromInt(2).Equal(fills[0].FilledSize))
+	assert.True(t, decimal.RequireFromString("0.08").Equal(fills[0].Fee))
+	assert.Equal(t, broker.Sell, fills[1].Side)
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	require.NoError(t, os.WriteFile(bad, []byte("filled_at,symbol,side,filled_price,filled_size,fee\n2022-01-03T10:00:02Z,btcusdt,Hold,100,1,0\n"), 0o644))
+	_, err = readFills(bad)
+	assert.ErrorIs(t, err, broker.ErrUnknownOrderSide)
+}
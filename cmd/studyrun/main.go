@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/thecolngroup/alphakit/cmd/studyrun/app"
+	"github.com/thecolngroup/alphakit/generator"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/trader"
 	"github.com/thecolngroup/alphakit/trader/hodl"
@@ -21,15 +22,25 @@ func main() {
 }
 
 func run(args []string) error {
+	registry := map[string]any{
+		"hodl":        trader.MakeFromConfig(hodl.MakeBotFromConfig),
+		"trend.cross": trader.MakeFromConfig(trend.MakeCrossBotFromConfig),
+		"trend.apex":  trader.MakeFromConfig(trend.MakeApexBotFromConfig),
+		"csv":         market.CSVSpec{},
+		"parquet":     market.DefaultParquetColumns(),
+		"gbm":         generator.MakeFromConfig(generator.MakeGBMFromConfig),
+		"garch":       generator.MakeFromConfig(generator.MakeGARCHFromConfig),
+		"heston":      generator.MakeFromConfig(generator.MakeHestonFromConfig),
+		"merton":      generator.MakeFromConfig(generator.MakeMertonFromConfig),
+		"bootstrap":   generator.MakeFromConfig(generator.MakeBlockBootstrapFromConfig),
+	}
+	for name, maker := range market.CSVDecoders {
+		registry[name] = maker
+	}
+
 	return app.Run(
 		args,
-		map[string]any{
-			"hodl":        trader.MakeFromConfig(hodl.MakeBotFromConfig),
-			"trend.cross": trader.MakeFromConfig(trend.MakeCrossBotFromConfig),
-			"trend.apex":  trader.MakeFromConfig(trend.MakeApexBotFromConfig),
-			"binance":     market.MakeCSVKlineReader(market.NewBinanceCSVKlineReader),
-			"metatrader":  market.MakeCSVKlineReader(market.NewMetaTraderCSVKlineReader),
-		},
+		registry,
 		app.BuildVersion{
 			GitTag:    buildGitTag,
 			GitCommit: buildGitCommit,
@@ -0,0 +1,110 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var _ Generator = (*BlockBootstrap)(nil)
+var _ MakeFromConfig = MakeBlockBootstrapFromConfig
+
+// BlockBootstrap generates prices by the stationary block bootstrap of a real price series (Politis and Romano):
+// the klines of the source are resampled in blocks of random length, preserving the distribution of returns
+// and their short term dependence, such as volatility clustering, while breaking any longer term pattern.
+//
+// Each generated kline applies the open, high, low and close of a source kline, relative to the previous
+// source close, to the previous generated close. Volume is copied from the source kline.
+// Series Steps and Volume are not used.
+type BlockBootstrap struct {
+	Series
+
+	// Source is the real price series to resample.
+	Source []market.Kline
+
+	// BlockSize is the mean number of klines in a block. Block lengths are geometrically distributed.
+	BlockSize float64
+}
+
+// NewBlockBootstrap creates a new BlockBootstrap generator.
+func NewBlockBootstrap(series Series, source []market.Kline, blockSize float64) *BlockBootstrap {
+	return &BlockBootstrap{
+		Series:    series,
+		Source:    source,
+		BlockSize: blockSize,
+	}
+}
+
+// Generate returns a new series of klines.
+func (g *BlockBootstrap) Generate() ([]market.Kline, error) {
+	if len(g.Source) < 2 || g.BlockSize < 1 {
+		return nil, fmt.Errorf("%w: bootstrap requires 2 or more source klines and a block size of 1 or more", ErrInvalidParams)
+	}
+	if err := g.Series.validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(g.Seed)) //nolint:gosec // Reproducible simulation not security
+
+	// Relative bar shapes of each source kline after the first
+	type shape struct{ o, h, l, c, volume float64 }
+	shapes := make([]shape, len(g.Source)-1)
+	for i := range shapes {
+		prev, k := g.Source[i].C.InexactFloat64(), g.Source[i+1]
+		shapes[i] = shape{
+			o:      k.O.InexactFloat64() / prev,
+			h:      k.H.InexactFloat64() / prev,
+			l:      k.L.InexactFloat64() / prev,
+			c:      k.C.InexactFloat64() / prev,
+			volume: k.Volume,
+		}
+	}
+
+	price := g.Price
+	next := rng.Intn(len(shapes))
+	klines := make([]market.Kline, g.Count)
+	for i := range klines {
+		s := shapes[next]
+		klines[i] = market.Kline{
+			Start:  g.Start.Add(time.Duration(i) * g.Interval),
+			O:      dec.New(price * s.o),
+			H:      dec.New(price * s.h),
+			L:      dec.New(price * s.l),
+			C:      dec.New(price * s.c),
+			Volume: s.volume,
+		}
+		price *= s.c
+
+		// Start a new block with probability 1 / BlockSize, otherwise continue the block (wrapping at the end)
+		if rng.Float64() < 1/g.BlockSize {
+			next = rng.Intn(len(shapes))
+		} else {
+			next = (next + 1) % len(shapes)
+		}
+	}
+	return klines, nil
+}
+
+// MakeBlockBootstrapFromConfig returns a BlockBootstrap generator of the source with key blocksize (default 24)
+// in addition to the series keys. The series defaults to the start, interval, length and initial price of the source.
+func MakeBlockBootstrapFromConfig(config map[string]any, source []market.Kline) (Generator, error) {
+	if len(source) < 2 {
+		return nil, fmt.Errorf("%w: bootstrap requires 2 or more source klines", ErrInvalidParams)
+	}
+	defaults := DefaultSeries()
+	defaults.Start = source[0].Start
+	defaults.Interval = source[1].Start.Sub(source[0].Start)
+	defaults.Count = len(source)
+	defaults.Price = source[0].C.InexactFloat64()
+
+	series, err := readSeriesFromConfig(config, defaults)
+	if err != nil {
+		return nil, err
+	}
+	return NewBlockBootstrap(series, source, floatFromConfig(config, "blocksize", 24)), nil
+}
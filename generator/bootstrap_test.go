@@ -0,0 +1,72 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestBlockBootstrap(t *testing.T) {
+	source, err := market.ReadKlinesFromCSV("../market/testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+
+	gen, err := MakeBlockBootstrapFromConfig(map[string]any{"blocksize": 12, "seed": 3}, source)
+	require.NoError(t, err)
+	act, err := gen.Generate()
+	require.NoError(t, err)
+
+	// Series defaults to the shape of the source
+	require.Len(t, act, len(source))
+	assert.Equal(t, source[0].Start, act[0].Start)
+	assert.Equal(t, source[1].Start, act[1].Start)
+
+	// Every generated return is a source return
+	sourceReturns := make(map[float64]bool)
+	for _, r := range logReturns(source) {
+		sourceReturns[roundForBootstrapTest(r)] = true
+	}
+	for i, r := range logReturns(act) {
+		assert.True(t, sourceReturns[roundForBootstrapTest(r)], "return %d", i)
+	}
+
+	again, err := gen.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, act, again)
+}
+
+func TestBlockBootstrap_Blocks(t *testing.T) {
+	source, err := market.ReadKlinesFromCSV("../market/testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	series := DefaultSeries()
+	series.Count = 2000
+
+	// A very large block size replays the source in order (wrapping at the end)
+	act, err := NewBlockBootstrap(series, source, 1e9).Generate()
+	require.NoError(t, err)
+
+	sourceReturns := logReturns(source)
+	actReturns := logReturns(act)
+	offset := -1
+	for i := range sourceReturns {
+		if roundForBootstrapTest(sourceReturns[i]) == roundForBootstrapTest(actReturns[0]) &&
+			roundForBootstrapTest(sourceReturns[(i+1)%len(sourceReturns)]) == roundForBootstrapTest(actReturns[1]) {
+			offset = i
+			break
+		}
+	}
+	require.GreaterOrEqual(t, offset, 0)
+	for i := 0; i < 100; i++ {
+		assert.Equal(t, roundForBootstrapTest(sourceReturns[(offset+i)%len(sourceReturns)]), roundForBootstrapTest(actReturns[i]))
+	}
+}
+
+// roundForBootstrapTest removes the float error of recomputing returns from generated prices.
+func roundForBootstrapTest(r float64) float64 {
+	const precision = 1e7
+	return float64(int64(r*precision)) / precision
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Generator = (*GARCH)(nil)
+var _ MakeFromConfig = MakeGARCHFromConfig
+
+// GARCH generates prices with GARCH(1,1) volatility clustering:
+// the variance of each step is Omega + Alpha * last squared shock + Beta * last variance.
+// Parameters are per model step (kline interval divided by Steps) rather than annualized.
+type GARCH struct {
+	Series
+
+	// Mean is the expected log return of a step.
+	Mean float64
+
+	Omega float64
+	Alpha float64
+	Beta  float64
+}
+
+// NewGARCH creates a new GARCH generator.
+func NewGARCH(series Series, mean, omega, alpha, beta float64) *GARCH {
+	return &GARCH{
+		Series: series,
+		Mean:   mean,
+		Omega:  omega,
+		Alpha:  alpha,
+		Beta:   beta,
+	}
+}
+
+// Generate returns a new series of klines. The variance starts at its long run level.
+func (g *GARCH) Generate() ([]market.Kline, error) {
+	if g.Omega <= 0 || g.Alpha < 0 || g.Beta < 0 || g.Alpha+g.Beta >= 1 {
+		return nil, fmt.Errorf("%w: garch requires omega > 0 and alpha + beta < 1", ErrInvalidParams)
+	}
+	return generate(g.Series, &garchProcess{GARCH: g, variance: g.Omega / (1 - g.Alpha - g.Beta)})
+}
+
+type garchProcess struct {
+	*GARCH
+	variance float64
+}
+
+func (p *garchProcess) step(rng *rand.Rand, _ float64) float64 {
+	shock := math.Sqrt(p.variance) * rng.NormFloat64()
+	p.variance = p.Omega + p.Alpha*shock*shock + p.Beta*p.variance
+	return p.Mean + shock
+}
+
+// MakeGARCHFromConfig returns a GARCH generator with keys mean, omega (default 1e-5), alpha (default 0.1)
+// and beta (default 0.85) in addition to the series keys.
+func MakeGARCHFromConfig(config map[string]any, _ []market.Kline) (Generator, error) {
+	series, err := readSeriesFromConfig(config, DefaultSeries())
+	if err != nil {
+		return nil, err
+	}
+	return NewGARCH(
+		series,
+		floatFromConfig(config, "mean", 0),
+		floatFromConfig(config, "omega", 1e-5),
+		floatFromConfig(config, "alpha", 0.1),
+		floatFromConfig(config, "beta", 0.85),
+	), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Generator = (*GBM)(nil)
+var _ MakeFromConfig = MakeGBMFromConfig
+
+// GBM generates prices by geometric Brownian motion, the model of Black-Scholes:
+// log returns are normal with constant drift and volatility.
+type GBM struct {
+	Series
+
+	// Drift is the annualized expected return.
+	Drift float64
+
+	// Volatility is the annualized standard deviation of log returns.
+	Volatility float64
+}
+
+// NewGBM creates a new GBM generator.
+func NewGBM(series Series, drift, volatility float64) *GBM {
+	return &GBM{
+		Series:     series,
+		Drift:      drift,
+		Volatility: volatility,
+	}
+}
+
+// Generate returns a new series of klines.
+func (g *GBM) Generate() ([]market.Kline, error) {
+	if g.Volatility < 0 {
+		return nil, fmt.Errorf("%w: volatility must not be negative", ErrInvalidParams)
+	}
+	return generate(g.Series, g)
+}
+
+func (g *GBM) step(rng *rand.Rand, dt float64) float64 {
+	return (g.Drift-g.Volatility*g.Volatility/2)*dt + g.Volatility*math.Sqrt(dt)*rng.NormFloat64()
+}
+
+// MakeGBMFromConfig returns a GBM generator with keys drift and volatility (default 0.5)
+// in addition to the series keys.
+func MakeGBMFromConfig(config map[string]any, _ []market.Kline) (Generator, error) {
+	series, err := readSeriesFromConfig(config, DefaultSeries())
+	if err != nil {
+		return nil, err
+	}
+	return NewGBM(
+		series,
+		floatFromConfig(config, "drift", 0),
+		floatFromConfig(config, "volatility", 0.5),
+	), nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package generator provides synthetic price series for testing the robustness of an algo
+// on data that it was not fitted to.
+// Each generator is parameterized by a stochastic model and a Series,
+// and is deterministic for a given seed so that a study can be reproduced.
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/conv"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// _hoursPerYear is used to convert annualized model parameters to the kline interval.
+// Markets are assumed to trade around the clock, 365 days a year.
+const _hoursPerYear = 365 * 24
+
+// ErrInvalidParams is returned when the parameters of a generator are out of range.
+var ErrInvalidParams = errors.New("invalid generator params")
+
+// Generator generates a synthetic series of klines.
+type Generator interface {
+	Generate() ([]market.Kline, error)
+}
+
+// MakeFromConfig is a factory for building a generator from a config.
+// Source is the real price series to resample, if any, for generators such as BlockBootstrap.
+// Used by studyrun to generate price samples.
+type MakeFromConfig func(config map[string]any, source []market.Kline) (Generator, error)
+
+// Series defines the shape of a generated series.
+type Series struct {
+	// Start is the start time of the first kline.
+	Start time.Time
+
+	// Interval is the duration of each kline.
+	Interval time.Duration
+
+	// Count is the number of klines to generate.
+	Count int
+
+	// Price is the initial price.
+	Price float64
+
+	// Steps is the number of model steps simulated within each kline to form its high and low.
+	Steps int
+
+	// Volume is the mean volume of a kline. Volume is lognormal and independent of price.
+	Volume float64
+
+	// Seed initializes the random source.
+	Seed int64
+}
+
+// DefaultSeries returns a series of 1000 hourly klines from 2021 with an initial price of 100.
+func DefaultSeries() Series {
+	return Series{
+		Start:    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Interval: time.Hour,
+		Count:    1000,
+		Price:    100,
+		Steps:    4,
+		Volume:   1000,
+		Seed:     1,
+	}
+}
+
+func (s Series) validate() error {
+	if s.Interval <= 0 || s.Count <= 0 || s.Price <= 0 || s.Steps < 0 || s.Volume < 0 {
+		return fmt.Errorf("%w: series must have positive interval, count and price", ErrInvalidParams)
+	}
+	return nil
+}
+
+// process is a stochastic model of log returns.
+type process interface {
+	// step returns the log return over dt, the fraction of a year.
+	step(rng *rand.Rand, dt float64) float64
+}
+
+// generate simulates the process over each kline of the series.
+func generate(s Series, p process) ([]market.Kline, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(s.Seed)) //nolint:gosec // Reproducible simulation not security
+	steps := s.Steps
+	if steps == 0 {
+		steps = 1
+	}
+	dt := s.Interval.Hours() / _hoursPerYear / float64(steps)
+
+	price := s.Price
+	klines := make([]market.Kline, s.Count)
+	for i := range klines {
+		o, h, l := price, price, price
+		for j := 0; j < steps; j++ {
+			price *= math.Exp(p.step(rng, dt))
+			h = math.Max(h, price)
+			l = math.Min(l, price)
+		}
+		klines[i] = market.Kline{
+			Start:  s.Start.Add(time.Duration(i) * s.Interval),
+			O:      dec.New(o),
+			H:      dec.New(h),
+			L:      dec.New(l),
+			C:      dec.New(price),
+			Volume: volume(rng, s.Volume),
+		}
+	}
+	return klines, nil
+}
+
+// volume draws a lognormal volume with the given mean.
+func volume(rng *rand.Rand, mean float64) float64 {
+	const sigma = 0.5
+	return mean * math.Exp(sigma*rng.NormFloat64()-sigma*sigma/2)
+}
+
+// readSeriesFromConfig reads the series keys of a config, overriding the given defaults.
+// Keys are start (a time or RFC3339 string), interval (a duration such as "1h"), count, price, steps, volume and seed.
+func readSeriesFromConfig(config map[string]any, s Series) (Series, error) {
+	if v, ok := config["start"]; ok {
+		switch start := v.(type) {
+		case time.Time:
+			s.Start = start
+		default:
+			t, err := time.Parse(time.RFC3339, conv.ToString(v))
+			if err != nil {
+				return s, err
+			}
+			s.Start = t
+		}
+	}
+	if v, ok := config["interval"]; ok {
+		interval, err := time.ParseDuration(conv.ToString(v))
+		if err != nil {
+			return s, err
+		}
+		s.Interval = interval
+	}
+	if v, ok := config["count"]; ok {
+		s.Count = conv.ToInt(v)
+	}
+	s.Price = floatFromConfig(config, "price", s.Price)
+	if v, ok := config["steps"]; ok {
+		s.Steps = conv.ToInt(v)
+	}
+	s.Volume = floatFromConfig(config, "volume", s.Volume)
+	if v, ok := config["seed"]; ok {
+		s.Seed = int64(conv.ToInt(v))
+	}
+	return s, s.validate()
+}
+
+// floatFromConfig returns the value of the key, or the default if the key is not present.
+func floatFromConfig(config map[string]any, key string, def float64) float64 {
+	if v, ok := config[key]; ok {
+		return conv.ToFloat(v)
+	}
+	return def
+}
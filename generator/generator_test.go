@@ -0,0 +1,158 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"gonum.org/v1/gonum/stat"
+)
+
+func logReturns(klines []market.Kline) []float64 {
+	returns := make([]float64, len(klines)-1)
+	for i := range returns {
+		returns[i] = math.Log(klines[i+1].C.InexactFloat64() / klines[i].C.InexactFloat64())
+	}
+	return returns
+}
+
+func TestGenerators(t *testing.T) {
+	series := DefaultSeries()
+	series.Count = 5000
+
+	tests := []struct {
+		name string
+		give func(Series) Generator
+	}{
+		{
+			name: "gbm",
+			give: func(s Series) Generator { return NewGBM(s, 0, 0.5) },
+		},
+		{
+			name: "garch",
+			give: func(s Series) Generator { return NewGARCH(s, 0, 1e-5, 0.1, 0.85) },
+		},
+		{
+			name: "heston",
+			give: func(s Series) Generator { return NewHeston(s, 0, 2, 0.25, 0.5, -0.7, 0.25) },
+		},
+		{
+			name: "merton",
+			give: func(s Series) Generator { return NewMerton(s, 0, 0.4, 10, -0.02, 0.05) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := tt.give(series).Generate()
+			require.NoError(t, err)
+			require.Len(t, act, series.Count)
+
+			for i, k := range act {
+				assert.Equal(t, series.Start.Add(time.Duration(i)*series.Interval), k.Start)
+				assert.True(t, k.H.GreaterThanOrEqual(k.O) && k.H.GreaterThanOrEqual(k.C), "high at %d", i)
+				assert.True(t, k.L.LessThanOrEqual(k.O) && k.L.LessThanOrEqual(k.C), "low at %d", i)
+				assert.True(t, k.L.IsPositive())
+				assert.Positive(t, k.Volume)
+				if i > 0 {
+					assert.True(t, k.O.Equal(act[i-1].C))
+				}
+			}
+
+			again, err := tt.give(series).Generate()
+			require.NoError(t, err)
+			assert.Equal(t, act, again, "same seed")
+
+			reseeded := series
+			reseeded.Seed++
+			other, err := tt.give(reseeded).Generate()
+			require.NoError(t, err)
+			assert.NotEqual(t, act[len(act)-1].C, other[len(other)-1].C, "different seed")
+		})
+	}
+}
+
+func TestGBM_Volatility(t *testing.T) {
+	series := DefaultSeries()
+	series.Count = 20000
+	act, err := NewGBM(series, 0, 0.5).Generate()
+	require.NoError(t, err)
+
+	want := 0.5 * math.Sqrt(series.Interval.Hours()/_hoursPerYear)
+	assert.InEpsilon(t, want, stat.StdDev(logReturns(act), nil), 0.05)
+}
+
+func TestGARCH_VolatilityClustering(t *testing.T) {
+	series := DefaultSeries()
+	series.Count = 20000
+	series.Steps = 1
+	act, err := NewGARCH(series, 0, 1e-5, 0.2, 0.75).Generate()
+	require.NoError(t, err)
+
+	returns := logReturns(act)
+	squared := make([]float64, len(returns))
+	for i := range returns {
+		squared[i] = returns[i] * returns[i]
+	}
+	autocorr := stat.Correlation(squared[1:], squared[:len(squared)-1], nil)
+	assert.Greater(t, autocorr, 0.1)
+}
+
+func TestMerton_Jumps(t *testing.T) {
+	series := DefaultSeries()
+	series.Count = 20000
+	act, err := NewMerton(series, 0, 0.4, 50, 0, 0.05).Generate()
+	require.NoError(t, err)
+
+	// Jumps give fat tails compared to the normal distribution
+	assert.Greater(t, stat.ExKurtosis(logReturns(act), nil), 1.0)
+}
+
+func TestGenerators_InvalidParams(t *testing.T) {
+	series := DefaultSeries()
+	invalid := series
+	invalid.Count = 0
+
+	tests := []struct {
+		name string
+		give Generator
+	}{
+		{name: "series", give: NewGBM(invalid, 0, 0.5)},
+		{name: "gbm", give: NewGBM(series, 0, -0.5)},
+		{name: "garch", give: NewGARCH(series, 0, 1e-5, 0.5, 0.5)},
+		{name: "heston", give: NewHeston(series, 0, 2, 0.25, 0.5, -1.5, 0.25)},
+		{name: "merton", give: NewMerton(series, 0, 0.4, -1, 0, 0.05)},
+		{name: "bootstrap", give: NewBlockBootstrap(series, nil, 24)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.give.Generate()
+			assert.ErrorIs(t, err, ErrInvalidParams)
+		})
+	}
+}
+
+func TestMakeGBMFromConfig(t *testing.T) {
+	gen, err := MakeGBMFromConfig(map[string]any{
+		"start":      "2022-06-01T00:00:00Z",
+		"interval":   "15m",
+		"count":      10,
+		"price":      50.0,
+		"volatility": 0.8,
+		"seed":       7,
+	}, nil)
+	require.NoError(t, err)
+
+	gbm := gen.(*GBM)
+	assert.Equal(t, time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC), gbm.Start)
+	assert.Equal(t, 15*time.Minute, gbm.Interval)
+	assert.Equal(t, 10, gbm.Count)
+	assert.Equal(t, 50.0, gbm.Price)
+	assert.Equal(t, 0.8, gbm.Volatility)
+	assert.Equal(t, int64(7), gbm.Seed)
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Generator = (*Heston)(nil)
+var _ MakeFromConfig = MakeHestonFromConfig
+
+// Heston generates prices with stochastic volatility: the variance reverts to a long run level
+// and its shocks are correlated with price shocks, typically negatively to model the leverage effect.
+// The model is simulated by Euler discretization with full truncation of negative variance.
+type Heston struct {
+	Series
+
+	// Drift is the annualized expected return.
+	Drift float64
+
+	// Kappa is the rate at which the variance reverts to Theta.
+	Kappa float64
+
+	// Theta is the long run annualized variance.
+	Theta float64
+
+	// Xi is the volatility of the variance.
+	Xi float64
+
+	// Rho is the correlation of price and variance shocks.
+	Rho float64
+
+	// V0 is the initial annualized variance.
+	V0 float64
+}
+
+// NewHeston creates a new Heston generator.
+func NewHeston(series Series, drift, kappa, theta, xi, rho, v0 float64) *Heston {
+	return &Heston{
+		Series: series,
+		Drift:  drift,
+		Kappa:  kappa,
+		Theta:  theta,
+		Xi:     xi,
+		Rho:    rho,
+		V0:     v0,
+	}
+}
+
+// Generate returns a new series of klines.
+func (g *Heston) Generate() ([]market.Kline, error) {
+	if g.Kappa <= 0 || g.Theta <= 0 || g.Xi < 0 || g.V0 < 0 || math.Abs(g.Rho) > 1 {
+		return nil, fmt.Errorf("%w: heston requires positive kappa and theta and rho within [-1, 1]", ErrInvalidParams)
+	}
+	return generate(g.Series, &hestonProcess{Heston: g, variance: g.V0})
+}
+
+type hestonProcess struct {
+	*Heston
+	variance float64
+}
+
+func (p *hestonProcess) step(rng *rand.Rand, dt float64) float64 {
+	z1 := rng.NormFloat64()
+	z2 := p.Rho*z1 + math.Sqrt(1-p.Rho*p.Rho)*rng.NormFloat64()
+
+	v := math.Max(p.variance, 0)
+	r := (p.Drift-v/2)*dt + math.Sqrt(v*dt)*z1
+	p.variance += p.Kappa*(p.Theta-v)*dt + p.Xi*math.Sqrt(v*dt)*z2
+	return r
+}
+
+// MakeHestonFromConfig returns a Heston generator with keys drift, kappa (default 2), theta (default 0.25),
+// xi (default 0.5), rho (default -0.7) and v0 (default theta) in addition to the series keys.
+func MakeHestonFromConfig(config map[string]any, _ []market.Kline) (Generator, error) {
+	series, err := readSeriesFromConfig(config, DefaultSeries())
+	if err != nil {
+		return nil, err
+	}
+	theta := floatFromConfig(config, "theta", 0.25)
+	return NewHeston(
+		series,
+		floatFromConfig(config, "drift", 0),
+		floatFromConfig(config, "kappa", 2),
+		theta,
+		floatFromConfig(config, "xi", 0.5),
+		floatFromConfig(config, "rho", -0.7),
+		floatFromConfig(config, "v0", theta),
+	), nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package generator
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Generator = (*Merton)(nil)
+var _ MakeFromConfig = MakeMertonFromConfig
+
+// Merton generates prices by jump diffusion: geometric Brownian motion plus jumps
+// that arrive as a Poisson process with normally distributed log jump sizes.
+// The drift is compensated for the jumps so that Drift remains the expected return.
+type Merton struct {
+	Series
+
+	// Drift is the annualized expected return.
+	Drift float64
+
+	// Volatility is the annualized standard deviation of the diffusion.
+	Volatility float64
+
+	// Lambda is the expected number of jumps in a year.
+	Lambda float64
+
+	// JumpMean is the mean log jump size.
+	JumpMean float64
+
+	// JumpStd is the standard deviation of the log jump size.
+	JumpStd float64
+}
+
+// NewMerton creates a new Merton generator.
+func NewMerton(series Series, drift, volatility, lambda, jumpMean, jumpStd float64) *Merton {
+	return &Merton{
+		Series:     series,
+		Drift:      drift,
+		Volatility: volatility,
+		Lambda:     lambda,
+		JumpMean:   jumpMean,
+		JumpStd:    jumpStd,
+	}
+}
+
+// Generate returns a new series of klines.
+func (g *Merton) Generate() ([]market.Kline, error) {
+	if g.Volatility < 0 || g.Lambda < 0 || g.JumpStd < 0 {
+		return nil, fmt.Errorf("%w: merton requires non-negative volatility, lambda and jump std", ErrInvalidParams)
+	}
+	return generate(g.Series, g)
+}
+
+func (g *Merton) step(rng *rand.Rand, dt float64) float64 {
+	k := math.Exp(g.JumpMean+g.JumpStd*g.JumpStd/2) - 1
+	r := (g.Drift-g.Volatility*g.Volatility/2-g.Lambda*k)*dt + g.Volatility*math.Sqrt(dt)*rng.NormFloat64()
+	for n := poisson(rng, g.Lambda*dt); n > 0; n-- {
+		r += g.JumpMean + g.JumpStd*rng.NormFloat64()
+	}
+	return r
+}
+
+// poisson draws from a Poisson distribution with the given mean by Knuth's method, suitable for a small mean.
+func poisson(rng *rand.Rand, mean float64) int {
+	limit := math.Exp(-mean)
+	n := 0
+	for p := rng.Float64(); p > limit; p *= rng.Float64() {
+		n++
+	}
+	return n
+}
+
+// MakeMertonFromConfig returns a Merton generator with keys drift, volatility (default 0.4), lambda (default 10),
+// jumpmean (default -0.02) and jumpstd (default 0.05) in addition to the series keys.
+func MakeMertonFromConfig(config map[string]any, _ []market.Kline) (Generator, error) {
+	series, err := readSeriesFromConfig(config, DefaultSeries())
+	if err != nil {
+		return nil, err
+	}
+	return NewMerton(
+		series,
+		floatFromConfig(config, "drift", 0),
+		floatFromConfig(config, "volatility", 0.4),
+		floatFromConfig(config, "lambda", 10),
+		floatFromConfig(config, "jumpmean", -0.02),
+		floatFromConfig(config, "jumpstd", 0.05),
+	), nil
+}
@@ -46,11 +46,12 @@ func (app *App) Run() error {
 	print("done\n")
 
 	print("Reading price samples... ")
-	samples, err := readPricesFromConfig(config, app.TypeRegistry)
+	samples, qualityReports, dividends, err := readPricesFromConfig(config, app.TypeRegistry)
 	if err != nil {
 		return err
 	}
 	print("done\n")
+	printQualitySummary(qualityReports)
 
 	print("Reading param space... ")
 	psets, err := readParamSpaceFromConfig(config)
@@ -72,6 +73,7 @@ func (app *App) Run() error {
 		return err
 	}
 	optimizer.MakeDealer = makeDealer
+	optimizer.Dividends = dividends
 	print("done\n")
 
 	print("\n----- Study Execution -----\n")
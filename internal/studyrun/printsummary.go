@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/olekukonko/tablewriter"
+	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/optimize"
 	"github.com/thecolngroup/gou/conv"
 	"golang.org/x/exp/maps"
@@ -49,6 +50,28 @@ func printSummary(report optimize.PhaseReport) {
 
 }
 
+// printQualitySummary prints the count of each kind of data anomaly found in each sample to stdout.
+func printQualitySummary(reports map[optimize.AssetID]market.QualityReport) {
+	header := []string{"Sample", "Klines", "Interval"}
+	for _, kind := range market.AnomalyKinds {
+		header = append(header, kind.String())
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	keys := maps.Keys(reports)
+	slices.Sort(keys)
+	for _, k := range keys {
+		report := reports[k]
+		row := []string{string(k), fmt.Sprintf("%d", report.KlineCount), report.Interval.String()}
+		for _, kind := range market.AnomalyKinds {
+			row = append(row, fmt.Sprintf("%d", report.Count(kind)))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
+
 // printParams pretty prints a map.
 func printParams(params map[string]any) {
 	keys := maps.Keys(params)
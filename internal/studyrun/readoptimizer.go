@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/alphakit/optimize"
 	"github.com/thecolngroup/alphakit/trader"
 	"github.com/thecolngroup/gou/conv"
@@ -38,5 +39,23 @@ func readBruteOptimizerFromConfig(config map[string]any, typeRegistry map[string
 	optimizer.WarmupBarCount = conv.ToInt(root["warmupbarcount"])
 	optimizer.Ranker = optimize.SharpeRanker
 
+	// Load optional trading calendar to measure performance by session
+	if _, ok := root["calendar"]; ok {
+		name := conv.ToString(root["calendar"])
+		makeCalendar, ok := _calendars[name]
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not a valid calendar", name)
+		}
+		optimizer.Calendar = makeCalendar()
+	}
+
 	return &optimizer, nil
 }
+
+// _calendars are the trading calendars that may be selected by the optimizer 'calendar' key.
+var _calendars = map[string]func() *calendar.Calendar{
+	"crypto": calendar.NewCryptoCalendar,
+	"nyse":   calendar.NewNYSECalendar,
+	"cme":    calendar.NewCMEGlobexCalendar,
+	"fx":     calendar.NewFXCalendar,
+}
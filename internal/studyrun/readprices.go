@@ -6,46 +6,358 @@ package studyrun
 import (
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/thecolngroup/alphakit/generator"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/optimize"
+	"github.com/thecolngroup/alphakit/store"
 	"github.com/thecolngroup/gou/conv"
 )
 
 // readPricesFromConfig reads the price samples from a config file params.
-func readPricesFromConfig(config map[string]any, typeRegistry map[string]any) (map[optimize.AssetID][]market.Kline, error) {
+// Each sample is validated and cleaned, and a data quality report is returned for each.
+// The expected interval between klines is read from the optional 'interval' key, otherwise it is inferred.
+// Samples with a store URI path are read from the market data store database named by the 'store' key.
+// The cash dividends of samples adjusted for splits only are returned for the dealer to pay.
+func readPricesFromConfig(config map[string]any, typeRegistry map[string]any) (map[optimize.AssetID][]market.Kline, map[optimize.AssetID]market.QualityReport, map[optimize.AssetID][]market.CorporateAction, error) {
 
 	if _, ok := config["samples"]; !ok {
-		return nil, errors.New("'samples' key not found")
+		return nil, nil, nil, errors.New("'samples' key not found")
+	}
+
+	var db *store.Store
+	if _, ok := config["store"]; ok {
+		var err error
+		if db, err = store.Open(conv.ToString(config["store"])); err != nil {
+			return nil, nil, nil, err
+		}
+		defer db.Close()
 	}
 	root := config["samples"].([]any)
 	samples := make(map[optimize.AssetID][]market.Kline)
+	reports := make(map[optimize.AssetID]market.QualityReport)
+	dividends := make(map[optimize.AssetID][]market.CorporateAction)
 
 	for _, sub := range root {
 
 		cfg := sub.(map[string]any)
 
-		// Load decoder from type registry
-		if _, ok := cfg["decoder"]; !ok {
-			return nil, errors.New("'decoder' key not found")
+		series, err := readSeriesFromConfig(cfg, typeRegistry, db)
+		if err != nil {
+			return nil, nil, nil, err
 		}
-		decoder := conv.ToString(cfg["decoder"])
-		if _, ok := typeRegistry[decoder]; !ok {
-			return nil, fmt.Errorf("'%s' key not found in type registry", decoder)
+
+		// Optionally back-adjust equity prices for splits, and for dividends to a total return series
+		// unless the dividends are to be paid as cash by the dealer
+		var cash []market.CorporateAction
+		if _, ok := cfg["actions"]; ok {
+			actions, err := market.ReadCorporateActionsFromCSV(conv.ToString(cfg["actions"]))
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			adjustment, err := readAdjustmentFromConfig(cfg)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if series, cash, err = market.AdjustForCorporateActions(series, actions, adjustment); err != nil {
+				return nil, nil, nil, err
+			}
 		}
-		maker := typeRegistry[decoder].(market.MakeCSVKlineReader)
 
-		// Load path to price files from config
-		path := cfg["path"].(string)
-		series, err := market.ReadKlinesFromCSVWithDecoder(path, maker)
+		// Validate and clean the sample before further processing
+		policy, err := readCleanPolicyFromConfig(cfg)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
+		}
+		var interval time.Duration
+		if _, ok := cfg["interval"]; ok {
+			if interval, err = time.ParseDuration(conv.ToString(cfg["interval"])); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		series, report, err := market.Clean(series, interval, policy)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		// Optionally resample to a higher timeframe
+		if _, ok := cfg["resample"]; ok {
+			tf, err := readTimeframeFromConfig(cfg)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			series = market.Resample(series, tf)
 		}
 
 		// Load asset key from config
 		assetID := optimize.AssetID(cfg["asset"].(string))
 		samples[assetID] = series
+		reports[assetID] = report
+		if len(cash) > 0 {
+			dividends[assetID] = cash
+		}
+	}
+
+	return samples, reports, dividends, nil
+}
+
+// readSeriesFromConfig reads the klines of a sample from its path with the registered decoder,
+// or generates them with a registered generator configured by the optional 'generator' table.
+// A generator that resamples real prices reads them from the path with the decoder named by the 'source' key,
+// or from the store if the path is a store URI.
+// A store URI path without a decoder, e.g. store://btcusdt/1h?from=2021-01-01, is read from the store.
+func readSeriesFromConfig(cfg map[string]any, typeRegistry map[string]any, db *store.Store) ([]market.Kline, error) {
+
+	// Load path to price files from config
+	path := conv.ToString(cfg["path"])
+	if _, ok := cfg["decoder"]; !ok && store.IsURI(path) {
+		return readSeriesFromStore(path, db)
+	}
+
+	// Load decoder from type registry
+	if _, ok := cfg["decoder"]; !ok {
+		return nil, errors.New("'decoder' key not found")
+	}
+	decoder := conv.ToString(cfg["decoder"])
+	if _, ok := typeRegistry[decoder]; !ok {
+		return nil, fmt.Errorf("'%s' key not found in type registry", decoder)
 	}
 
-	return samples, nil
+	switch reg := typeRegistry[decoder].(type) {
+	case market.MakeCSVKlineReader:
+		return market.ReadKlinesFromCSVWithDecoder(path, reg, decoder)
+	case market.CSVSpec:
+		spec, err := readCSVSpecFromConfig(cfg, reg)
+		if err != nil {
+			return nil, err
+		}
+		return market.ReadKlinesFromCSVWithDecoder(path, spec.NewCSVKlineReader, "")
+	case market.ParquetColumns:
+		columns, err := readParquetColumnsFromConfig(cfg, reg)
+		if err != nil {
+			return nil, err
+		}
+		return market.ReadKlinesFromParquet(path, columns)
+	case generator.MakeFromConfig:
+		var source []market.Kline
+		if _, ok := cfg["source"]; ok || store.IsURI(path) {
+			sourceCfg := make(map[string]any, len(cfg))
+			for k, v := range cfg {
+				sourceCfg[k] = v
+			}
+			delete(sourceCfg, "decoder")
+			if ok {
+				sourceCfg["decoder"] = cfg["source"]
+			}
+			delete(sourceCfg, "source")
+			var err error
+			if source, err = readSeriesFromConfig(sourceCfg, typeRegistry, db); err != nil {
+				return nil, err
+			}
+		}
+		params := make(map[string]any)
+		if _, ok := cfg["generator"]; ok {
+			if params, ok = cfg["generator"].(map[string]any); !ok {
+				return nil, errors.New("'generator' key must be a table")
+			}
+		}
+		gen, err := reg(params, source)
+		if err != nil {
+			return nil, err
+		}
+		return gen.Generate()
+	default:
+		return nil, fmt.Errorf("'%s' is not a price decoder", decoder)
+	}
+}
+
+// readSeriesFromStore reads the klines selected by a store URI.
+func readSeriesFromStore(uri string, db *store.Store) ([]market.Kline, error) {
+	if db == nil {
+		return nil, fmt.Errorf("'store' key not found for sample '%s'", uri)
+	}
+	q, err := store.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return db.ReadKlines(q)
+}
+
+// readAdjustmentFromConfig reads the optional corporate action adjustment of a sample from the 'adjust' key:
+// total (the default) to adjust for splits and dividends, or splits to adjust for splits only.
+func readAdjustmentFromConfig(cfg map[string]any) (market.CorporateActionAdjustment, error) {
+	if _, ok := cfg["adjust"]; !ok {
+		return market.AdjustSplitsAndDividends, nil
+	}
+	switch adjust := strings.ToLower(conv.ToString(cfg["adjust"])); adjust {
+	case "total":
+		return market.AdjustSplitsAndDividends, nil
+	case "splits":
+		return market.AdjustSplits, nil
+	default:
+		return market.AdjustSplitsAndDividends, fmt.Errorf("'%s' is not a valid adjust value", adjust)
+	}
+}
+
+// readCleanPolicyFromConfig reads the optional repair policy of a sample.
+// Keys of the 'clean' table are the anomaly kinds: order, duplicate, gap, zerovolume, range and misaligned.
+// Values are the repair names: ignore, fail, sort, dedupe, drop, fill, flag and align.
+func readCleanPolicyFromConfig(cfg map[string]any) (market.CleanPolicy, error) {
+	policy := market.DefaultCleanPolicy()
+	if _, ok := cfg["clean"]; !ok {
+		return policy, nil
+	}
+	root := cfg["clean"].(map[string]any)
+
+	fields := map[string]*market.Repair{
+		"order":      &policy.OutOfOrder,
+		"duplicate":  &policy.Duplicate,
+		"gap":        &policy.Gap,
+		"zerovolume": &policy.ZeroVolume,
+		"range":      &policy.InvalidRange,
+		"misaligned": &policy.Misaligned,
+	}
+	for k, v := range root {
+		field, ok := fields[strings.ToLower(k)]
+		if !ok {
+			return policy, fmt.Errorf("'%s' is not a valid clean key", k)
+		}
+		repair, err := market.ParseRepair(conv.ToString(v))
+		if err != nil {
+			return policy, err
+		}
+		*field = repair
+	}
+	return policy, nil
+}
+
+// readCSVSpecFromConfig reads the layout of a generic CSV sample, overriding the registered defaults.
+// Keys of the 'columns' table are start, time, open, high, low, close, volume, quotevolume, tradecount,
+// takerbuyvolume and takerbuyquotevolume, each a column index or header name.
+// Keys of the optional 'csv' table are timeformat, timezone, delimiter, skiprows and header.
+func readCSVSpecFromConfig(cfg map[string]any, spec market.CSVSpec) (market.CSVSpec, error) {
+	if _, ok := cfg["columns"]; !ok {
+		return spec, errors.New("'columns' key not found")
+	}
+	root := cfg["columns"].(map[string]any)
+
+	columns := map[string]*string{
+		"start":  &spec.Start,
+		"time":   &spec.Time,
+		"open":   &spec.Open,
+		"high":   &spec.High,
+		"low":    &spec.Low,
+		"close":  &spec.Close,
+		"volume": &spec.Volume,
+
+		"quotevolume":         &spec.QuoteVolume,
+		"tradecount":          &spec.TradeCount,
+		"takerbuyvolume":      &spec.TakerBuyVolume,
+		"takerbuyquotevolume": &spec.TakerBuyQuoteVolume,
+	}
+	for k, v := range root {
+		field, ok := columns[strings.ToLower(k)]
+		if !ok {
+			return spec, fmt.Errorf("'%s' is not a valid columns key", k)
+		}
+		*field = conv.ToString(v)
+	}
+
+	if _, ok := cfg["csv"]; ok {
+		for k, v := range cfg["csv"].(map[string]any) {
+			switch strings.ToLower(k) {
+			case "timeformat":
+				spec.TimeFormat = conv.ToString(v)
+			case "timezone":
+				loc, err := time.LoadLocation(conv.ToString(v))
+				if err != nil {
+					return spec, err
+				}
+				spec.Location = loc
+			case "delimiter":
+				delimiter := []rune(conv.ToString(v))
+				if len(delimiter) != 1 {
+					return spec, fmt.Errorf("'%s' is not a valid delimiter", conv.ToString(v))
+				}
+				spec.Delimiter = delimiter[0]
+			case "skiprows":
+				spec.SkipRows = conv.ToInt(v)
+			case "header":
+				header, ok := v.(bool)
+				if !ok {
+					return spec, fmt.Errorf("'%v' is not a valid header flag", v)
+				}
+				spec.Header = header
+			default:
+				return spec, fmt.Errorf("'%s' is not a valid csv key", k)
+			}
+		}
+	}
+
+	return spec, spec.Validate()
+}
+
+// readParquetColumnsFromConfig reads the optional column mapping of a Parquet sample, overriding the registered defaults.
+// Keys of the 'columns' table are start, open, high, low, close, volume, quotevolume, tradecount,
+// takerbuyvolume, takerbuyquotevolume and timeunit (a duration such as "1ms").
+func readParquetColumnsFromConfig(cfg map[string]any, columns market.ParquetColumns) (market.ParquetColumns, error) {
+	if _, ok := cfg["columns"]; !ok {
+		return columns, nil
+	}
+	root := cfg["columns"].(map[string]any)
+
+	fields := map[string]*string{
+		"start":  &columns.Start,
+		"open":   &columns.Open,
+		"high":   &columns.High,
+		"low":    &columns.Low,
+		"close":  &columns.Close,
+		"volume": &columns.Volume,
+
+		"quotevolume":         &columns.QuoteVolume,
+		"tradecount":          &columns.TradeCount,
+		"takerbuyvolume":      &columns.TakerBuyVolume,
+		"takerbuyquotevolume": &columns.TakerBuyQuoteVolume,
+	}
+	for k, v := range root {
+		if strings.ToLower(k) == "timeunit" {
+			unit, err := time.ParseDuration(conv.ToString(v))
+			if err != nil {
+				return columns, err
+			}
+			columns.TimeUnit = unit
+			continue
+		}
+		field, ok := fields[strings.ToLower(k)]
+		if !ok {
+			return columns, fmt.Errorf("'%s' is not a valid columns key", k)
+		}
+		*field = conv.ToString(v)
+	}
+	return columns, nil
+}
+
+// readTimeframeFromConfig reads the resample timeframe of a sample, with optional timezone and session offset.
+func readTimeframeFromConfig(cfg map[string]any) (market.Timeframe, error) {
+	tf, err := market.ParseTimeframe(conv.ToString(cfg["resample"]))
+	if err != nil {
+		return tf, err
+	}
+	if _, ok := cfg["timezone"]; ok {
+		loc, err := time.LoadLocation(conv.ToString(cfg["timezone"]))
+		if err != nil {
+			return tf, err
+		}
+		tf.Location = loc
+	}
+	if _, ok := cfg["offset"]; ok {
+		offset, err := time.ParseDuration(conv.ToString(cfg["offset"]))
+		if err != nil {
+			return tf, err
+		}
+		tf.Offset = offset
+	}
+	return tf, nil
 }
@@ -0,0 +1,327 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ Receiver = (*BarReceiver)(nil)
+
+var _ BarBuilder = (*VolumeBarBuilder)(nil)
+var _ BarBuilder = (*DollarBarBuilder)(nil)
+var _ BarBuilder = (*TickBarBuilder)(nil)
+var _ BarBuilder = (*RangeBarBuilder)(nil)
+var _ BarBuilder = (*RenkoBuilder)(nil)
+var _ BarBuilder = (*HeikinAshiBuilder)(nil)
+
+// BarBuilder builds alternative bars from a stream of fine-grained klines or trades.
+// Klines are not split between bars, so bars close on the first kline that reaches the threshold.
+// The Start of a bar is the Start of its first kline.
+type BarBuilder interface {
+	// Add adds a kline and returns the bars it completes, if any.
+	Add(k Kline) []Kline
+
+	// Flush returns the incomplete bar, if any, and resets the builder for a new bar.
+	Flush() (Kline, bool)
+}
+
+// TradeKline represents a single trade as a Kline for input to a BarBuilder.
+// All prices equal the trade price, the volume equals the trade size and the trade count is 1.
+func TradeKline(t time.Time, price decimal.Decimal, size float64) Kline {
+	return Kline{
+		Start:       t,
+		O:           price,
+		H:           price,
+		L:           price,
+		C:           price,
+		Volume:      size,
+		QuoteVolume: price.InexactFloat64() * size,
+		TradeCount:  1,
+	}
+}
+
+// BuildBars builds bars from klines in ascending chronological order.
+// The final bar may be incomplete if the klines end part way through a bar.
+func BuildBars(klines []Kline, b BarBuilder) []Kline {
+	var bars []Kline
+	for _, k := range klines {
+		bars = append(bars, b.Add(k)...)
+	}
+	if bar, ok := b.Flush(); ok {
+		bars = append(bars, bar)
+	}
+	return bars
+}
+
+// BarReceiver is a Receiver that builds bars from a stream of klines and forwards each completed bar to the next Receiver.
+// Call Flush to forward the incomplete bar, for example at the end of a backtest.
+type BarReceiver struct {
+	Builder BarBuilder
+
+	next Receiver
+}
+
+// NewBarReceiver creates a new BarReceiver that forwards the bars built by b to next.
+func NewBarReceiver(b BarBuilder, next Receiver) *BarReceiver {
+	return &BarReceiver{
+		Builder: b,
+		next:    next,
+	}
+}
+
+// ReceivePrice adds the kline to the builder and forwards any completed bars.
+func (r *BarReceiver) ReceivePrice(ctx context.Context, k Kline) error {
+	for _, bar := range r.Builder.Add(k) {
+		if err := r.next.ReceivePrice(ctx, bar); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush forwards the incomplete bar, if any, to the next Receiver.
+func (r *BarReceiver) Flush(ctx context.Context) error {
+	bar, ok := r.Builder.Flush()
+	if !ok {
+		return nil
+	}
+	return r.next.ReceivePrice(ctx, bar)
+}
+
+// VolumeBarBuilder closes a bar when its volume reaches the threshold.
+type VolumeBarBuilder struct {
+	Threshold float64
+
+	acc barAccumulator
+}
+
+// NewVolumeBarBuilder creates a new VolumeBarBuilder.
+func NewVolumeBarBuilder(threshold float64) *VolumeBarBuilder {
+	return &VolumeBarBuilder{Threshold: threshold}
+}
+
+// Add adds a kline to the current bar and returns the bar if it is complete.
+func (b *VolumeBarBuilder) Add(k Kline) []Kline {
+	b.acc.add(k)
+	if b.acc.bar.Volume >= b.Threshold {
+		return b.acc.take()
+	}
+	return nil
+}
+
+// Flush returns the incomplete bar.
+func (b *VolumeBarBuilder) Flush() (Kline, bool) {
+	return b.acc.flush()
+}
+
+// DollarBarBuilder closes a bar when the value traded reaches the threshold.
+// The value of each kline is its QuoteVolume, or if zero is estimated as the close price multiplied by the volume.
+type DollarBarBuilder struct {
+	Threshold float64
+
+	acc barAccumulator
+}
+
+// NewDollarBarBuilder creates a new DollarBarBuilder.
+func NewDollarBarBuilder(threshold float64) *DollarBarBuilder {
+	return &DollarBarBuilder{Threshold: threshold}
+}
+
+// Add adds a kline to the current bar and returns the bar if it is complete.
+func (b *DollarBarBuilder) Add(k Kline) []Kline {
+	b.acc.add(k)
+	if b.acc.value >= b.Threshold {
+		return b.acc.take()
+	}
+	return nil
+}
+
+// Flush returns the incomplete bar.
+func (b *DollarBarBuilder) Flush() (Kline, bool) {
+	return b.acc.flush()
+}
+
+// TickBarBuilder closes a bar after a fixed count of klines or trades.
+type TickBarBuilder struct {
+	Count int
+
+	acc barAccumulator
+}
+
+// NewTickBarBuilder creates a new TickBarBuilder.
+func NewTickBarBuilder(count int) *TickBarBuilder {
+	return &TickBarBuilder{Count: count}
+}
+
+// Add adds a kline to the current bar and returns the bar if it is complete.
+func (b *TickBarBuilder) Add(k Kline) []Kline {
+	b.acc.add(k)
+	if b.acc.count >= b.Count {
+		return b.acc.take()
+	}
+	return nil
+}
+
+// Flush returns the incomplete bar.
+func (b *TickBarBuilder) Flush() (Kline, bool) {
+	return b.acc.flush()
+}
+
+// RangeBarBuilder closes a bar when its high-low range reaches the given range.
+type RangeBarBuilder struct {
+	Range decimal.Decimal
+
+	acc barAccumulator
+}
+
+// NewRangeBarBuilder creates a new RangeBarBuilder.
+func NewRangeBarBuilder(r decimal.Decimal) *RangeBarBuilder {
+	return &RangeBarBuilder{Range: r}
+}
+
+// Add adds a kline to the current bar and returns the bar if it is complete.
+func (b *RangeBarBuilder) Add(k Kline) []Kline {
+	b.acc.add(k)
+	if b.acc.bar.H.Sub(b.acc.bar.L).GreaterThanOrEqual(b.Range) {
+		return b.acc.take()
+	}
+	return nil
+}
+
+// Flush returns the incomplete bar.
+func (b *RangeBarBuilder) Flush() (Kline, bool) {
+	return b.acc.flush()
+}
+
+// RenkoBuilder builds Renko bricks of a fixed box size from the close price.
+// A brick in the same direction as the last brick requires the close to move one box beyond it,
+// a reversal requires the close to move one box beyond the opposite side of the last brick.
+// A kline that moves several boxes completes several bricks, all with the Start of that kline.
+// The volume (and order flow) since the last brick is assigned to the first new brick. There is no incomplete brick to flush.
+type RenkoBuilder struct {
+	BoxSize decimal.Decimal
+
+	top     decimal.Decimal
+	bottom  decimal.Decimal
+	volume  Kline
+	started bool
+}
+
+// NewRenkoBuilder creates a new RenkoBuilder. The first close received is the base of the first brick.
+func NewRenkoBuilder(boxSize decimal.Decimal) *RenkoBuilder {
+	return &RenkoBuilder{BoxSize: boxSize}
+}
+
+// Add returns the bricks completed by the close of the kline.
+func (b *RenkoBuilder) Add(k Kline) []Kline {
+	b.volume = addVolume(b.volume, k)
+	if !b.started {
+		b.top, b.bottom = k.C, k.C
+		b.started = true
+		return nil
+	}
+	if !b.BoxSize.IsPositive() {
+		return nil
+	}
+
+	var bricks []Kline
+	brick := func(o, c decimal.Decimal) {
+		brick := b.volume
+		brick.Start, brick.O, brick.H, brick.L, brick.C = k.Start, o, decimal.Max(o, c), decimal.Min(o, c), c
+		bricks = append(bricks, brick)
+		b.volume = Kline{}
+	}
+	for k.C.GreaterThanOrEqual(b.top.Add(b.BoxSize)) {
+		brick(b.top, b.top.Add(b.BoxSize))
+		b.bottom, b.top = b.top, b.top.Add(b.BoxSize)
+	}
+	for k.C.LessThanOrEqual(b.bottom.Sub(b.BoxSize)) {
+		brick(b.bottom, b.bottom.Sub(b.BoxSize))
+		b.top, b.bottom = b.bottom, b.bottom.Sub(b.BoxSize)
+	}
+	return bricks
+}
+
+// Flush returns false as a Renko brick is only formed when complete.
+func (b *RenkoBuilder) Flush() (Kline, bool) {
+	return Kline{}, false
+}
+
+// HeikinAshiBuilder transforms each kline into a Heikin-Ashi candle:
+// close is the average of OHLC, open is the midpoint of the previous candle's open and close,
+// and the high and low include the new open and close.
+type HeikinAshiBuilder struct {
+	prev    Kline
+	started bool
+}
+
+// NewHeikinAshiBuilder creates a new HeikinAshiBuilder.
+func NewHeikinAshiBuilder() *HeikinAshiBuilder {
+	return &HeikinAshiBuilder{}
+}
+
+// Add returns the Heikin-Ashi candle for the kline.
+func (b *HeikinAshiBuilder) Add(k Kline) []Kline {
+	two, four := decimal.NewFromInt(2), decimal.NewFromInt(4)
+
+	ha := k
+	ha.C = k.O.Add(k.H).Add(k.L).Add(k.C).Div(four)
+	if b.started {
+		ha.O = b.prev.O.Add(b.prev.C).Div(two)
+	} else {
+		ha.O = k.O.Add(k.C).Div(two)
+	}
+	ha.H = decimal.Max(k.H, ha.O, ha.C)
+	ha.L = decimal.Min(k.L, ha.O, ha.C)
+
+	b.prev = ha
+	b.started = true
+	return []Kline{ha}
+}
+
+// Flush returns false as each candle is complete when added.
+func (b *HeikinAshiBuilder) Flush() (Kline, bool) {
+	return Kline{}, false
+}
+
+// barAccumulator merges klines into a pending bar and tracks the statistics used by threshold bar builders.
+type barAccumulator struct {
+	bar     Kline
+	count   int
+	value   float64
+	pending bool
+}
+
+func (a *barAccumulator) add(k Kline) {
+	if a.pending {
+		a.bar = mergeKline(a.bar, k)
+	} else {
+		a.bar = k
+		a.pending = true
+	}
+	a.count++
+	if k.QuoteVolume != 0 {
+		a.value += k.QuoteVolume
+	} else {
+		a.value += k.C.InexactFloat64() * k.Volume
+	}
+}
+
+func (a *barAccumulator) take() []Kline {
+	bar, _ := a.flush()
+	return []Kline{bar}
+}
+
+func (a *barAccumulator) flush() (Kline, bool) {
+	if !a.pending {
+		return Kline{}, false
+	}
+	bar := a.bar
+	*a = barAccumulator{}
+	return bar, true
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestBuildBars(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	kline := func(i int, o, h, l, c, v float64) Kline {
+		return Kline{Start: start.Add(time.Duration(i) * time.Minute), O: dec.New(o), H: dec.New(h), L: dec.New(l), C: dec.New(c), Volume: v}
+	}
+	give := []Kline{
+		kline(0, 10, 11, 9, 10, 2),
+		kline(1, 10, 12, 10, 12, 3),
+		kline(2, 12, 13, 11, 11, 1),
+		kline(3, 11, 11, 8, 8, 4),
+		kline(4, 8, 9, 7, 9, 1),
+	}
+
+	tests := []struct {
+		name string
+		give BarBuilder
+		want []Kline
+	}{
+		{
+			name: "volume",
+			give: NewVolumeBarBuilder(5),
+			want: []Kline{kline(0, 10, 12, 9, 12, 5), kline(2, 12, 13, 8, 8, 5), kline(4, 8, 9, 7, 9, 1)},
+		},
+		{
+			name: "dollar",
+			give: NewDollarBarBuilder(50),
+			want: []Kline{kline(0, 10, 12, 9, 12, 5), kline(2, 12, 13, 7, 9, 6)},
+		},
+		{
+			name: "tick",
+			give: NewTickBarBuilder(2),
+			want: []Kline{kline(0, 10, 12, 9, 12, 5), kline(2, 12, 13, 8, 8, 5), kline(4, 8, 9, 7, 9, 1)},
+		},
+		{
+			name: "range",
+			give: NewRangeBarBuilder(dec.New(3)),
+			want: []Kline{kline(0, 10, 12, 9, 12, 5), kline(2, 12, 13, 8, 8, 5), kline(4, 8, 9, 7, 9, 1)},
+		},
+		{
+			name: "renko",
+			give: NewRenkoBuilder(dec.New(1)),
+			want: []Kline{
+				kline(1, 10, 11, 10, 11, 5),
+				kline(1, 11, 12, 11, 12, 0),
+				// Reversal requires a close one box below the bottom of the last brick
+				kline(3, 11, 11, 10, 10, 5),
+				kline(3, 10, 10, 9, 9, 0),
+				kline(3, 9, 9, 8, 8, 0),
+			},
+		},
+		{
+			name: "heikin-ashi",
+			give: NewHeikinAshiBuilder(),
+			want: []Kline{
+				kline(0, 10, 11, 9, 10, 2),
+				kline(1, 10, 12, 10, 11, 3),
+				kline(2, 10.5, 13, 10.5, 11.75, 1),
+				kline(3, 11.125, 11.125, 8, 9.5, 4),
+				kline(4, 10.3125, 10.3125, 7, 8.25, 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := BuildBars(give, tt.give)
+			assertKlinesEqual(t, tt.want, act)
+		})
+	}
+}
+
+func TestDollarBarBuilder_QuoteVolume(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	kline := func(i int, quoteVolume float64) Kline {
+		return Kline{Start: start.Add(time.Duration(i) * time.Minute), O: dec.New(10), H: dec.New(10), L: dec.New(10), C: dec.New(10), Volume: 2, QuoteVolume: quoteVolume}
+	}
+	builder := NewDollarBarBuilder(50)
+
+	// Traded value is the quote volume rather than the close multiplied by the volume (20 per kline)
+	assert.Empty(t, builder.Add(kline(0, 30)))
+	act := builder.Add(kline(1, 25))
+	require.Len(t, act, 1)
+	assert.Equal(t, start, act[0].Start)
+	assert.Equal(t, 55.0, act[0].QuoteVolume)
+
+	// Klines without quote volume fall back to the estimate
+	assert.Empty(t, builder.Add(kline(2, 0)))
+	assert.Empty(t, builder.Add(kline(3, 0)))
+	assert.Len(t, builder.Add(kline(4, 0)), 1)
+}
+
+func TestBarReceiver(t *testing.T) {
+	prices, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+
+	want := BuildBars(prices, NewVolumeBarBuilder(50000))
+
+	var receiver receiverForResampleTest
+	bars := NewBarReceiver(NewVolumeBarBuilder(50000), &receiver)
+	ctx := context.Background()
+	for _, k := range prices {
+		require.NoError(t, bars.ReceivePrice(ctx, k))
+	}
+	require.NoError(t, bars.Flush(ctx))
+
+	assert.Greater(t, len(want), 1)
+	assertKlinesEqual(t, want, receiver.klines)
+}
+
+func TestTradeKline(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	trades := []Kline{
+		TradeKline(start, dec.New(100), 1),
+		TradeKline(start.Add(time.Second), dec.New(102), 2),
+		TradeKline(start.Add(2*time.Second), dec.New(99), 1),
+	}
+
+	act := BuildBars(trades, NewTickBarBuilder(3))
+	assertKlinesEqual(t, []Kline{
+		{Start: start, O: dec.New(100), H: dec.New(102), L: dec.New(99), C: dec.New(99), Volume: 4, QuoteVolume: 403, TradeCount: 3},
+	}, act)
+}
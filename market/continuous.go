@@ -0,0 +1,206 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidContracts is returned when contracts cannot be stitched into a continuous series.
+var ErrInvalidContracts = errors.New("invalid futures contracts")
+
+// Contract is a dated futures contract and its kline series.
+type Contract struct {
+	Asset  Asset
+	Expiry time.Time
+	Klines []Kline
+
+	// OpenInterest is the open interest at the close of each kline, for RollOpenInterestCrossover.
+	// Optional: leave nil if not available.
+	OpenInterest []float64
+}
+
+// RollMethod determines when a continuous series rolls from the front contract to the next.
+type RollMethod int
+
+const (
+	// RollBeforeExpiry rolls a fixed number of days before the front contract expires.
+	RollBeforeExpiry RollMethod = iota
+
+	// RollVolumeCrossover rolls when the volume of the next contract exceeds the front contract.
+	RollVolumeCrossover
+
+	// RollOpenInterestCrossover rolls when the open interest of the next contract exceeds the front contract.
+	RollOpenInterestCrossover
+)
+
+// RollSchedule defines when a continuous series rolls to the next contract.
+// A crossover that has not happened by the expiry of the front contract rolls at expiry.
+type RollSchedule struct {
+	Method RollMethod
+
+	// Days before expiry to roll, for RollBeforeExpiry.
+	Days int
+}
+
+// Adjustment is the method of back-adjusting prices before a roll to remove the gap between contracts.
+type Adjustment int
+
+const (
+	// AdjustNone joins the unadjusted prices of each contract.
+	AdjustNone Adjustment = iota
+
+	// AdjustDifference adds the price difference between the contracts at each later roll.
+	AdjustDifference
+
+	// AdjustRatio multiplies by the price ratio between the contracts at each later roll.
+	// Returns are preserved and prices remain positive.
+	AdjustRatio
+)
+
+// Roll is a roll event of a continuous series from one contract to the next.
+type Roll struct {
+	// Start is the start of the first kline of the new contract.
+	Start time.Time
+
+	From Asset
+	To   Asset
+
+	// FromPrice and ToPrice are the unadjusted close prices of each contract at the last kline before the roll.
+	FromPrice decimal.Decimal
+	ToPrice   decimal.Decimal
+}
+
+// Difference returns the price of the new contract less the old at the roll.
+func (r Roll) Difference() decimal.Decimal {
+	return r.ToPrice.Sub(r.FromPrice)
+}
+
+// Ratio returns the price of the new contract divided by the old at the roll.
+func (r Roll) Ratio() decimal.Decimal {
+	return r.ToPrice.Div(r.FromPrice)
+}
+
+// StitchContracts stitches a continuous series from the klines of several contracts, in expiry order,
+// rolling from each contract to the next according to the schedule.
+// A roll is decided at the close of a kline that both contracts share, and the new contract is used from its next kline.
+// Prices before each roll are back-adjusted so that the series ends with the unadjusted prices of the last contract.
+// Returns the continuous series and a roll event for each roll.
+func StitchContracts(contracts []Contract, schedule RollSchedule, adjustment Adjustment) ([]Kline, []Roll, error) {
+	if len(contracts) == 0 {
+		return nil, nil, fmt.Errorf("%w: no contracts", ErrInvalidContracts)
+	}
+	contracts = append([]Contract(nil), contracts...)
+	sort.SliceStable(contracts, func(i, j int) bool {
+		return contracts[i].Expiry.Before(contracts[j].Expiry)
+	})
+	for i, c := range contracts {
+		if len(c.Klines) == 0 {
+			return nil, nil, fmt.Errorf("%w: %s has no klines", ErrInvalidContracts, c.Asset.Symbol)
+		}
+		if schedule.Method == RollOpenInterestCrossover && len(c.OpenInterest) != len(c.Klines) {
+			return nil, nil, fmt.Errorf("%w: %s open interest does not match klines", ErrInvalidContracts, c.Asset.Symbol)
+		}
+		if i > 0 && !c.Expiry.After(contracts[i-1].Expiry) {
+			return nil, nil, fmt.Errorf("%w: %s has the same expiry as %s", ErrInvalidContracts, c.Asset.Symbol, contracts[i-1].Asset.Symbol)
+		}
+	}
+
+	// Segments of each contract in the continuous series, from the first kline after the previous roll
+	segments := make([][]Kline, len(contracts))
+	rolls := make([]Roll, 0, len(contracts)-1)
+	first := 0
+	for i := 0; i < len(contracts)-1; i++ {
+		front, next := contracts[i], contracts[i+1]
+		last, nextLast, ok := findRoll(front, next, first, schedule)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s does not overlap %s", ErrInvalidContracts, front.Asset.Symbol, next.Asset.Symbol)
+		}
+		if nextLast+1 >= len(next.Klines) {
+			return nil, nil, fmt.Errorf("%w: %s has no klines after the roll", ErrInvalidContracts, next.Asset.Symbol)
+		}
+		if adjustment == AdjustRatio && !front.Klines[last].C.IsPositive() {
+			return nil, nil, fmt.Errorf("%w: %s price at the roll must be positive to adjust by ratio", ErrInvalidContracts, front.Asset.Symbol)
+		}
+		segments[i] = front.Klines[first : last+1]
+		rolls = append(rolls, Roll{
+			Start:     next.Klines[nextLast+1].Start,
+			From:      front.Asset,
+			To:        next.Asset,
+			FromPrice: front.Klines[last].C,
+			ToPrice:   next.Klines[nextLast].C,
+		})
+		first = nextLast + 1
+	}
+	segments[len(segments)-1] = contracts[len(contracts)-1].Klines[first:]
+
+	// Back-adjust each segment by the rolls that follow it, working back from the last contract
+	var klines []Kline
+	offset, factor := decimal.Zero, decimal.NewFromInt(1)
+	for i := len(segments) - 1; i >= 0; i-- {
+		if i < len(rolls) {
+			switch adjustment {
+			case AdjustDifference:
+				offset = offset.Add(rolls[i].Difference())
+			case AdjustRatio:
+				factor = factor.Mul(rolls[i].Ratio())
+			}
+		}
+		adjusted := make([]Kline, len(segments[i]))
+		for j, k := range segments[i] {
+			switch adjustment {
+			case AdjustDifference:
+				k.O, k.H, k.L, k.C = k.O.Add(offset), k.H.Add(offset), k.L.Add(offset), k.C.Add(offset)
+			case AdjustRatio:
+				k.O, k.H, k.L, k.C = k.O.Mul(factor), k.H.Mul(factor), k.L.Mul(factor), k.C.Mul(factor)
+			}
+			adjusted[j] = k
+		}
+		klines = append(adjusted, klines...)
+	}
+
+	return klines, rolls, nil
+}
+
+// findRoll returns the index of the last kline of the front contract before the roll,
+// and the index of the kline of the next contract at the same time.
+// The front contract is searched from index first. Returns false if the contracts have no kline in common.
+func findRoll(front, next Contract, first int, schedule RollSchedule) (int, int, bool) {
+	index := make(map[int64]int, len(next.Klines))
+	for i, k := range next.Klines {
+		index[k.Start.UnixNano()] = i
+	}
+
+	last, nextLast, found := 0, 0, false
+	for i := first; i < len(front.Klines); i++ {
+		k := front.Klines[i]
+		j, ok := index[k.Start.UnixNano()]
+		if !ok {
+			continue
+		}
+		last, nextLast, found = i, j, true
+
+		if !k.Start.Before(front.Expiry) {
+			break
+		}
+		var roll bool
+		switch schedule.Method {
+		case RollBeforeExpiry:
+			roll = !k.Start.Before(front.Expiry.AddDate(0, 0, -schedule.Days))
+		case RollVolumeCrossover:
+			roll = next.Klines[j].Volume > k.Volume
+		case RollOpenInterestCrossover:
+			roll = next.OpenInterest[j] > front.OpenInterest[i]
+		}
+		if roll {
+			break
+		}
+	}
+	return last, nextLast, found
+}
@@ -0,0 +1,130 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// contractForContinuousTest returns a contract with daily klines from the 1st of January 2022
+// with a close of price + day index and volume from the volume function.
+func contractForContinuousTest(symbol string, days int, price float64, volume func(int) float64) Contract {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	c := Contract{
+		Asset:  NewAsset(symbol),
+		Expiry: start.AddDate(0, 0, days),
+	}
+	for i := 0; i < days; i++ {
+		p := dec.New(price + float64(i))
+		c.Klines = append(c.Klines, Kline{Start: start.AddDate(0, 0, i), O: p, H: p, L: p, C: p, Volume: volume(i)})
+		c.OpenInterest = append(c.OpenInterest, volume(i))
+	}
+	return c
+}
+
+func TestStitchContracts(t *testing.T) {
+	jan := func(day int) time.Time { return time.Date(2022, time.January, day, 0, 0, 0, 0, time.UTC) }
+	front := contractForContinuousTest("F", 10, 100, func(i int) float64 { return float64(100 - 10*i) })
+	next := contractForContinuousTest("G", 20, 110, func(i int) float64 { return float64(10*i + 5) })
+
+	tests := []struct {
+		name           string
+		giveSchedule   RollSchedule
+		giveAdjustment Adjustment
+		wantRoll       Roll
+		wantFirstClose float64
+	}{
+		{
+			name:           "volume crossover by difference",
+			giveSchedule:   RollSchedule{Method: RollVolumeCrossover},
+			giveAdjustment: AdjustDifference,
+			wantRoll:       Roll{Start: jan(7), From: front.Asset, To: next.Asset, FromPrice: dec.New(105), ToPrice: dec.New(115)},
+			wantFirstClose: 110,
+		},
+		{
+			name:           "open interest crossover by ratio",
+			giveSchedule:   RollSchedule{Method: RollOpenInterestCrossover},
+			giveAdjustment: AdjustRatio,
+			wantRoll:       Roll{Start: jan(7), From: front.Asset, To: next.Asset, FromPrice: dec.New(105), ToPrice: dec.New(115)},
+			wantFirstClose: 100 * 115.0 / 105,
+		},
+		{
+			name:           "days before expiry unadjusted",
+			giveSchedule:   RollSchedule{Method: RollBeforeExpiry, Days: 3},
+			giveAdjustment: AdjustNone,
+			wantRoll:       Roll{Start: jan(9), From: front.Asset, To: next.Asset, FromPrice: dec.New(107), ToPrice: dec.New(117)},
+			wantFirstClose: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, rolls, err := StitchContracts([]Contract{next, front}, tt.giveSchedule, tt.giveAdjustment)
+			require.NoError(t, err)
+
+			require.Len(t, rolls, 1)
+			assert.True(t, tt.wantRoll.Start.Equal(rolls[0].Start))
+			assert.Equal(t, tt.wantRoll.From, rolls[0].From)
+			assert.Equal(t, tt.wantRoll.To, rolls[0].To)
+			assert.True(t, tt.wantRoll.FromPrice.Equal(rolls[0].FromPrice))
+			assert.True(t, tt.wantRoll.ToPrice.Equal(rolls[0].ToPrice))
+
+			// One kline a day without gaps, ending with the unadjusted next contract
+			require.Len(t, act, 20)
+			for i := range act {
+				assert.True(t, jan(i+1).Equal(act[i].Start))
+			}
+			assert.InDelta(t, tt.wantFirstClose, act[0].C.InexactFloat64(), 1e-9)
+			assert.True(t, next.Klines[19].C.Equal(act[19].C))
+		})
+	}
+}
+
+func TestStitchContracts_Cumulative(t *testing.T) {
+	flat := func(int) float64 { return 1 }
+	contracts := []Contract{
+		contractForContinuousTest("F", 10, 100, flat),
+		contractForContinuousTest("G", 20, 110, flat),
+		contractForContinuousTest("H", 30, 125, flat),
+	}
+
+	act, rolls, err := StitchContracts(contracts, RollSchedule{Method: RollBeforeExpiry, Days: 1}, AdjustDifference)
+	require.NoError(t, err)
+
+	require.Len(t, rolls, 2)
+	require.Len(t, act, 30)
+	// Each roll gap is removed: 10 to the second contract and 15 to the third
+	assert.Equal(t, 125.0, act[0].C.InexactFloat64())
+	for i := 1; i < len(act); i++ {
+		assert.Equal(t, 1.0, act[i].C.Sub(act[i-1].C).InexactFloat64(), "change %d", i)
+	}
+}
+
+func TestStitchContracts_Invalid(t *testing.T) {
+	flat := func(int) float64 { return 1 }
+	front := contractForContinuousTest("F", 10, 100, flat)
+	late := contractForContinuousTest("G", 20, 110, flat)
+	late.Klines = late.Klines[12:]
+	same := contractForContinuousTest("H", 10, 110, flat)
+
+	tests := []struct {
+		name string
+		give []Contract
+	}{
+		{name: "no contracts", give: nil},
+		{name: "no overlap", give: []Contract{front, late}},
+		{name: "same expiry", give: []Contract{front, same}},
+		{name: "no klines after roll", give: []Contract{front, {Asset: NewAsset("E"), Expiry: late.Expiry, Klines: front.Klines}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := StitchContracts(tt.give, RollSchedule{}, AdjustNone)
+			assert.ErrorIs(t, err, ErrInvalidContracts)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrInvalidCorporateAction is returned when a corporate action cannot be read or applied.
+var ErrInvalidCorporateAction = errors.New("invalid corporate action")
+
+// CorporateActionKind is the kind of a corporate action of an equity.
+type CorporateActionKind int
+
+const (
+	// Split changes the number of shares, e.g. a 4-for-1 split.
+	Split CorporateActionKind = iota + 1
+
+	// Dividend is a cash dividend paid per share.
+	Dividend
+)
+
+func (k CorporateActionKind) String() string {
+	return [...]string{"", "split", "dividend"}[k]
+}
+
+// CorporateAction is a split or cash dividend of an equity, effective from the start of its ex-date.
+type CorporateAction struct {
+	Kind   CorporateActionKind
+	ExDate time.Time
+
+	// Ratio is the number of new shares per old share for a split,
+	// e.g. 4 for a 4-for-1 split or 0.1 for a 1-for-10 reverse split.
+	Ratio float64
+
+	// Amount is the cash dividend per share.
+	Amount decimal.Decimal
+}
+
+// ReadCorporateActionsFromCSV reads the corporate actions of an equity from a CSV file with a header row,
+// sorted by ex-date. Dates are formatted as 2006-01-02. Two layouts are supported:
+//
+// A generic layout with date, action and value columns, where action is split or dividend.
+//
+// A Yahoo Finance layout with Date and either a Dividends or a Stock Splits column.
+//
+// Split ratios are a number of new shares per old share, e.g. 4, or a ratio of new to old such as 4:1 or 1/10.
+func ReadCorporateActionsFromCSV(path string) ([]CorporateAction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//nolint:errcheck // Read ops only so safe to ignore err return
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing header: %s", ErrInvalidCorporateAction, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	dateCol, ok := columns["date"]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing date column", ErrInvalidCorporateAction)
+	}
+
+	// Kind of each record, from the action column of the generic layout or fixed by a Yahoo layout
+	var kindCol, valueCol int
+	var fixed CorporateActionKind
+	if i, ok := columns["action"]; ok {
+		kindCol = i
+		if valueCol, ok = columns["value"]; !ok {
+			return nil, fmt.Errorf("%w: missing value column", ErrInvalidCorporateAction)
+		}
+	} else if i, ok := columns["dividends"]; ok {
+		fixed, valueCol = Dividend, i
+	} else if i, ok := columns["stock splits"]; ok {
+		fixed, valueCol = Split, i
+	} else {
+		return nil, fmt.Errorf("%w: missing action column", ErrInvalidCorporateAction)
+	}
+
+	var actions []CorporateAction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) <= dateCol || len(record) <= valueCol || len(record) <= kindCol {
+			return nil, fmt.Errorf("%w: missing fields in %v", ErrInvalidCorporateAction, record)
+		}
+
+		kind := fixed
+		if kind == 0 {
+			switch strings.ToLower(strings.TrimSpace(record[kindCol])) {
+			case "split":
+				kind = Split
+			case "dividend":
+				kind = Dividend
+			default:
+				return nil, fmt.Errorf("%w: unknown action '%s'", ErrInvalidCorporateAction, record[kindCol])
+			}
+		}
+
+		action, err := decodeCorporateAction(kind, record[dateCol], record[valueCol])
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].ExDate.Before(actions[j].ExDate) })
+	return actions, nil
+}
+
+func decodeCorporateAction(kind CorporateActionKind, date, value string) (CorporateAction, error) {
+	action := CorporateAction{Kind: kind}
+	var err error
+	if action.ExDate, err = time.Parse(YahooTimeFormat, strings.TrimSpace(date)); err != nil {
+		return action, fmt.Errorf("%w: %s", ErrInvalidCorporateAction, err)
+	}
+	value = strings.TrimSpace(value)
+
+	switch kind {
+	case Split:
+		if action.Ratio, err = parseSplitRatio(value); err != nil {
+			return action, err
+		}
+	case Dividend:
+		if action.Amount, err = decimal.NewFromString(value); err != nil || action.Amount.IsNegative() {
+			return action, fmt.Errorf("%w: invalid dividend '%s'", ErrInvalidCorporateAction, value)
+		}
+	}
+	return action, nil
+}
+
+// parseSplitRatio parses a split ratio such as 4, 4:1 or 1/10 into new shares per old share.
+func parseSplitRatio(s string) (float64, error) {
+	invalid := fmt.Errorf("%w: invalid split ratio '%s'", ErrInvalidCorporateAction, s)
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '/' })
+	if len(parts) == 0 || len(parts) > 2 {
+		return 0, invalid
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, invalid
+	}
+	if len(parts) == 2 {
+		old, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || old <= 0 {
+			return 0, invalid
+		}
+		ratio /= old
+	}
+	if ratio <= 0 {
+		return 0, invalid
+	}
+	return ratio, nil
+}
+
+// CorporateActionAdjustment is the method of back-adjusting equity prices for corporate actions.
+type CorporateActionAdjustment int
+
+const (
+	// AdjustSplits adjusts prices and volumes for splits, so that dividends are paid as cash.
+	AdjustSplits CorporateActionAdjustment = iota
+
+	// AdjustSplitsAndDividends also adjusts prices for dividends, for a total return series.
+	AdjustSplitsAndDividends
+)
+
+// AdjustForCorporateActions back-adjusts the klines of an equity, in chronological order, for its corporate actions
+// so that the series ends with unadjusted prices.
+// Prices before a split are divided by the split ratio and volumes multiplied by it.
+// Prices before a dividend are multiplied by one less the dividend over the close before the ex-date.
+//
+// Returns the adjusted klines and the dividends paid during the series, restated per share of the adjusted series.
+// Pass the dividends to the backtest dealer with SetDividends to credit them to open positions.
+// When adjusting for dividends no dividends are returned as the adjusted prices already include them.
+func AdjustForCorporateActions(klines []Kline, actions []CorporateAction, adjustment CorporateActionAdjustment) ([]Kline, []CorporateAction, error) {
+	actions = append([]CorporateAction(nil), actions...)
+	sort.SliceStable(actions, func(i, j int) bool { return actions[i].ExDate.Before(actions[j].ExDate) })
+
+	// Work back from the last kline, applying each action to the klines that start before its ex-date
+	adjusted := make([]Kline, len(klines))
+	var dividends []CorporateAction
+	factor, split := decimal.NewFromInt(1), 1.0
+	next := len(actions) - 1
+	for i := len(klines) - 1; i >= 0; i-- {
+		k := klines[i]
+		for ; next >= 0 && actions[next].ExDate.After(k.Start); next-- {
+			action := actions[next]
+			switch action.Kind {
+			case Split:
+				if action.Ratio <= 0 {
+					return nil, nil, fmt.Errorf("%w: split ratio must be positive at %s", ErrInvalidCorporateAction, action.ExDate)
+				}
+				factor = factor.Div(decimal.NewFromFloat(action.Ratio))
+				split *= action.Ratio
+			case Dividend:
+				if adjustment == AdjustSplitsAndDividends {
+					if !action.Amount.LessThan(k.C) {
+						return nil, nil, fmt.Errorf("%w: dividend at %s is not less than the close", ErrInvalidCorporateAction, action.ExDate)
+					}
+					factor = factor.Mul(decimal.NewFromInt(1).Sub(action.Amount.Div(k.C)))
+					continue
+				}
+				// Dividends after the start of the last kline are not paid during the series
+				if i < len(klines)-1 {
+					action.Amount = action.Amount.Div(decimal.NewFromFloat(split))
+					dividends = append(dividends, action)
+				}
+			default:
+				return nil, nil, fmt.Errorf("%w: unknown kind %d at %s", ErrInvalidCorporateAction, action.Kind, action.ExDate)
+			}
+		}
+		k.O, k.H, k.L, k.C = k.O.Mul(factor), k.H.Mul(factor), k.L.Mul(factor), k.C.Mul(factor)
+		k.Volume *= split
+		k.TakerBuyVolume *= split
+		adjusted[i] = k
+	}
+
+	// Dividends were collected latest first
+	for i, j := 0, len(dividends)-1; i < j; i, j = i+1, j-1 {
+		dividends[i], dividends[j] = dividends[j], dividends[i]
+	}
+
+	return adjusted, dividends, nil
+}
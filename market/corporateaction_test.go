@@ -0,0 +1,174 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestReadCorporateActionsFromCSV(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		give    string
+		want    []CorporateAction
+		wantErr error
+	}{
+		{
+			name: "generic",
+			give: "testdata/corporateactions/actions.csv",
+			want: []CorporateAction{
+				{Kind: Dividend, ExDate: date(2020, time.August, 7), Amount: dec.New(0.82)},
+				{Kind: Split, ExDate: date(2020, time.August, 31), Ratio: 4},
+				{Kind: Dividend, ExDate: date(2020, time.November, 6), Amount: dec.New(0.205)},
+			},
+		},
+		{
+			name: "yahoo dividends",
+			give: "testdata/corporateactions/yahoo-dividends.csv",
+			want: []CorporateAction{
+				{Kind: Dividend, ExDate: date(2020, time.August, 7), Amount: dec.New(0.82)},
+				{Kind: Dividend, ExDate: date(2020, time.November, 6), Amount: dec.New(0.205)},
+			},
+		},
+		{
+			name: "yahoo splits",
+			give: "testdata/corporateactions/yahoo-splits.csv",
+			want: []CorporateAction{
+				{Kind: Split, ExDate: date(2014, time.June, 9), Ratio: 7},
+				{Kind: Split, ExDate: date(2020, time.August, 31), Ratio: 4},
+			},
+		},
+		{
+			name:    "unknown action",
+			give:    "testdata/corporateactions/invalid.csv",
+			wantErr: ErrInvalidCorporateAction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := ReadCorporateActionsFromCSV(tt.give)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, act, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Kind, act[i].Kind)
+				assert.Equal(t, tt.want[i].ExDate, act[i].ExDate)
+				assert.Equal(t, tt.want[i].Ratio, act[i].Ratio)
+				assert.True(t, tt.want[i].Amount.Equal(act[i].Amount), "amount %s", act[i].Amount)
+			}
+		})
+	}
+}
+
+func TestParseSplitRatio(t *testing.T) {
+	tests := []struct {
+		give    string
+		want    float64
+		wantErr bool
+	}{
+		{give: "4", want: 4},
+		{give: "4:1", want: 4},
+		{give: "3/2", want: 1.5},
+		{give: "1:10", want: 0.1},
+		{give: "0", wantErr: true},
+		{give: "4:0", wantErr: true},
+		{give: "four", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.give, func(t *testing.T) {
+			act, err := parseSplitRatio(tt.give)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCorporateAction)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.want, act, 1e-9)
+		})
+	}
+}
+
+func TestAdjustForCorporateActions(t *testing.T) {
+	day := func(i int) time.Time { return time.Date(2022, time.January, 3+i, 0, 0, 0, 0, time.UTC) }
+	klines := []Kline{
+		{Start: day(0), C: dec.New(100), Volume: 10},
+		{Start: day(1), C: dec.New(100), Volume: 10},
+		{Start: day(2), C: dec.New(25), Volume: 40}, // After a 4-for-1 split
+		{Start: day(3), C: dec.New(25), Volume: 40},
+		{Start: day(4), C: dec.New(25), Volume: 40},
+	}
+	actions := []CorporateAction{
+		{Kind: Dividend, ExDate: day(3), Amount: dec.New(0.5)},
+		{Kind: Split, ExDate: day(2), Ratio: 4},
+		{Kind: Dividend, ExDate: day(1), Amount: dec.New(2)},
+		{Kind: Dividend, ExDate: day(9), Amount: dec.New(1)}, // After the series
+	}
+
+	tests := []struct {
+		name          string
+		give          CorporateActionAdjustment
+		wantCloses    []float64
+		wantDividends []float64
+	}{
+		{
+			name:          "splits",
+			give:          AdjustSplits,
+			wantCloses:    []float64{25, 25, 25, 25, 25},
+			wantDividends: []float64{0.5, 0.5}, // First dividend restated per share after the split
+		},
+		{
+			name: "splits and dividends",
+			give: AdjustSplitsAndDividends,
+			// The dividend after the series adjusts every kline by 1 - 1/25
+			wantCloses: []float64{24.01 * 0.96, 24.5 * 0.96, 24.5 * 0.96, 25 * 0.96, 25 * 0.96},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, dividends, err := AdjustForCorporateActions(klines, actions, tt.give)
+			require.NoError(t, err)
+
+			require.Len(t, act, len(klines))
+			for i := range act {
+				assert.True(t, klines[i].Start.Equal(act[i].Start))
+				assert.InDelta(t, tt.wantCloses[i], act[i].C.InexactFloat64(), 1e-9, "close %d", i)
+				assert.Equal(t, 40.0, act[i].Volume, "volume %d", i)
+			}
+
+			require.Len(t, dividends, len(tt.wantDividends))
+			for i := range dividends {
+				assert.InDelta(t, tt.wantDividends[i], dividends[i].Amount.InexactFloat64(), 1e-9)
+			}
+			if len(dividends) > 0 {
+				assert.True(t, day(1).Equal(dividends[0].ExDate))
+			}
+		})
+	}
+}
+
+func TestAdjustForCorporateActions_Invalid(t *testing.T) {
+	start := time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC)
+	klines := []Kline{{Start: start, C: dec.New(1)}, {Start: start.AddDate(0, 0, 1), C: dec.New(1)}}
+
+	_, _, err := AdjustForCorporateActions(klines, []CorporateAction{
+		{Kind: Dividend, ExDate: start.AddDate(0, 0, 1), Amount: dec.New(1)},
+	}, AdjustSplitsAndDividends)
+	assert.ErrorIs(t, err, ErrInvalidCorporateAction)
+
+	_, _, err = AdjustForCorporateActions(klines, []CorporateAction{
+		{Kind: Split, ExDate: start.AddDate(0, 0, 1)},
+	}, AdjustSplits)
+	assert.ErrorIs(t, err, ErrInvalidCorporateAction)
+}
@@ -0,0 +1,200 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"container/heap"
+	"encoding/csv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var _ KlineReadCloser = (*CSVDirKlineReader)(nil)
+
+// CSVDirKlineReader streams klines lazily from all the .csv files in a directory (or a single file) in time order.
+// Klines within each file must be in ascending time order. Files may overlap in time:
+// the reader merges the files, opening each file only when its first kline is next in time.
+// Memory use is bounded by the number of files that overlap, not the length of the series.
+type CSVDirKlineReader struct {
+	// SkipDuplicates skips klines with the same start time as the previous kline, for example where files overlap.
+	SkipDuplicates bool
+
+	maker   MakeCSVKlineReader
+	pending []csvFileHead
+	open    csvFileHeap
+	last    time.Time
+	started bool
+}
+
+// NewCSVDirKlineReader scans the path for .csv files and reads the first kline of each to order them.
+// Files are not held open until they are needed.
+func NewCSVDirKlineReader(path string, maker MakeCSVKlineReader) (*CSVDirKlineReader, error) {
+	r := &CSVDirKlineReader{maker: maker}
+
+	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".csv" {
+			return nil
+		}
+		f, err := r.openFile(path, len(r.pending))
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		r.pending = append(r.pending, csvFileHead{path: path, order: f.order, first: f.next.Start})
+		return f.file.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(r.pending, func(i, j int) bool {
+		return r.pending[i].first.Before(r.pending[j].first)
+	})
+
+	return r, nil
+}
+
+// Read returns the next kline in time order across all files, or io.EOF when all files are exhausted.
+func (r *CSVDirKlineReader) Read() (Kline, error) {
+	for {
+		if err := r.openDue(); err != nil {
+			return Kline{}, err
+		}
+		if r.open.Len() == 0 {
+			return Kline{}, io.EOF
+		}
+
+		f := r.open[0]
+		k := f.next
+		next, err := f.reader.Read()
+		switch {
+		case err == io.EOF:
+			heap.Pop(&r.open)
+			if err := f.file.Close(); err != nil {
+				return Kline{}, err
+			}
+		case err != nil:
+			return Kline{}, err
+		default:
+			f.next = next
+			heap.Fix(&r.open, 0)
+		}
+
+		if r.SkipDuplicates && r.started && k.Start.Equal(r.last) {
+			continue
+		}
+		r.started = true
+		r.last = k.Start
+		return k, nil
+	}
+}
+
+// ReadAll reads all remaining klines. Prefer Read to bound memory use.
+func (r *CSVDirKlineReader) ReadAll() ([]Kline, error) {
+	var ks []Kline
+	for {
+		k, err := r.Read()
+		if err == io.EOF {
+			return ks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+}
+
+// Close closes any open files.
+func (r *CSVDirKlineReader) Close() error {
+	var firstErr error
+	for _, f := range r.open {
+		if err := f.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	r.open = nil
+	r.pending = nil
+	return firstErr
+}
+
+// openDue opens the pending files whose first kline is at or before the next kline of the open files.
+func (r *CSVDirKlineReader) openDue() error {
+	for len(r.pending) > 0 {
+		head := r.pending[0]
+		if r.open.Len() > 0 && head.first.After(r.open[0].next.Start) {
+			return nil
+		}
+		r.pending = r.pending[1:]
+		f, err := r.openFile(head.path, head.order)
+		if err == io.EOF {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		heap.Push(&r.open, f)
+	}
+	return nil
+}
+
+func (r *CSVDirKlineReader) openFile(path string, order int) (*csvFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	reader := r.maker(csv.NewReader(file))
+	next, err := reader.Read()
+	if err != nil {
+		//nolint:errcheck // Already returning the read error
+		file.Close()
+		return nil, err
+	}
+	return &csvFile{file: file, reader: reader, next: next, order: order}, nil
+}
+
+type csvFileHead struct {
+	path  string
+	order int
+	first time.Time
+}
+
+type csvFile struct {
+	file   *os.File
+	reader *CSVKlineReader
+	next   Kline
+	order  int
+}
+
+// csvFileHeap is a min-heap of open files ordered by their next kline, then by file order.
+type csvFileHeap []*csvFile
+
+func (h csvFileHeap) Len() int { return len(h) }
+
+func (h csvFileHeap) Less(i, j int) bool {
+	if h[i].next.Start.Equal(h[j].next.Start) {
+		return h[i].order < h[j].order
+	}
+	return h[i].next.Start.Before(h[j].next.Start)
+}
+
+func (h csvFileHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *csvFileHeap) Push(x any) { *h = append(*h, x.(*csvFile)) }
+
+func (h *csvFileHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
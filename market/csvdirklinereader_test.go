@@ -0,0 +1,106 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCSVForDirReaderTest writes a Binance format file of hourly klines with the close set to the hour.
+func writeCSVForDirReaderTest(t *testing.T, dir, name string, hours ...int) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var sb strings.Builder
+	for _, h := range hours {
+		ms := start.Add(time.Duration(h) * time.Hour).UnixMilli()
+		fmt.Fprintf(&sb, "%d,1,2,0.5,%d,10\n", ms, h)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(sb.String()), 0600))
+}
+
+func TestCSVDirKlineReader(t *testing.T) {
+	dir := t.TempDir()
+	// Named so that walk order differs from time order, and overlapping in time
+	writeCSVForDirReaderTest(t, dir, "a.csv", 4, 5, 6, 7)
+	writeCSVForDirReaderTest(t, dir, "b.csv", 0, 1, 2, 3, 4)
+	writeCSVForDirReaderTest(t, dir, "c.csv", 8, 9)
+	writeCSVForDirReaderTest(t, dir, "empty.csv")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0600))
+
+	closes := func(skipDuplicates bool) []int64 {
+		r, err := NewCSVDirKlineReader(dir, NewBinanceCSVKlineReader)
+		require.NoError(t, err)
+		r.SkipDuplicates = skipDuplicates
+		defer func() { assert.NoError(t, r.Close()) }()
+
+		var act []int64
+		for {
+			k, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			act = append(act, k.C.IntPart())
+		}
+		return act
+	}
+
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 4, 5, 6, 7, 8, 9}, closes(false))
+	assert.Equal(t, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, closes(true))
+}
+
+func TestCSVDirKlineReader_MatchesReadKlinesFromCSV(t *testing.T) {
+	want, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+
+	r, err := NewCSVDirKlineSource("./testdata/BTCUSDT-1h-2021-Q1.csv", NewBinanceCSVKlineReader).Open()
+	require.NoError(t, err)
+	act, err := r.ReadAll()
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, want, act)
+}
+
+func TestSubKlineSource(t *testing.T) {
+	src := SliceKlineSource(make([]Kline, 10))
+	for i := range src {
+		src[i].Volume = float64(i)
+	}
+
+	tests := []struct {
+		name        string
+		giveOffset  int
+		giveCount   int
+		wantVolumes []float64
+	}{
+		{name: "head", giveOffset: 0, giveCount: 3, wantVolumes: []float64{0, 1, 2}},
+		{name: "tail", giveOffset: 8, giveCount: -1, wantVolumes: []float64{8, 9}},
+		{name: "offset beyond end", giveOffset: 20, giveCount: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewSubKlineSource(src, tt.giveOffset, tt.giveCount).Open()
+			require.NoError(t, err)
+			ks, err := r.ReadAll()
+			assert.NoError(t, err)
+			var act []float64
+			for _, k := range ks {
+				act = append(act, k.Volume)
+			}
+			assert.Equal(t, tt.wantVolumes, act)
+		})
+	}
+
+	n, err := CountKlines(NewSubKlineSource(src, 2, 5))
+	assert.NoError(t, err)
+	assert.Equal(t, 5, n)
+}
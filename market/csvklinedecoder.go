@@ -28,6 +28,9 @@ var (
 
 	// ErrInvalidVolumeFormat is returned when the CSV price record does not have a valid volume format.
 	ErrInvalidVolumeFormat = errors.New("volume must be in valid float format")
+
+	// ErrInvalidTradeCountFormat is returned when the CSV price record does not have a valid trade count format.
+	ErrInvalidTradeCountFormat = errors.New("trade count must be in valid integer format")
 )
 
 // CSVKlineDecoder is an extension point for CSVKlineReader to support custom file formats.
@@ -42,6 +45,7 @@ func NewBinanceCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
 }
 
 // BinanceCSVKlineDecoder decodes a CSV record from Binance into a Kline.
+// The order flow columns (quote volume, trade count and taker buy base and quote volume) are decoded if present.
 func BinanceCSVKlineDecoder(record []string) (Kline, error) {
 	var k, empty Kline
 	var err error
@@ -75,6 +79,22 @@ func BinanceCSVKlineDecoder(record []string) (Kline, error) {
 		}
 	}
 
+	// Column 6 is the close time
+	if len(record) > 10 {
+		if k.QuoteVolume, err = strconv.ParseFloat(record[7], 64); err != nil {
+			return empty, ErrInvalidVolumeFormat
+		}
+		if k.TradeCount, err = strconv.ParseInt(record[8], 10, 64); err != nil {
+			return empty, ErrInvalidTradeCountFormat
+		}
+		if k.TakerBuyVolume, err = strconv.ParseFloat(record[9], 64); err != nil {
+			return empty, ErrInvalidVolumeFormat
+		}
+		if k.TakerBuyQuoteVolume, err = strconv.ParseFloat(record[10], 64); err != nil {
+			return empty, ErrInvalidVolumeFormat
+		}
+	}
+
 	return k, nil
 }
 
@@ -6,6 +6,7 @@ package market
 import (
 	"encoding/csv"
 	"io"
+	"sort"
 )
 
 var _ KlineReader = (*CSVKlineReader)(nil)
@@ -19,6 +20,30 @@ type CSVKlineReader struct {
 // MakeCSVKlineReader is a factory method type that creates a new CSVKlineReader.
 type MakeCSVKlineReader func(csv *csv.Reader) *CSVKlineReader
 
+// CSVDecoders is the registry of CSV kline readers by decoder name, e.g. "binance".
+// The commands and the studyrun app select a reader by this name, and the kline cache records it.
+var CSVDecoders = map[string]MakeCSVKlineReader{
+	"binance":        NewBinanceCSVKlineReader,
+	"metatrader":     NewMetaTraderCSVKlineReader,
+	"yahoo":          NewYahooCSVKlineReader,
+	"yahoo.adjusted": NewYahooAdjustedCSVKlineReader,
+	"dukascopy":      NewDukascopyCSVKlineReader,
+	"kraken":         NewKrakenCSVKlineReader,
+	"coinbase":       NewCoinbaseCSVKlineReader,
+	"bybit":          NewBybitCSVKlineReader,
+	"tradingview":    NewTradingViewCSVKlineReader,
+}
+
+// CSVDecoderNames returns the names of the registered CSV decoders in lexical order.
+func CSVDecoderNames() []string {
+	names := make([]string, 0, len(CSVDecoders))
+	for name := range CSVDecoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewCSVKlineReader creates a new CSVKlineReader with the default Binance decoder.
 func NewCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
 	return &CSVKlineReader{
@@ -36,15 +61,22 @@ func NewCSVKlineReaderWithDecoder(csv *csv.Reader, decoder CSVKlineDecoder) *CSV
 }
 
 // Read reads the next Kline from the underlying CSV data.
+// Records for which the decoder returns ErrSkipRecord are skipped.
 func (r *CSVKlineReader) Read() (Kline, error) {
-	var k Kline
+	for {
+		var k Kline
 
-	rec, err := r.csv.Read()
-	if err != nil {
+		rec, err := r.csv.Read()
+		if err != nil {
+			return k, err
+		}
+
+		k, err = r.decoder(rec)
+		if err == ErrSkipRecord {
+			continue
+		}
 		return k, err
 	}
-
-	return r.decoder(rec)
 }
 
 // ReadAll reads all the Klines from the underlying CSV data.
@@ -154,3 +154,11 @@ func TestCSVKlineReader_ReadWithMetaTraderDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestCSVDecoderNames(t *testing.T) {
+	act := CSVDecoderNames()
+	assert.Len(t, act, len(CSVDecoders))
+	assert.IsIncreasing(t, act)
+	assert.Contains(t, act, "binance")
+	assert.Contains(t, act, "yahoo.adjusted")
+}
@@ -0,0 +1,246 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Time formats of a unix timestamp column for CSVSpec.TimeFormat. Any other value is a time.Parse layout.
+const (
+	TimeFormatUnix      = "unix"
+	TimeFormatUnixMilli = "unixms"
+	TimeFormatUnixMicro = "unixus"
+)
+
+var (
+	// ErrSkipRecord is returned by a CSVKlineDecoder to skip a record that is not a kline, such as a header row.
+	ErrSkipRecord = errors.New("skip record")
+
+	// ErrInvalidCSVSpec is returned when a CSVSpec is incomplete or inconsistent.
+	ErrInvalidCSVSpec = errors.New("invalid csv spec")
+
+	// ErrColumnNotFound is returned when a column name in a CSVSpec is not present in the header row.
+	ErrColumnNotFound = errors.New("column not found in header")
+)
+
+// CSVSpec declares the layout of a CSV price file so that a new vendor format can be read without writing a decoder.
+// Columns are referenced by zero based index (e.g. "0") or, if the file has a header row, by name (e.g. "open_time").
+type CSVSpec struct {
+	Start  string
+	Time   string // Optional: a separate time of day column, joined to Start with a space before parsing
+	Open   string
+	High   string
+	Low    string
+	Close  string
+	Volume string // Optional: leave empty if the file has no volume column
+
+	// Optional order flow columns: leave empty if the file does not have them.
+	QuoteVolume         string
+	TradeCount          string
+	TakerBuyVolume      string
+	TakerBuyQuoteVolume string
+
+	// TimeFormat is one of TimeFormatUnix, TimeFormatUnixMilli, TimeFormatUnixMicro or a time.Parse layout.
+	// Empty defaults to TimeFormatUnixMilli.
+	TimeFormat string
+
+	// Location is the timezone of times parsed with a layout. Nil defaults to UTC.
+	Location *time.Location
+
+	// Delimiter is the field delimiter. Zero defaults to a comma.
+	Delimiter rune
+
+	// SkipRows is the number of leading rows to skip, before the header row if any.
+	SkipRows int
+
+	// Header is true if the first row after SkipRows is a header of column names.
+	Header bool
+}
+
+// Validate checks that the required columns are declared and that named columns are only used with a header row.
+func (s CSVSpec) Validate() error {
+	required := []struct{ field, col string }{
+		{"start", s.Start}, {"open", s.Open}, {"high", s.High}, {"low", s.Low}, {"close", s.Close},
+	}
+	for _, r := range required {
+		if r.col == "" {
+			return fmt.Errorf("%w: '%s' column is required", ErrInvalidCSVSpec, r.field)
+		}
+	}
+	for _, col := range s.refs() {
+		if col == "" {
+			continue
+		}
+		if i, err := strconv.Atoi(col); err == nil {
+			if i < 0 {
+				return fmt.Errorf("%w: negative column index %d", ErrInvalidCSVSpec, i)
+			}
+			continue
+		}
+		if !s.Header {
+			return fmt.Errorf("%w: column '%s' referenced by name requires a header row", ErrInvalidCSVSpec, col)
+		}
+	}
+	if s.SkipRows < 0 {
+		return fmt.Errorf("%w: negative skip rows", ErrInvalidCSVSpec)
+	}
+	return nil
+}
+
+// NewCSVKlineReader creates a new CSVKlineReader that decodes records according to the spec.
+// Use as a MakeCSVKlineReader, e.g. MakeCSVKlineReader(spec.NewCSVKlineReader).
+// The spec should be checked with Validate beforehand, otherwise errors are returned on the first Read.
+func (s CSVSpec) NewCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	if s.Delimiter != 0 {
+		csv.Comma = s.Delimiter
+	}
+	// Skipped rows may have a different number of fields to the price records
+	csv.FieldsPerRecord = -1
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: s.NewCSVKlineDecoder(),
+	}
+}
+
+// NewCSVKlineDecoder creates a decoder for the spec. The decoder holds the state of the rows skipped and the header,
+// so a new decoder is required for each file.
+func (s CSVSpec) NewCSVKlineDecoder() CSVKlineDecoder {
+	var rows int
+	var index map[string]int
+	var columns []int
+	return func(record []string) (Kline, error) {
+		var k Kline
+
+		rows++
+		if rows <= s.SkipRows {
+			return k, ErrSkipRecord
+		}
+		if s.Header && index == nil {
+			index = make(map[string]int, len(record))
+			for i, name := range record {
+				index[strings.TrimSpace(name)] = i
+			}
+			return k, ErrSkipRecord
+		}
+		if columns == nil {
+			var err error
+			if columns, err = s.resolveColumns(index); err != nil {
+				return k, err
+			}
+		}
+		return s.decode(record, columns)
+	}
+}
+
+// refs returns the spec columns in the order start, time, open, high, low, close, volume,
+// quote volume, trade count, taker buy volume and taker buy quote volume.
+func (s CSVSpec) refs() []string {
+	return []string{s.Start, s.Time, s.Open, s.High, s.Low, s.Close, s.Volume,
+		s.QuoteVolume, s.TradeCount, s.TakerBuyVolume, s.TakerBuyQuoteVolume}
+}
+
+// resolveColumns maps the spec columns to indexes in the order of refs.
+// Optional columns that are not declared are mapped to -1.
+func (s CSVSpec) resolveColumns(index map[string]int) ([]int, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	refs := s.refs()
+	columns := make([]int, len(refs))
+	for i, ref := range refs {
+		if ref == "" {
+			columns[i] = -1
+			continue
+		}
+		if n, err := strconv.Atoi(ref); err == nil {
+			columns[i] = n
+			continue
+		}
+		n, ok := index[ref]
+		if !ok {
+			return nil, fmt.Errorf("%w: '%s'", ErrColumnNotFound, ref)
+		}
+		columns[i] = n
+	}
+	return columns, nil
+}
+
+func (s CSVSpec) decode(record []string, columns []int) (Kline, error) {
+	var k, empty Kline
+	var err error
+
+	for _, col := range columns {
+		if col >= len(record) {
+			return empty, ErrNotEnoughColumns
+		}
+	}
+
+	t := record[columns[0]]
+	if columns[1] >= 0 {
+		t += " " + record[columns[1]]
+	}
+	if k.Start, err = s.parseTime(strings.TrimSpace(t)); err != nil {
+		return empty, ErrInvalidTimeFormat
+	}
+
+	prices := []*decimal.Decimal{&k.O, &k.H, &k.L, &k.C}
+	for i, price := range prices {
+		if *price, err = decimal.NewFromString(strings.TrimSpace(record[columns[i+2]])); err != nil {
+			return empty, ErrInvalidPriceFormat
+		}
+	}
+
+	volumes := []*float64{&k.Volume, &k.QuoteVolume, &k.TakerBuyVolume, &k.TakerBuyQuoteVolume}
+	for i, col := range []int{columns[6], columns[7], columns[9], columns[10]} {
+		if col < 0 {
+			continue
+		}
+		if *volumes[i], err = strconv.ParseFloat(strings.TrimSpace(record[col]), 64); err != nil {
+			return empty, ErrInvalidVolumeFormat
+		}
+	}
+
+	if columns[8] >= 0 {
+		if k.TradeCount, err = strconv.ParseInt(strings.TrimSpace(record[columns[8]]), 10, 64); err != nil {
+			return empty, ErrInvalidTradeCountFormat
+		}
+	}
+
+	return k, nil
+}
+
+func (s CSVSpec) parseTime(v string) (time.Time, error) {
+	var unit time.Duration
+	switch s.TimeFormat {
+	case "", TimeFormatUnixMilli:
+		unit = time.Millisecond
+	case TimeFormatUnix:
+		unit = time.Second
+	case TimeFormatUnixMicro:
+		unit = time.Microsecond
+	default:
+		loc := s.Location
+		if loc == nil {
+			loc = time.UTC
+		}
+		t, err := time.ParseInLocation(s.TimeFormat, v, loc)
+		if err != nil {
+			return t, err
+		}
+		return t.UTC(), nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, n*int64(unit)).UTC(), nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestCSVSpec_NewCSVKlineReader(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		spec CSVSpec
+		give string
+		want []Kline
+		err  error
+	}{
+		{
+			name: "binance equivalent",
+			spec: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3", Close: "4", Volume: "5"},
+			give: "1609459200000,28923.63,29031.34,28690.17,28995.13,2311.81",
+			want: []Kline{
+				{Start: time.UnixMilli(1609459200000).UTC(), O: dec.New(28923.63), H: dec.New(29031.34), L: dec.New(28690.17), C: dec.New(28995.13), Volume: 2311.81},
+			},
+		},
+		{
+			name: "binance order flow",
+			spec: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3", Close: "4", Volume: "5",
+				QuoteVolume: "7", TradeCount: "8", TakerBuyVolume: "9", TakerBuyQuoteVolume: "10"},
+			give: "1609459200000,28923.63,29031.34,28690.17,28995.13,2311.811445,1609462799999,66768830.34010008,58389,1215.359238,35103542.78288276,0",
+			want: []Kline{
+				{Start: time.UnixMilli(1609459200000).UTC(), O: dec.New(28923.63), H: dec.New(29031.34), L: dec.New(28690.17), C: dec.New(28995.13),
+					Volume: 2311.811445, QuoteVolume: 66768830.34010008, TradeCount: 58389, TakerBuyVolume: 1215.359238, TakerBuyQuoteVolume: 35103542.78288276},
+			},
+		},
+		{
+			name: "header names with layout and timezone",
+			spec: CSVSpec{Start: "Date", Open: "Open", High: "High", Low: "Low", Close: "Close",
+				TimeFormat: "2006-01-02", Location: newYork, Header: true},
+			give: "Date,Open,High,Low,Close,Adj Close\n2022-01-03,10,12,9,11,11\n2022-01-04,11,13,10,12,12",
+			want: []Kline{
+				{Start: time.Date(2022, time.January, 3, 5, 0, 0, 0, time.UTC), O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(11)},
+				{Start: time.Date(2022, time.January, 4, 5, 0, 0, 0, time.UTC), O: dec.New(11), H: dec.New(13), L: dec.New(10), C: dec.New(12)},
+			},
+		},
+		{
+			name: "skip rows, delimiter and separate time column",
+			spec: CSVSpec{Start: "0", Time: "1", Open: "2", High: "3", Low: "4", Close: "5", Volume: "6",
+				TimeFormat: "02/01/2006 15:04", Delimiter: ';', SkipRows: 1},
+			give: "exported by vendor\n01/02/2022;13:00;1.5;2;1;1.75;100",
+			want: []Kline{
+				{Start: time.Date(2022, time.February, 1, 13, 0, 0, 0, time.UTC), O: dec.New(1.5), H: dec.New(2), L: dec.New(1), C: dec.New(1.75), Volume: 100},
+			},
+		},
+		{
+			name: "unix seconds",
+			spec: CSVSpec{Start: "0", Open: "1", High: "1", Low: "1", Close: "1", TimeFormat: TimeFormatUnix},
+			give: "1640995200,5",
+			want: []Kline{
+				{Start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), O: dec.New(5), H: dec.New(5), L: dec.New(5), C: dec.New(5)},
+			},
+		},
+		{
+			name: "column not in header",
+			spec: CSVSpec{Start: "time", Open: "o", High: "h", Low: "l", Close: "missing", Header: true},
+			give: "time,o,h,l,c\n1640995200000,1,1,1,1",
+			err:  ErrColumnNotFound,
+		},
+		{
+			name: "not enough columns",
+			spec: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3", Close: "4"},
+			give: "1640995200000,1,1,1",
+			err:  ErrNotEnoughColumns,
+		},
+		{
+			name: "invalid time",
+			spec: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3", Close: "4", TimeFormat: TimeFormatUnix},
+			give: "2022-01-01,1,1,1,1",
+			err:  ErrInvalidTimeFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := tt.spec.NewCSVKlineReader(csv.NewReader(strings.NewReader(tt.give)))
+			act, err := reader.ReadAll()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assertKlinesEqual(t, tt.want, act)
+		})
+	}
+}
+
+func TestCSVSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		give CSVSpec
+		want error
+	}{
+		{
+			name: "valid",
+			give: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3", Close: "4"},
+			want: nil,
+		},
+		{
+			name: "missing close",
+			give: CSVSpec{Start: "0", Open: "1", High: "2", Low: "3"},
+			want: ErrInvalidCSVSpec,
+		},
+		{
+			name: "name without header",
+			give: CSVSpec{Start: "time", Open: "1", High: "2", Low: "3", Close: "4"},
+			want: ErrInvalidCSVSpec,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.give.Validate(), tt.want)
+		})
+	}
+}
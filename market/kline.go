@@ -11,6 +11,7 @@ import (
 )
 
 // Kline represents a single candlestick.
+// The order flow fields are optional and zero if not provided by the price source.
 type Kline struct {
 	Start  time.Time
 	O      decimal.Decimal
@@ -18,4 +19,23 @@ type Kline struct {
 	L      decimal.Decimal
 	C      decimal.Decimal
 	Volume float64
+
+	// QuoteVolume is the volume in the quote asset, e.g. USDT for BTCUSDT.
+	QuoteVolume float64
+
+	// TradeCount is the number of trades.
+	TradeCount int64
+
+	// TakerBuyVolume is the volume of trades initiated by a buyer (taker), in the base asset.
+	TakerBuyVolume float64
+
+	// TakerBuyQuoteVolume is the volume of trades initiated by a buyer (taker), in the quote asset.
+	TakerBuyQuoteVolume float64
+
+	// Synthetic is true if the kline was inserted to fill a gap in the price data, rather than traded.
+	// Indicators should skip synthetic klines so that flat prices do not distort them.
+	Synthetic bool
+
+	// AfterGap is true if klines are missing immediately before this kline and the gap was flagged rather than filled.
+	AfterGap bool
 }
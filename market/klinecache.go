@@ -0,0 +1,374 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// KlineCacheExt is the file extension of a kline cache file.
+const KlineCacheExt = ".klc"
+
+// KlineCacheVersion is the version of the kline cache format written by this package.
+// Caches of an older version are not read, so are rebuilt from the CSV files.
+const KlineCacheVersion = 4
+
+var _klineCacheMagic = [4]byte{'A', 'K', 'K', 'C'}
+
+const _klineCacheHeaderLen = 16
+
+// Bits of the kline cache flags column.
+const (
+	_klineCacheSynthetic = 1 << iota
+	_klineCacheAfterGap
+)
+
+var (
+	// ErrInvalidKlineCache is returned when data is not a valid kline cache.
+	ErrInvalidKlineCache = errors.New("invalid kline cache")
+
+	// ErrKlineCacheVersion is returned when a kline cache was written with an unsupported format version.
+	ErrKlineCacheVersion = errors.New("unsupported kline cache version")
+
+	// ErrKlineCacheOverflow is returned when a price cannot be represented in the cache format.
+	ErrKlineCacheOverflow = errors.New("price exceeds kline cache precision")
+)
+
+// EncodeKlineCache writes klines in a compact columnar binary format that can be decoded without parsing.
+// The decoder is the name of the CSV decoder the klines were read with, so that a reader can check
+// the cache holds the same prices as decoding the CSV files would.
+//
+// All values are little endian. A 16 byte header holds the magic "AKKC", the uint32 format version and the uint64 kline count.
+// The uint64 length of the decoder name follows, then the name padded to 8 bytes.
+// The columns follow in order, each aligned to 8 bytes:
+//
+// - Start: int64 unix nanoseconds
+//
+// - O, H, L, C: int32 decimal exponent shared by the column, 4 bytes padding, then int64 coefficients
+//
+// - Volume, QuoteVolume: float64
+//
+// - TradeCount: int64
+//
+// - TakerBuyVolume, TakerBuyQuoteVolume: float64
+//
+// - Flags: uint64 with bit 0 set for Synthetic and bit 1 for AfterGap
+func EncodeKlineCache(w io.Writer, klines []Kline, decoder string) error {
+	bw := bufio.NewWriter(w)
+
+	header := make([]byte, _klineCacheHeaderLen+klineCacheDecoderLen(len(decoder)))
+	copy(header, _klineCacheMagic[:])
+	binary.LittleEndian.PutUint32(header[4:], KlineCacheVersion)
+	binary.LittleEndian.PutUint64(header[8:], uint64(len(klines)))
+	binary.LittleEndian.PutUint64(header[16:], uint64(len(decoder)))
+	copy(header[24:], decoder)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8)
+	writeUint64 := func(v uint64) error {
+		binary.LittleEndian.PutUint64(buf, v)
+		_, err := bw.Write(buf)
+		return err
+	}
+
+	for i := range klines {
+		if err := writeUint64(uint64(klines[i].Start.UnixNano())); err != nil {
+			return err
+		}
+	}
+
+	columns := []func(Kline) decimal.Decimal{
+		func(k Kline) decimal.Decimal { return k.O },
+		func(k Kline) decimal.Decimal { return k.H },
+		func(k Kline) decimal.Decimal { return k.L },
+		func(k Kline) decimal.Decimal { return k.C },
+	}
+	for _, column := range columns {
+		exp, coefficients, err := encodeDecimalColumn(klines, column)
+		if err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint64(buf, uint64(uint32(exp)))
+		if _, err := bw.Write(buf); err != nil {
+			return err
+		}
+		for _, c := range coefficients {
+			if err := writeUint64(uint64(c)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, column := range klineCacheVolumeColumns {
+		for i := range klines {
+			if err := writeUint64(column.encode(&klines[i])); err != nil {
+				return err
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// DecodeKlineCache decodes klines from data written by EncodeKlineCache. Start times are in UTC.
+func DecodeKlineCache(data []byte) ([]Kline, error) {
+	decoder, err := decodeKlineCacheDecoder(data)
+	if err != nil {
+		return nil, err
+	}
+	headerLen := uint64(_klineCacheHeaderLen + klineCacheDecoderLen(len(decoder)))
+	count := binary.LittleEndian.Uint64(data[8:])
+	// Start, O, H, L, C, volume and flag columns plus 4 decimal exponent words
+	columnCount := uint64(5 + len(klineCacheVolumeColumns))
+	if count > uint64(len(data)) || uint64(len(data)) != headerLen+count*8*columnCount+4*8 {
+		return nil, ErrInvalidKlineCache
+	}
+	n := int(count)
+
+	klines := make([]Kline, n)
+	offset := int(headerLen)
+	for i := 0; i < n; i++ {
+		klines[i].Start = time.Unix(0, int64(binary.LittleEndian.Uint64(data[offset:]))).UTC()
+		offset += 8
+	}
+
+	columns := []func(*Kline) *decimal.Decimal{
+		func(k *Kline) *decimal.Decimal { return &k.O },
+		func(k *Kline) *decimal.Decimal { return &k.H },
+		func(k *Kline) *decimal.Decimal { return &k.L },
+		func(k *Kline) *decimal.Decimal { return &k.C },
+	}
+	for _, column := range columns {
+		exp := int32(binary.LittleEndian.Uint32(data[offset:]))
+		offset += 8
+		for i := 0; i < n; i++ {
+			*column(&klines[i]) = decimal.New(int64(binary.LittleEndian.Uint64(data[offset:])), exp)
+			offset += 8
+		}
+	}
+
+	for _, column := range klineCacheVolumeColumns {
+		for i := 0; i < n; i++ {
+			column.decode(&klines[i], binary.LittleEndian.Uint64(data[offset:]))
+			offset += 8
+		}
+	}
+
+	return klines, nil
+}
+
+// decodeKlineCacheDecoder decodes the name of the CSV decoder from the header of data written by EncodeKlineCache.
+func decodeKlineCacheDecoder(data []byte) (string, error) {
+	n, err := decodeKlineCacheHeader(data)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(len(data)) || uint64(len(data)) < uint64(_klineCacheHeaderLen+klineCacheDecoderLen(int(n))) {
+		return "", ErrInvalidKlineCache
+	}
+	return string(data[24 : 24+n]), nil
+}
+
+// decodeKlineCacheHeader checks the fixed header and returns the length of the decoder name that follows it.
+func decodeKlineCacheHeader(data []byte) (uint64, error) {
+	if len(data) < _klineCacheHeaderLen+8 || !strings.HasPrefix(string(data[:4]), string(_klineCacheMagic[:])) {
+		return 0, ErrInvalidKlineCache
+	}
+	if binary.LittleEndian.Uint32(data[4:]) != KlineCacheVersion {
+		return 0, ErrKlineCacheVersion
+	}
+	return binary.LittleEndian.Uint64(data[16:]), nil
+}
+
+// klineCacheDecoderLen returns the length of the decoder name field: the length word then the name padded to 8 bytes.
+func klineCacheDecoderLen(n int) int {
+	return 8 + (n+7)/8*8
+}
+
+// klineCacheVolumeColumns are the 64 bit volume, order flow and flag columns in cache order.
+var klineCacheVolumeColumns = []struct {
+	encode func(k *Kline) uint64
+	decode func(k *Kline, v uint64)
+}{
+	{
+		func(k *Kline) uint64 { return math.Float64bits(k.Volume) },
+		func(k *Kline, v uint64) { k.Volume = math.Float64frombits(v) },
+	},
+	{
+		func(k *Kline) uint64 { return math.Float64bits(k.QuoteVolume) },
+		func(k *Kline, v uint64) { k.QuoteVolume = math.Float64frombits(v) },
+	},
+	{
+		func(k *Kline) uint64 { return uint64(k.TradeCount) },
+		func(k *Kline, v uint64) { k.TradeCount = int64(v) },
+	},
+	{
+		func(k *Kline) uint64 { return math.Float64bits(k.TakerBuyVolume) },
+		func(k *Kline, v uint64) { k.TakerBuyVolume = math.Float64frombits(v) },
+	},
+	{
+		func(k *Kline) uint64 { return math.Float64bits(k.TakerBuyQuoteVolume) },
+		func(k *Kline, v uint64) { k.TakerBuyQuoteVolume = math.Float64frombits(v) },
+	},
+	{
+		func(k *Kline) uint64 {
+			var flags uint64
+			if k.Synthetic {
+				flags |= _klineCacheSynthetic
+			}
+			if k.AfterGap {
+				flags |= _klineCacheAfterGap
+			}
+			return flags
+		},
+		func(k *Kline, v uint64) {
+			k.Synthetic = v&_klineCacheSynthetic != 0
+			k.AfterGap = v&_klineCacheAfterGap != 0
+		},
+	},
+}
+
+// WriteKlineCache writes klines read with the named CSV decoder to a cache file.
+func WriteKlineCache(filename string, klines []Kline, decoder string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := EncodeKlineCache(file, klines, decoder); err != nil {
+		//nolint:errcheck // Already returning the encode error
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// ReadKlineCache reads klines from a cache file. The file is memory-mapped where supported.
+func ReadKlineCache(filename string) ([]Kline, error) {
+	data, unmap, err := mapFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	klines, err := DecodeKlineCache(data)
+	if unmapErr := unmap(); err == nil {
+		err = unmapErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return klines, nil
+}
+
+// KlineCacheFilename returns the cache filename for a CSV path.
+// The cache for a directory is stored next to it: "data/btcusdt-h1/" is cached in "data/btcusdt-h1.klc".
+// The cache for a single file is stored alongside it: "data/btcusdt.csv" is cached in "data/btcusdt.klc".
+func KlineCacheFilename(path string) string {
+	path = filepath.Clean(path)
+	return strings.TrimSuffix(path, filepath.Ext(path)) + KlineCacheExt
+}
+
+// ReadKlineCacheDecoder reads the name of the CSV decoder that a cache file was written with.
+func ReadKlineCacheDecoder(filename string) (string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	//nolint:errcheck // Read ops only so safe to ignore err return
+	defer file.Close()
+
+	header := make([]byte, _klineCacheHeaderLen+8)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return "", ErrInvalidKlineCache
+	}
+	n, err := decodeKlineCacheHeader(header)
+	if err != nil {
+		return "", err
+	}
+	if n > math.MaxUint16 {
+		return "", ErrInvalidKlineCache
+	}
+	name := make([]byte, n)
+	if _, err := io.ReadFull(file, name); err != nil {
+		return "", ErrInvalidKlineCache
+	}
+	return string(name), nil
+}
+
+// readFreshKlineCache reads the cache for a CSV path if it exists, was written with the named decoder
+// and is newer than every CSV file and directory in the path.
+func readFreshKlineCache(path, decoder string) ([]Kline, bool) {
+	filename := KlineCacheFilename(path)
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, false
+	}
+	cached := info.ModTime()
+	if name, err := ReadKlineCacheDecoder(filename); err != nil || name != decoder {
+		return nil, false
+	}
+
+	stale := errors.New("stale")
+	err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && filepath.Ext(path) != ".csv" {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(cached) {
+			return stale
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	klines, err := ReadKlineCache(filename)
+	if err != nil {
+		return nil, false
+	}
+	return klines, true
+}
+
+// encodeDecimalColumn rescales the column values to the smallest exponent in the column so they share one exponent.
+func encodeDecimalColumn(klines []Kline, column func(Kline) decimal.Decimal) (int32, []int64, error) {
+	var exp int32
+	for i := range klines {
+		if e := column(klines[i]).Exponent(); i == 0 || e < exp {
+			exp = e
+		}
+	}
+
+	coefficients := make([]int64, len(klines))
+	for i := range klines {
+		d := column(klines[i])
+		c := d.Coefficient()
+		if shift := d.Exponent() - exp; shift > 0 {
+			c.Mul(c, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(shift)), nil))
+		}
+		if !c.IsInt64() {
+			return 0, nil, ErrKlineCacheOverflow
+		}
+		coefficients[i] = c.Int64()
+	}
+	return exp, coefficients, nil
+}
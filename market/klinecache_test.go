@@ -0,0 +1,108 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func assertKlinesEqual(t *testing.T, want, act []Kline) {
+	t.Helper()
+	require.Len(t, act, len(want))
+	for i := range want {
+		assert.True(t, want[i].Start.Equal(act[i].Start), "start %d", i)
+		assert.True(t, want[i].O.Equal(act[i].O), "open %d", i)
+		assert.True(t, want[i].H.Equal(act[i].H), "high %d", i)
+		assert.True(t, want[i].L.Equal(act[i].L), "low %d", i)
+		assert.True(t, want[i].C.Equal(act[i].C), "close %d", i)
+		assert.Equal(t, want[i].Volume, act[i].Volume, "volume %d", i)
+		assert.Equal(t, want[i].QuoteVolume, act[i].QuoteVolume, "quote volume %d", i)
+		assert.Equal(t, want[i].TradeCount, act[i].TradeCount, "trade count %d", i)
+		assert.Equal(t, want[i].TakerBuyVolume, act[i].TakerBuyVolume, "taker buy volume %d", i)
+		assert.Equal(t, want[i].TakerBuyQuoteVolume, act[i].TakerBuyQuoteVolume, "taker buy quote volume %d", i)
+		assert.Equal(t, want[i].Synthetic, act[i].Synthetic, "synthetic %d", i)
+		assert.Equal(t, want[i].AfterGap, act[i].AfterGap, "after gap %d", i)
+	}
+}
+
+func TestKlineCache_EncodeDecode(t *testing.T) {
+	give := []Kline{
+		{Start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC), O: dec.New(1.5), H: dec.New(2), L: dec.New(0.125), C: dec.New(1), Volume: 10.5, AfterGap: true},
+		{Start: time.Date(2022, time.January, 1, 1, 0, 0, 0, time.UTC), O: dec.New(-3), H: dec.New(200), L: dec.New(0), C: dec.New(12.25), Volume: 0, Synthetic: true},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeKlineCache(&buf, give, "yahoo.adjusted"))
+	act, err := DecodeKlineCache(buf.Bytes())
+	require.NoError(t, err)
+	assertKlinesEqual(t, give, act)
+	decoder, err := decodeKlineCacheDecoder(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "yahoo.adjusted", decoder)
+
+	_, err = DecodeKlineCache(buf.Bytes()[:buf.Len()-1])
+	assert.ErrorIs(t, err, ErrInvalidKlineCache)
+	_, err = DecodeKlineCache([]byte("not a cache file"))
+	assert.ErrorIs(t, err, ErrInvalidKlineCache)
+}
+
+func TestKlineCacheFilename(t *testing.T) {
+	assert.Equal(t, filepath.FromSlash("data/btcusdt-h1.klc"), KlineCacheFilename("data/btcusdt-h1/"))
+	assert.Equal(t, filepath.FromSlash("data/btcusdt.klc"), KlineCacheFilename("data/btcusdt.csv"))
+}
+
+func TestReadKlinesFromCSV_UsesFreshCache(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prices")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	csv, err := os.ReadFile("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	csvFilename := filepath.Join(dir, "btcusdt.csv")
+	require.NoError(t, os.WriteFile(csvFilename, csv, 0600))
+
+	want, err := ReadKlinesFromCSV(dir)
+	require.NoError(t, err)
+
+	// Full round trip of real data
+	fullFilename := filepath.Join(t.TempDir(), "full.klc")
+	require.NoError(t, WriteKlineCache(fullFilename, want, "binance"))
+	full, err := ReadKlineCache(fullFilename)
+	require.NoError(t, err)
+	assertKlinesEqual(t, want, full)
+	decoder, err := ReadKlineCacheDecoder(fullFilename)
+	require.NoError(t, err)
+	assert.Equal(t, "binance", decoder)
+
+	// Write a truncated cache so that it is distinguishable from the CSV
+	cacheFilename := KlineCacheFilename(dir)
+	require.NoError(t, WriteKlineCache(cacheFilename, want[:10], "binance"))
+	act, err := ReadKlinesFromCSV(dir)
+	require.NoError(t, err)
+	assertKlinesEqual(t, want[:10], act)
+
+	// Cache is only read when asked for with the same decoder
+	act, err = ReadKlinesFromCSVWithDecoder(dir, NewBinanceCSVKlineReader, "binance")
+	require.NoError(t, err)
+	assertKlinesEqual(t, want[:10], act)
+	act, err = ReadKlinesFromCSVWithDecoder(dir, NewBinanceCSVKlineReader, "")
+	require.NoError(t, err)
+	assert.Len(t, act, len(want))
+	act, err = ReadKlinesFromCSVWithDecoder(dir, NewBinanceCSVKlineReader, "metatrader")
+	require.NoError(t, err)
+	assert.Len(t, act, len(want))
+
+	// CSV modified after the cache so cache is stale
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(csvFilename, later, later))
+	act, err = ReadKlinesFromCSV(dir)
+	require.NoError(t, err)
+	assert.Len(t, act, len(want))
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"io"
+)
+
+var _ KlineSource = (SliceKlineSource)(nil)
+var _ KlineSource = (*CSVDirKlineSource)(nil)
+var _ KlineSource = (*SubKlineSource)(nil)
+
+// KlineReadCloser is a KlineReader that holds resources which must be released by calling Close.
+type KlineReadCloser interface {
+	KlineReader
+	io.Closer
+}
+
+// KlineSource opens a new independent stream of klines on each call to Open.
+// A source enables a price series to be replayed many times, for example by concurrent backtests,
+// without holding the full series in memory. Read returns io.EOF at the end of the stream.
+type KlineSource interface {
+	Open() (KlineReadCloser, error)
+}
+
+// SliceKlineSource is a KlineSource for klines already held in memory.
+type SliceKlineSource []Kline
+
+// Open returns a reader over the slice.
+func (s SliceKlineSource) Open() (KlineReadCloser, error) {
+	return &sliceKlineReader{klines: s}, nil
+}
+
+// CSVDirKlineSource is a KlineSource that streams klines from the .csv files in a directory (or a single file).
+type CSVDirKlineSource struct {
+	Path           string
+	Maker          MakeCSVKlineReader
+	SkipDuplicates bool
+}
+
+// NewCSVDirKlineSource creates a new source that uses the given reader factory to decode each file.
+func NewCSVDirKlineSource(path string, maker MakeCSVKlineReader) *CSVDirKlineSource {
+	return &CSVDirKlineSource{
+		Path:  path,
+		Maker: maker,
+	}
+}
+
+// Open returns a new CSVDirKlineReader.
+func (s *CSVDirKlineSource) Open() (KlineReadCloser, error) {
+	r, err := NewCSVDirKlineReader(s.Path, s.Maker)
+	if err != nil {
+		return nil, err
+	}
+	r.SkipDuplicates = s.SkipDuplicates
+	return r, nil
+}
+
+// SubKlineSource is a KlineSource that streams a contiguous range of another source.
+type SubKlineSource struct {
+	Source KlineSource
+	Offset int
+	Count  int
+}
+
+// NewSubKlineSource creates a source that skips the first offset klines of src and then streams up to count klines.
+// A negative count streams to the end of src.
+func NewSubKlineSource(src KlineSource, offset, count int) *SubKlineSource {
+	return &SubKlineSource{
+		Source: src,
+		Offset: offset,
+		Count:  count,
+	}
+}
+
+// Open opens the underlying source and skips to the offset.
+func (s *SubKlineSource) Open() (KlineReadCloser, error) {
+	r, err := s.Source.Open()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < s.Offset; i++ {
+		if _, err := r.Read(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			//nolint:errcheck // Already returning the read error
+			r.Close()
+			return nil, err
+		}
+	}
+	return &limitKlineReader{reader: r, remaining: s.Count}, nil
+}
+
+// CountKlines counts the klines in a source by streaming it once.
+func CountKlines(src KlineSource) (int, error) {
+	r, err := src.Open()
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck // Read ops only so safe to ignore err return
+	defer r.Close()
+
+	var n int
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		n++
+	}
+}
+
+// ReadN reads up to n klines from the reader. Fewer are returned without error if the reader reaches io.EOF.
+func ReadN(r KlineReader, n int) ([]Kline, error) {
+	klines := make([]Kline, 0, n)
+	for len(klines) < n {
+		k, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
+
+type sliceKlineReader struct {
+	klines []Kline
+	next   int
+}
+
+func (r *sliceKlineReader) Read() (Kline, error) {
+	if r.next >= len(r.klines) {
+		return Kline{}, io.EOF
+	}
+	k := r.klines[r.next]
+	r.next++
+	return k, nil
+}
+
+func (r *sliceKlineReader) ReadAll() ([]Kline, error) {
+	rest := r.klines[r.next:]
+	r.next = len(r.klines)
+	return rest, nil
+}
+
+func (r *sliceKlineReader) Close() error {
+	return nil
+}
+
+type limitKlineReader struct {
+	reader    KlineReadCloser
+	remaining int
+}
+
+func (r *limitKlineReader) Read() (Kline, error) {
+	if r.remaining == 0 {
+		return Kline{}, io.EOF
+	}
+	k, err := r.reader.Read()
+	if err != nil {
+		return k, err
+	}
+	if r.remaining > 0 {
+		r.remaining--
+	}
+	return k, nil
+}
+
+func (r *limitKlineReader) ReadAll() ([]Kline, error) {
+	var ks []Kline
+	for {
+		k, err := r.Read()
+		if err == io.EOF {
+			return ks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+}
+
+func (r *limitKlineReader) Close() error {
+	return r.reader.Close()
+}
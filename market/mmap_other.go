@@ -0,0 +1,17 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd
+
+package market
+
+import "os"
+
+// mapFile reads the whole file on platforms where memory mapping is not supported.
+func mapFile(filename string) (data []byte, unmap func() error, err error) {
+	data, err = os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,250 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+var _ MultiAssetReceiver = (AssetReceivers)(nil)
+
+// MissingPolicy is the action taken by MultiAssetFeed when an asset has no kline at a timestamp.
+type MissingPolicy int
+
+const (
+	// MissingSkip omits the asset from the timestamp.
+	MissingSkip MissingPolicy = iota
+
+	// MissingFill inserts a flat kline at the asset's previous close with zero volume, marked as Synthetic.
+	MissingFill
+
+	// MissingDrop drops the timestamp for all assets.
+	MissingDrop
+)
+
+// StartPolicy determines when MultiAssetFeed starts emitting if the assets start on different dates.
+type StartPolicy int
+
+const (
+	// StartEarliest emits from the first kline of any asset. Assets join the feed at their first kline.
+	StartEarliest StartPolicy = iota
+
+	// StartLatest emits from the first timestamp at which every asset has started.
+	StartLatest
+)
+
+// AssetKlines is the klines of several assets that start at the same time.
+type AssetKlines struct {
+	Start  time.Time
+	Klines map[Asset]Kline
+
+	// Filled is true for an asset whose kline was inserted by MissingFill rather than read.
+	Filled map[Asset]bool
+}
+
+// MultiAssetReceiver receives the time-aligned klines of several assets, for example a portfolio or spread bot.
+type MultiAssetReceiver interface {
+	ReceivePrices(context.Context, AssetKlines) error
+}
+
+// AssetReceivers is a MultiAssetReceiver that forwards the kline of each asset to the Receiver for that asset,
+// for example to a simulated dealer per asset. Assets without a Receiver are ignored.
+type AssetReceivers map[Asset]Receiver
+
+// ReceivePrices forwards each kline to the Receiver for its asset in symbol order.
+func (r AssetReceivers) ReceivePrices(ctx context.Context, prices AssetKlines) error {
+	for _, asset := range sortedAssets(prices.Klines) {
+		receiver, ok := r[asset]
+		if !ok {
+			continue
+		}
+		if err := receiver.ReceivePrice(ctx, prices.Klines[asset]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MultiAssetFeed merges the kline series of several assets into a single stream ordered by start time.
+// Each call to Read returns the klines of all assets that start at the next timestamp.
+//
+// An asset is active from its first to its last kline. A timestamp at which an active asset has no kline
+// is handled by MissingPolicy. Assets before their first kline or after their last are never filled,
+// so series with different date ranges are merged over their union unless StartPolicy says otherwise.
+// The klines of each asset must be in ascending order of start time.
+//
+// The feed is not used by package optimize or the studyrun app, which backtest each asset separately.
+// Call Play directly to backtest a multi-asset bot, as in the hodl.BasketBot tests.
+type MultiAssetFeed struct {
+	MissingPolicy MissingPolicy
+	StartPolicy   StartPolicy
+
+	assets  []Asset
+	readers map[Asset]KlineReader
+	heads   map[Asset]*Kline
+	last    map[Asset]Kline
+}
+
+// NewMultiAssetFeed creates a new feed with MissingSkip and StartEarliest policies.
+func NewMultiAssetFeed(readers map[Asset]KlineReader) *MultiAssetFeed {
+	assets := make([]Asset, 0, len(readers))
+	for asset := range readers {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+
+	return &MultiAssetFeed{
+		assets:  assets,
+		readers: readers,
+		last:    make(map[Asset]Kline, len(readers)),
+	}
+}
+
+// Assets returns the assets of the feed in symbol order.
+func (f *MultiAssetFeed) Assets() []Asset {
+	return f.assets
+}
+
+// Read returns the klines at the next timestamp. Returns io.EOF when all readers are exhausted.
+func (f *MultiAssetFeed) Read() (AssetKlines, error) {
+	if f.heads == nil {
+		f.heads = make(map[Asset]*Kline, len(f.assets))
+		for _, asset := range f.assets {
+			if err := f.advance(asset); err != nil {
+				return AssetKlines{}, err
+			}
+		}
+	}
+
+	for {
+		start, ok := f.nextStart()
+		if !ok {
+			return AssetKlines{}, io.EOF
+		}
+
+		prices := AssetKlines{
+			Start:  start,
+			Klines: make(map[Asset]Kline, len(f.assets)),
+		}
+		var missing []Asset
+		for _, asset := range f.assets {
+			head := f.heads[asset]
+			if head != nil && head.Start.Equal(start) {
+				prices.Klines[asset] = *head
+				f.last[asset] = *head
+				if err := f.advance(asset); err != nil {
+					return AssetKlines{}, err
+				}
+				continue
+			}
+			// Active assets have a previous kline and are not exhausted
+			if _, started := f.last[asset]; started && head != nil {
+				missing = append(missing, asset)
+			}
+		}
+
+		if f.StartPolicy == StartLatest && len(f.last) < len(f.assets) {
+			continue
+		}
+		if len(missing) > 0 {
+			switch f.MissingPolicy {
+			case MissingDrop:
+				continue
+			case MissingFill:
+				prices.Filled = make(map[Asset]bool, len(missing))
+				for _, asset := range missing {
+					c := f.last[asset].C
+					prices.Klines[asset] = Kline{Start: start, O: c, H: c, L: c, C: c, Synthetic: true}
+					prices.Filled[asset] = true
+				}
+			}
+		}
+		if len(prices.Klines) == 0 {
+			continue
+		}
+		return prices, nil
+	}
+}
+
+// ReadAll reads the remaining timestamps.
+func (f *MultiAssetFeed) ReadAll() ([]AssetKlines, error) {
+	var all []AssetKlines
+	for {
+		prices, err := f.Read()
+		if err == io.EOF {
+			return all, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, prices)
+	}
+}
+
+// Play reads the feed to the end and gives each timestamp to the receivers in order.
+// To backtest, pass the dealers (as AssetReceivers) before the bot so that orders are matched
+// against the price before the bot evaluates it, as in a single asset backtest.
+func (f *MultiAssetFeed) Play(ctx context.Context, receivers ...MultiAssetReceiver) error {
+	for {
+		prices, err := f.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, receiver := range receivers {
+			if err := receiver.ReceivePrices(ctx, prices); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// advance reads the next kline of the asset into its head, or sets the head to nil at io.EOF.
+func (f *MultiAssetFeed) advance(asset Asset) error {
+	k, err := f.readers[asset].Read()
+	if err == io.EOF {
+		f.heads[asset] = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if prev, ok := f.last[asset]; ok && !k.Start.After(prev.Start) {
+		return fmt.Errorf("%w: %s kline at %s is not after %s", ErrDataQuality, asset.Symbol, k.Start, prev.Start)
+	}
+	f.heads[asset] = &k
+	return nil
+}
+
+// nextStart returns the earliest start time of the pending klines.
+func (f *MultiAssetFeed) nextStart() (time.Time, bool) {
+	var start time.Time
+	var ok bool
+	for _, asset := range f.assets {
+		head := f.heads[asset]
+		if head == nil {
+			continue
+		}
+		if !ok || head.Start.Before(start) {
+			start = head.Start
+			ok = true
+		}
+	}
+	return start, ok
+}
+
+func sortedAssets(klines map[Asset]Kline) []Asset {
+	assets := make([]Asset, 0, len(klines))
+	for asset := range klines {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+	return assets
+}
@@ -0,0 +1,151 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func klinesForMultiAssetFeedTest(start time.Time, hours ...int) []Kline {
+	var klines []Kline
+	for _, h := range hours {
+		c := dec.New(float64(h))
+		klines = append(klines, Kline{Start: start.Add(time.Duration(h) * time.Hour), O: c, H: c, L: c, C: c, Volume: 1})
+	}
+	return klines
+}
+
+func TestMultiAssetFeed_Read(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	btc, eth := NewAsset("BTCUSD"), NewAsset("ETHUSD")
+
+	// BTC from 0 to 4 with 2 missing, ETH from 1 to 3
+	giveBTC := []int{0, 1, 3, 4}
+	giveETH := []int{1, 2, 3}
+
+	tests := []struct {
+		name        string
+		giveMissing MissingPolicy
+		giveStart   StartPolicy
+		want        map[Asset][]int
+		wantFilled  map[Asset][]int
+	}{
+		{
+			name:        "skip missing from earliest",
+			giveMissing: MissingSkip,
+			giveStart:   StartEarliest,
+			want:        map[Asset][]int{btc: {0, 1, 3, 4}, eth: {1, 2, 3}},
+		},
+		{
+			name:        "fill missing from earliest",
+			giveMissing: MissingFill,
+			giveStart:   StartEarliest,
+			want:        map[Asset][]int{btc: {0, 1, 1, 3, 4}, eth: {1, 2, 3}},
+			wantFilled:  map[Asset][]int{btc: {2}},
+		},
+		{
+			name:        "drop missing from earliest",
+			giveMissing: MissingDrop,
+			giveStart:   StartEarliest,
+			want:        map[Asset][]int{btc: {0, 1, 3, 4}, eth: {1, 3}},
+		},
+		{
+			name:        "skip missing from latest",
+			giveMissing: MissingSkip,
+			giveStart:   StartLatest,
+			want:        map[Asset][]int{btc: {1, 3, 4}, eth: {1, 2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feed := NewMultiAssetFeed(map[Asset]KlineReader{
+				btc: &sliceKlineReader{klines: klinesForMultiAssetFeedTest(start, giveBTC...)},
+				eth: &sliceKlineReader{klines: klinesForMultiAssetFeedTest(start, giveETH...)},
+			})
+			feed.MissingPolicy = tt.giveMissing
+			feed.StartPolicy = tt.giveStart
+
+			all, err := feed.ReadAll()
+			require.NoError(t, err)
+
+			act := make(map[Asset][]int)
+			actFilled := make(map[Asset][]int)
+			var prev time.Time
+			for _, prices := range all {
+				assert.True(t, prices.Start.After(prev))
+				prev = prices.Start
+				for _, asset := range feed.Assets() {
+					k, ok := prices.Klines[asset]
+					if !ok {
+						continue
+					}
+					assert.Equal(t, prices.Start, k.Start)
+					act[asset] = append(act[asset], int(k.C.IntPart()))
+					assert.Equal(t, prices.Filled[asset], k.Synthetic)
+					if prices.Filled[asset] {
+						assert.Zero(t, k.Volume)
+						actFilled[asset] = append(actFilled[asset], int(prices.Start.Sub(start).Hours()))
+					}
+				}
+			}
+			assert.Equal(t, tt.want, act)
+			if tt.wantFilled == nil {
+				tt.wantFilled = map[Asset][]int{}
+			}
+			assert.Equal(t, tt.wantFilled, actFilled)
+		})
+	}
+}
+
+func TestMultiAssetFeed_ReadOutOfOrder(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	feed := NewMultiAssetFeed(map[Asset]KlineReader{
+		NewAsset("BTCUSD"): &sliceKlineReader{klines: klinesForMultiAssetFeedTest(start, 0, 2, 1)},
+	})
+	_, err := feed.ReadAll()
+	assert.ErrorIs(t, err, ErrDataQuality)
+}
+
+type receiverForMultiAssetFeedTest struct {
+	klines []Kline
+	prices []AssetKlines
+}
+
+func (r *receiverForMultiAssetFeedTest) ReceivePrice(ctx context.Context, k Kline) error {
+	r.klines = append(r.klines, k)
+	return nil
+}
+
+func (r *receiverForMultiAssetFeedTest) ReceivePrices(ctx context.Context, prices AssetKlines) error {
+	r.prices = append(r.prices, prices)
+	return nil
+}
+
+func TestMultiAssetFeed_Play(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	btc, eth := NewAsset("BTCUSD"), NewAsset("ETHUSD")
+	btcKlines := klinesForMultiAssetFeedTest(start, 0, 1, 2)
+	ethKlines := klinesForMultiAssetFeedTest(start, 1, 2)
+
+	feed := NewMultiAssetFeed(map[Asset]KlineReader{
+		btc: &sliceKlineReader{klines: btcKlines},
+		eth: &sliceKlineReader{klines: ethKlines},
+	})
+	var btcDealer, ethDealer, bot receiverForMultiAssetFeedTest
+	dealers := AssetReceivers{btc: &btcDealer, eth: &ethDealer}
+	require.NoError(t, feed.Play(context.Background(), dealers, &bot))
+
+	assert.Equal(t, btcKlines, btcDealer.klines)
+	assert.Equal(t, ethKlines, ethDealer.klines)
+	require.Len(t, bot.prices, 3)
+	assert.Len(t, bot.prices[0].Klines, 1)
+	assert.Len(t, bot.prices[1].Klines, 2)
+	assert.Len(t, bot.prices[2].Klines, 2)
+}
@@ -0,0 +1,489 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/big"
+	"path/filepath"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetExt is the file extension of a Parquet file.
+const ParquetExt = ".parquet"
+
+const _parquetBatchSize = 4096
+
+var _ KlineReadCloser = (*ParquetKlineReader)(nil)
+
+var (
+	// ErrParquetColumnNotFound is returned when a mapped column is not present in a Parquet file.
+	ErrParquetColumnNotFound = errors.New("parquet column not found")
+
+	// ErrParquetColumnType is returned when a mapped column has a physical type that cannot be decoded into a Kline field.
+	ErrParquetColumnType = errors.New("unsupported parquet column type")
+
+	// ErrParquetNullValue is returned when a mapped column contains a null value.
+	ErrParquetNullValue = errors.New("parquet column contains null value")
+)
+
+// ParquetColumns maps the fields of a Kline to the columns of a Parquet file.
+type ParquetColumns struct {
+	Start  string
+	Open   string
+	High   string
+	Low    string
+	Close  string
+	Volume string // Optional: leave empty if the file has no volume column
+
+	// Order flow columns are optional and ignored if the file has no such column.
+	QuoteVolume         string
+	TradeCount          string
+	TakerBuyVolume      string
+	TakerBuyQuoteVolume string
+
+	// Gap flag columns are optional BOOLEAN columns and ignored if the file has no such column.
+	Synthetic string
+	AfterGap  string
+
+	// TimeUnit is the unit of an integer start column that is not annotated as a timestamp.
+	// Zero defaults to milliseconds.
+	TimeUnit time.Duration
+}
+
+// DefaultParquetColumns returns the column mapping used by ParquetKlineWriter.
+func DefaultParquetColumns() ParquetColumns {
+	return ParquetColumns{
+		Start:  "start",
+		Open:   "open",
+		High:   "high",
+		Low:    "low",
+		Close:  "close",
+		Volume: "volume",
+
+		QuoteVolume:         "quote_volume",
+		TradeCount:          "trade_count",
+		TakerBuyVolume:      "taker_buy_volume",
+		TakerBuyQuoteVolume: "taker_buy_quote_volume",
+
+		Synthetic: "synthetic",
+		AfterGap:  "after_gap",
+
+		TimeUnit: time.Millisecond,
+	}
+}
+
+// ParquetKlineReader is a KlineReader that reads from a Parquet file.
+// Rows are decoded in batches so that memory use is bounded by the batch size rather than the file size.
+//
+// Supported column types:
+//
+// - Start: INT64 timestamp (millis, micros or nanos), INT96 timestamp, or an unannotated INT32/INT64 in ParquetColumns.TimeUnit
+//
+// - Prices: DOUBLE, FLOAT, INT32/INT64 (optionally DECIMAL), DECIMAL as BYTE_ARRAY or FIXED_LEN_BYTE_ARRAY, or a UTF8 decimal string
+//
+// - Volume and order flow: DOUBLE, FLOAT, INT32/INT64 (optionally DECIMAL) or a UTF8 decimal string
+//
+// - Synthetic and AfterGap: BOOLEAN
+type ParquetKlineReader struct {
+	file    source.ParquetFile
+	parquet *reader.ParquetReader
+	columns []parquetColumn
+
+	remaining int64
+	batch     []Kline
+	next      int
+}
+
+// NewParquetKlineReader creates a new ParquetKlineReader for the named file.
+func NewParquetKlineReader(filename string, columns ParquetColumns) (*ParquetKlineReader, error) {
+	file, err := local.NewLocalFileReader(filename)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewParquetKlineReaderFromFile(file, columns)
+	if err != nil {
+		//nolint:errcheck // Already returning the open error
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewParquetKlineReaderFromFile creates a new ParquetKlineReader for any Parquet file source, for example an in-memory buffer.
+func NewParquetKlineReaderFromFile(file source.ParquetFile, columns ParquetColumns) (*ParquetKlineReader, error) {
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		return nil, err
+	}
+	r := &ParquetKlineReader{
+		file:      file,
+		parquet:   pr,
+		remaining: pr.GetNumRows(),
+	}
+
+	unit := columns.TimeUnit
+	if unit == 0 {
+		unit = time.Millisecond
+	}
+	fields := []struct {
+		name     string
+		optional bool
+		flow     bool
+		decode   parquetDecoder
+	}{
+		{columns.Start, false, false, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.Start, err = decodeParquetTime(v, se, unit)
+			return err
+		}},
+		{columns.Open, false, false, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.O, err = decodeParquetDecimal(v, se)
+			return err
+		}},
+		{columns.High, false, false, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.H, err = decodeParquetDecimal(v, se)
+			return err
+		}},
+		{columns.Low, false, false, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.L, err = decodeParquetDecimal(v, se)
+			return err
+		}},
+		{columns.Close, false, false, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.C, err = decodeParquetDecimal(v, se)
+			return err
+		}},
+		{columns.Volume, true, false, func(k *Kline, v any, se *parquet.SchemaElement) error {
+			d, err := decodeParquetDecimal(v, se)
+			k.Volume = d.InexactFloat64()
+			return err
+		}},
+		{columns.QuoteVolume, true, true, func(k *Kline, v any, se *parquet.SchemaElement) error {
+			d, err := decodeParquetDecimal(v, se)
+			k.QuoteVolume = d.InexactFloat64()
+			return err
+		}},
+		{columns.TradeCount, true, true, func(k *Kline, v any, se *parquet.SchemaElement) error {
+			d, err := decodeParquetDecimal(v, se)
+			k.TradeCount = d.IntPart()
+			return err
+		}},
+		{columns.TakerBuyVolume, true, true, func(k *Kline, v any, se *parquet.SchemaElement) error {
+			d, err := decodeParquetDecimal(v, se)
+			k.TakerBuyVolume = d.InexactFloat64()
+			return err
+		}},
+		{columns.TakerBuyQuoteVolume, true, true, func(k *Kline, v any, se *parquet.SchemaElement) error {
+			d, err := decodeParquetDecimal(v, se)
+			k.TakerBuyQuoteVolume = d.InexactFloat64()
+			return err
+		}},
+		{columns.Synthetic, true, true, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.Synthetic, err = decodeParquetBool(v, se)
+			return err
+		}},
+		{columns.AfterGap, true, true, func(k *Kline, v any, se *parquet.SchemaElement) (err error) {
+			k.AfterGap, err = decodeParquetBool(v, se)
+			return err
+		}},
+	}
+	for _, field := range fields {
+		if field.name == "" && field.optional {
+			continue
+		}
+		path := common.PathToStr([]string{pr.SchemaHandler.GetRootExName(), field.name})
+		inPath, err := pr.SchemaHandler.ConvertToInPathStr(path)
+		if err != nil && field.flow {
+			continue
+		}
+		if err != nil {
+			r.parquet.ReadStop()
+			return nil, fmt.Errorf("%w: '%s'", ErrParquetColumnNotFound, field.name)
+		}
+		se := pr.SchemaHandler.SchemaElements[pr.SchemaHandler.MapIndex[inPath]]
+		r.columns = append(r.columns, parquetColumn{name: field.name, path: path, schema: se, decode: field.decode})
+	}
+
+	return r, nil
+}
+
+// Read reads the next Kline from the file, or returns io.EOF at the end of the file.
+func (r *ParquetKlineReader) Read() (Kline, error) {
+	if r.next >= len(r.batch) {
+		if err := r.readBatch(); err != nil {
+			return Kline{}, err
+		}
+	}
+	k := r.batch[r.next]
+	r.next++
+	return k, nil
+}
+
+// ReadAll reads all the remaining Klines from the file.
+func (r *ParquetKlineReader) ReadAll() ([]Kline, error) {
+	ks := make([]Kline, 0, int64(len(r.batch)-r.next)+r.remaining)
+	for {
+		k, err := r.Read()
+		if err == io.EOF {
+			return ks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ks = append(ks, k)
+	}
+}
+
+// Close closes the file.
+func (r *ParquetKlineReader) Close() error {
+	r.parquet.ReadStop()
+	return r.file.Close()
+}
+
+func (r *ParquetKlineReader) readBatch() error {
+	if r.remaining <= 0 {
+		return io.EOF
+	}
+	n := int64(_parquetBatchSize)
+	if r.remaining < n {
+		n = r.remaining
+	}
+	r.remaining -= n
+
+	r.batch = r.batch[:0]
+	for i := int64(0); i < n; i++ {
+		r.batch = append(r.batch, Kline{})
+	}
+	r.next = 0
+
+	for _, col := range r.columns {
+		values, _, _, err := r.parquet.ReadColumnByPath(col.path, n)
+		if err != nil {
+			return err
+		}
+		if int64(len(values)) != n {
+			return fmt.Errorf("%w: '%s' has %d values, expected %d", ErrParquetColumnType, col.name, len(values), n)
+		}
+		for i, v := range values {
+			if v == nil {
+				return fmt.Errorf("%w: '%s'", ErrParquetNullValue, col.name)
+			}
+			if err := col.decode(&r.batch[i], v, col.schema); err != nil {
+				return fmt.Errorf("column '%s': %w", col.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadKlinesFromParquet reads all the .parquet files in a given directory or a single file into a slice of Klines.
+// Directories are walked in lexical order, so data partitioned by date (e.g. "symbol=BTCUSDT/date=2022-01-01/")
+// is read in chronological order.
+func ReadKlinesFromParquet(path string, columns ParquetColumns) ([]Kline, error) {
+	var prices []Kline
+
+	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ParquetExt {
+			return nil
+		}
+		reader, err := NewParquetKlineReader(path, columns)
+		if err != nil {
+			return err
+		}
+		//nolint:errcheck // Read ops only so safe to ignore err return
+		defer reader.Close()
+		klines, err := reader.ReadAll()
+		if err != nil {
+			return err
+		}
+		prices = append(prices, klines...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prices, nil
+}
+
+// ParquetKlineWriter writes klines to a Parquet file with the columns of DefaultParquetColumns.
+// Start is written as an INT64 millisecond timestamp, prices as UTF8 decimal strings so that they round trip exactly,
+// volume and order flow as DOUBLE, and the Synthetic and AfterGap flags as BOOLEAN.
+type ParquetKlineWriter struct {
+	parquet *writer.ParquetWriter
+}
+
+// NewParquetKlineWriter creates a new ParquetKlineWriter. Call Close to write the file footer.
+func NewParquetKlineWriter(w io.Writer) (*ParquetKlineWriter, error) {
+	pw, err := writer.NewParquetWriter(writerfile.NewWriterFile(w), new(parquetKline), 1)
+	if err != nil {
+		return nil, err
+	}
+	return &ParquetKlineWriter{parquet: pw}, nil
+}
+
+// Write writes a single kline.
+func (w *ParquetKlineWriter) Write(k Kline) error {
+	return w.parquet.Write(parquetKline{
+		Start:  k.Start.UnixMilli(),
+		Open:   k.O.String(),
+		High:   k.H.String(),
+		Low:    k.L.String(),
+		Close:  k.C.String(),
+		Volume: k.Volume,
+
+		QuoteVolume:         k.QuoteVolume,
+		TradeCount:          k.TradeCount,
+		TakerBuyVolume:      k.TakerBuyVolume,
+		TakerBuyQuoteVolume: k.TakerBuyQuoteVolume,
+
+		Synthetic: k.Synthetic,
+		AfterGap:  k.AfterGap,
+	})
+}
+
+// Close flushes buffered rows and writes the file footer. It does not close the underlying writer.
+func (w *ParquetKlineWriter) Close() error {
+	return w.parquet.WriteStop()
+}
+
+// WriteKlinesToParquet writes klines to a Parquet file.
+func WriteKlinesToParquet(filename string, klines []Kline) error {
+	file, err := local.NewLocalFileWriter(filename)
+	if err != nil {
+		return err
+	}
+	if err := writeKlinesToParquet(file, klines); err != nil {
+		//nolint:errcheck // Already returning the write error
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func writeKlinesToParquet(w io.Writer, klines []Kline) error {
+	pw, err := NewParquetKlineWriter(w)
+	if err != nil {
+		return err
+	}
+	for i := range klines {
+		if err := pw.Write(klines[i]); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+type parquetKline struct {
+	Start  int64   `parquet:"name=start, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Open   string  `parquet:"name=open, type=BYTE_ARRAY, convertedtype=UTF8"`
+	High   string  `parquet:"name=high, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Low    string  `parquet:"name=low, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Close  string  `parquet:"name=close, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Volume float64 `parquet:"name=volume, type=DOUBLE"`
+
+	QuoteVolume         float64 `parquet:"name=quote_volume, type=DOUBLE"`
+	TradeCount          int64   `parquet:"name=trade_count, type=INT64"`
+	TakerBuyVolume      float64 `parquet:"name=taker_buy_volume, type=DOUBLE"`
+	TakerBuyQuoteVolume float64 `parquet:"name=taker_buy_quote_volume, type=DOUBLE"`
+
+	Synthetic bool `parquet:"name=synthetic, type=BOOLEAN"`
+	AfterGap  bool `parquet:"name=after_gap, type=BOOLEAN"`
+}
+
+type parquetDecoder func(k *Kline, v any, se *parquet.SchemaElement) error
+
+type parquetColumn struct {
+	name   string
+	path   string
+	schema *parquet.SchemaElement
+	decode parquetDecoder
+}
+
+func decodeParquetTime(v any, se *parquet.SchemaElement, unit time.Duration) (time.Time, error) {
+	switch v := v.(type) {
+	case int32:
+		return time.Unix(0, int64(v)*int64(unit)).UTC(), nil
+	case int64:
+		if lt := se.GetLogicalType(); lt != nil && lt.IsSetTIMESTAMP() {
+			switch u := lt.GetTIMESTAMP().GetUnit(); {
+			case u.IsSetMICROS():
+				unit = time.Microsecond
+			case u.IsSetNANOS():
+				unit = time.Nanosecond
+			default:
+				unit = time.Millisecond
+			}
+		} else if se.ConvertedType != nil {
+			switch se.GetConvertedType() {
+			case parquet.ConvertedType_TIMESTAMP_MILLIS:
+				unit = time.Millisecond
+			case parquet.ConvertedType_TIMESTAMP_MICROS:
+				unit = time.Microsecond
+			}
+		}
+		return time.Unix(0, v*int64(unit)).UTC(), nil
+	case string:
+		if se.GetType() != parquet.Type_INT96 || len(v) != 12 {
+			return time.Time{}, ErrInvalidTimeFormat
+		}
+		// INT96 is 8 bytes of nanoseconds within the day followed by 4 bytes of Julian day
+		const julianUnixEpoch = 2440588
+		nanos := int64(binary.LittleEndian.Uint64([]byte(v[:8])))
+		days := int64(binary.LittleEndian.Uint32([]byte(v[8:]))) - julianUnixEpoch
+		return time.Unix(days*24*60*60, nanos).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: %s", ErrParquetColumnType, se.GetType())
+}
+
+func decodeParquetDecimal(v any, se *parquet.SchemaElement) (decimal.Decimal, error) {
+	isDecimal := se.ConvertedType != nil && se.GetConvertedType() == parquet.ConvertedType_DECIMAL
+	switch v := v.(type) {
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case float32:
+		return decimal.NewFromFloat32(v), nil
+	case int32:
+		return decimal.New(int64(v), -se.GetScale()), nil
+	case int64:
+		return decimal.New(v, -se.GetScale()), nil
+	case string:
+		if isDecimal {
+			// Big-endian two's complement unscaled value
+			unscaled := new(big.Int).SetBytes([]byte(v))
+			if len(v) > 0 && v[0]&0x80 != 0 {
+				unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(v)*8)))
+			}
+			return decimal.NewFromBigInt(unscaled, -se.GetScale()), nil
+		}
+		d, err := decimal.NewFromString(v)
+		if err != nil {
+			return d, ErrInvalidPriceFormat
+		}
+		return d, nil
+	}
+	return decimal.Zero, fmt.Errorf("%w: %s", ErrParquetColumnType, se.GetType())
+}
+
+func decodeParquetBool(v any, se *parquet.SchemaElement) (bool, error) {
+	if v, ok := v.(bool); ok {
+		return v, nil
+	}
+	return false, fmt.Errorf("%w: %s", ErrParquetColumnType, se.GetType())
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go-source/writerfile"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+func TestParquetKlineWriter_RoundTrip(t *testing.T) {
+	want, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	// Repeat the series to span more than one read batch
+	want = append(append(want, want...), want...)
+	want[1].Synthetic = true
+	want[2].AfterGap = true
+
+	var buf bytes.Buffer
+	require.NoError(t, writeKlinesToParquet(&buf, want))
+
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	require.NoError(t, err)
+	reader, err := NewParquetKlineReaderFromFile(file, DefaultParquetColumns())
+	require.NoError(t, err)
+	act, err := reader.ReadAll()
+	require.NoError(t, err)
+	assert.NoError(t, reader.Close())
+	assertKlinesEqual(t, want, act)
+}
+
+func TestParquetKlineReader_ColumnMapping(t *testing.T) {
+	type row struct {
+		Time  int64   `parquet:"name=ts, type=INT64, convertedtype=TIMESTAMP_MICROS"`
+		Open  int64   `parquet:"name=px_open, type=INT64, convertedtype=DECIMAL, scale=2, precision=18"`
+		High  string  `parquet:"name=px_high, type=BYTE_ARRAY, convertedtype=UTF8"`
+		Low   float32 `parquet:"name=px_low, type=FLOAT"`
+		Close float64 `parquet:"name=px_close, type=DOUBLE"`
+		Qty   int32   `parquet:"name=qty, type=INT32"`
+	}
+	start := time.Date(2022, time.March, 1, 9, 30, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	pw, err := writer.NewParquetWriter(writerfile.NewWriterFile(&buf), new(row), 1)
+	require.NoError(t, err)
+	require.NoError(t, pw.Write(row{Time: start.UnixMicro(), Open: 10050, High: "101.25", Low: 99.5, Close: 100.75, Qty: 42}))
+	require.NoError(t, pw.WriteStop())
+
+	columns := ParquetColumns{Start: "ts", Open: "px_open", High: "px_high", Low: "px_low", Close: "px_close", Volume: "qty"}
+	file, err := buffer.NewBufferFile(buf.Bytes())
+	require.NoError(t, err)
+	reader, err := NewParquetKlineReaderFromFile(file, columns)
+	require.NoError(t, err)
+	act, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	assertKlinesEqual(t, []Kline{
+		{Start: start, O: dec.New(100.5), H: dec.New(101.25), L: dec.New(99.5), C: dec.New(100.75), Volume: 42},
+	}, act)
+
+	columns.Close = "missing"
+	_, err = NewParquetKlineReaderFromFile(file, columns)
+	assert.ErrorIs(t, err, ErrParquetColumnNotFound)
+}
+
+func TestReadKlinesFromParquet(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var want []Kline
+	for d := 0; d < 2; d++ {
+		var klines []Kline
+		for h := 0; h < 24; h++ {
+			start := day.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour)
+			klines = append(klines, Kline{Start: start, O: dec.New(1), H: dec.New(2), L: dec.New(0.5), C: dec.New(1.5), Volume: float64(h)})
+		}
+		partition := filepath.Join(dir, "symbol=BTCUSDT", "date="+day.AddDate(0, 0, d).Format("2006-01-02"))
+		require.NoError(t, os.MkdirAll(partition, 0700))
+		require.NoError(t, WriteKlinesToParquet(filepath.Join(partition, "part-0.parquet"), klines))
+		want = append(want, klines...)
+	}
+
+	act, err := ReadKlinesFromParquet(filepath.Join(dir, "symbol=BTCUSDT"), DefaultParquetColumns())
+	require.NoError(t, err)
+	assertKlinesEqual(t, want, act)
+}
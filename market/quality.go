@@ -0,0 +1,392 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrDataQuality is returned by Clean when an anomaly is found for which the repair policy is RepairFail.
+var ErrDataQuality = errors.New("price data failed quality check")
+
+// ErrInvalidRepair is returned by Clean when a repair is not applicable to the anomaly kind.
+var ErrInvalidRepair = errors.New("repair not applicable to anomaly")
+
+// AnomalyKind is a kind of price data defect.
+type AnomalyKind int
+
+const (
+	// OutOfOrder is a kline that starts before a kline preceding it.
+	OutOfOrder AnomalyKind = iota + 1
+
+	// Duplicate is a kline with the same start time as a kline preceding it,
+	// typically due to overlapping source files.
+	Duplicate
+
+	// Gap is one or more missing klines. Reported at the first kline after the gap.
+	Gap
+
+	// ZeroVolume is a kline with zero volume.
+	ZeroVolume
+
+	// InvalidRange is a kline with a non-positive price, high less than low,
+	// or an open or close outside the high-low range.
+	InvalidRange
+
+	// Misaligned is a kline that does not start on a multiple of the interval, e.g. 10:00:03 for hourly klines.
+	// Only detected for intervals that divide a day, which are aligned to midnight UTC.
+	Misaligned
+)
+
+// AnomalyKinds is the list of all anomaly kinds in reporting order.
+var AnomalyKinds = []AnomalyKind{OutOfOrder, Duplicate, Gap, ZeroVolume, InvalidRange, Misaligned}
+
+func (k AnomalyKind) String() string {
+	return [...]string{"None", "OutOfOrder", "Duplicate", "Gap", "ZeroVolume", "InvalidRange", "Misaligned"}[k]
+}
+
+// Anomaly is a single price data defect.
+type Anomaly struct {
+	Kind   AnomalyKind
+	Start  time.Time
+	Index  int
+	Detail string
+}
+
+// QualityReport lists the anomalies found in a series of klines.
+type QualityReport struct {
+	KlineCount int
+	Interval   time.Duration
+	Anomalies  []Anomaly
+}
+
+// Count returns the number of anomalies of the given kind.
+func (r QualityReport) Count(kind AnomalyKind) int {
+	var n int
+	for _, a := range r.Anomalies {
+		if a.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// OK returns true if no anomalies were found.
+func (r QualityReport) OK() bool {
+	return len(r.Anomalies) == 0
+}
+
+// Repair is the action taken by Clean for an anomaly kind.
+type Repair int
+
+const (
+	// RepairIgnore leaves the anomaly in place.
+	RepairIgnore Repair = iota
+
+	// RepairFail returns ErrDataQuality.
+	RepairFail
+
+	// RepairSort sorts klines by start time. Applies to OutOfOrder.
+	RepairSort
+
+	// RepairDedupe keeps the first kline for each start time. Applies to Duplicate.
+	RepairDedupe
+
+	// RepairDrop removes the kline. Applies to OutOfOrder, ZeroVolume and InvalidRange.
+	RepairDrop
+
+	// RepairFill inserts flat klines at the previous close with zero volume, marked as synthetic. Applies to Gap.
+	RepairFill
+
+	// RepairFlag leaves the gap in place and marks the kline after it with AfterGap. Applies to Gap.
+	RepairFlag
+
+	// RepairAlign truncates the start of the kline to the interval. Applies to Misaligned.
+	RepairAlign
+)
+
+var _repairNames = [...]string{"ignore", "fail", "sort", "dedupe", "drop", "fill", "flag", "align"}
+
+func (r Repair) String() string {
+	return _repairNames[r]
+}
+
+// ParseRepair parses the lowercase name of a repair, such as "dedupe" or "fill".
+func ParseRepair(name string) (Repair, error) {
+	for i := range _repairNames {
+		if _repairNames[i] == name {
+			return Repair(i), nil
+		}
+	}
+	return RepairIgnore, fmt.Errorf("%w: unknown repair '%s'", ErrInvalidRepair, name)
+}
+
+// CleanPolicy defines the repair for each anomaly kind.
+type CleanPolicy struct {
+	OutOfOrder   Repair
+	Duplicate    Repair
+	Gap          Repair
+	ZeroVolume   Repair
+	InvalidRange Repair
+	Misaligned   Repair
+}
+
+// DefaultCleanPolicy sorts and dedupes, which is required when combining overlapping files,
+// and ignores all other anomalies.
+func DefaultCleanPolicy() CleanPolicy {
+	return CleanPolicy{
+		OutOfOrder: RepairSort,
+		Duplicate:  RepairDedupe,
+	}
+}
+
+// Validate checks a series of klines for anomalies.
+// Interval is the expected duration between klines, used to detect gaps.
+// If interval is zero the most common duration between klines is used.
+func Validate(klines []Kline, interval time.Duration) QualityReport {
+	report := QualityReport{
+		KlineCount: len(klines),
+		Interval:   interval,
+	}
+	if report.Interval == 0 {
+		report.Interval = inferInterval(klines)
+	}
+
+	seen := make(map[int64]struct{}, len(klines))
+	var latest time.Time
+	for i, k := range klines {
+		key := k.Start.UnixNano()
+		if _, ok := seen[key]; ok {
+			report.Anomalies = append(report.Anomalies, Anomaly{Kind: Duplicate, Start: k.Start, Index: i})
+		} else if i > 0 && k.Start.Before(latest) {
+			report.Anomalies = append(report.Anomalies, Anomaly{Kind: OutOfOrder, Start: k.Start, Index: i,
+				Detail: fmt.Sprintf("after %s", latest)})
+		}
+		seen[key] = struct{}{}
+		if k.Start.After(latest) {
+			latest = k.Start
+		}
+
+		if k.Volume == 0 {
+			report.Anomalies = append(report.Anomalies, Anomaly{Kind: ZeroVolume, Start: k.Start, Index: i})
+		}
+		if detail := invalidRange(k); detail != "" {
+			report.Anomalies = append(report.Anomalies, Anomaly{Kind: InvalidRange, Start: k.Start, Index: i, Detail: detail})
+		}
+		if aligned := alignStart(k.Start, report.Interval); !aligned.Equal(k.Start) {
+			report.Anomalies = append(report.Anomalies, Anomaly{Kind: Misaligned, Start: k.Start, Index: i,
+				Detail: fmt.Sprintf("expected %s", aligned)})
+		}
+	}
+
+	// Gaps are detected on the sorted unique start times so that they are independent of ordering defects
+	if report.Interval > 0 {
+		starts := uniqueSortedStarts(klines)
+		for i := 1; i < len(starts); i++ {
+			if missing := int(starts[i].Sub(starts[i-1])/report.Interval) - 1; missing > 0 {
+				report.Anomalies = append(report.Anomalies, Anomaly{Kind: Gap, Start: starts[i], Index: -1,
+					Detail: fmt.Sprintf("%d missing after %s", missing, starts[i-1])})
+			}
+		}
+	}
+
+	return report
+}
+
+// Clean validates the klines and then repairs them according to the policy.
+// The returned report describes the anomalies in the input, before repair.
+// Repairs are applied in order: align, sort, dedupe, drop, then fill or flag.
+func Clean(klines []Kline, interval time.Duration, policy CleanPolicy) ([]Kline, QualityReport, error) {
+	report := Validate(klines, interval)
+
+	for _, a := range report.Anomalies {
+		if policy.repair(a.Kind) == RepairFail {
+			return nil, report, fmt.Errorf("%w: %s at %s %s", ErrDataQuality, a.Kind, a.Start, a.Detail)
+		}
+	}
+	if err := policy.validate(); err != nil {
+		return nil, report, err
+	}
+
+	cleaned := make([]Kline, 0, len(klines))
+	var latest time.Time
+	for _, k := range klines {
+		if policy.Misaligned == RepairAlign {
+			k.Start = alignStart(k.Start, report.Interval)
+		}
+		if policy.OutOfOrder == RepairDrop && k.Start.Before(latest) {
+			continue
+		}
+		if k.Start.After(latest) {
+			latest = k.Start
+		}
+		cleaned = append(cleaned, k)
+	}
+
+	if policy.OutOfOrder == RepairSort {
+		sort.SliceStable(cleaned, func(i, j int) bool { return cleaned[i].Start.Before(cleaned[j].Start) })
+	}
+
+	if policy.Duplicate == RepairDedupe {
+		seen := make(map[int64]struct{}, len(cleaned))
+		deduped := cleaned[:0]
+		for _, k := range cleaned {
+			key := k.Start.UnixNano()
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			deduped = append(deduped, k)
+		}
+		cleaned = deduped
+	}
+
+	if policy.ZeroVolume == RepairDrop || policy.InvalidRange == RepairDrop {
+		kept := cleaned[:0]
+		for _, k := range cleaned {
+			if policy.ZeroVolume == RepairDrop && k.Volume == 0 {
+				continue
+			}
+			if policy.InvalidRange == RepairDrop && invalidRange(k) != "" {
+				continue
+			}
+			kept = append(kept, k)
+		}
+		cleaned = kept
+	}
+
+	if policy.Gap == RepairFill && report.Interval > 0 {
+		cleaned = fillGaps(cleaned, report.Interval)
+	}
+	if policy.Gap == RepairFlag && report.Interval > 0 {
+		flagGaps(cleaned, report.Interval)
+	}
+
+	return cleaned, report, nil
+}
+
+func (p CleanPolicy) repair(kind AnomalyKind) Repair {
+	switch kind {
+	case OutOfOrder:
+		return p.OutOfOrder
+	case Duplicate:
+		return p.Duplicate
+	case Gap:
+		return p.Gap
+	case ZeroVolume:
+		return p.ZeroVolume
+	case InvalidRange:
+		return p.InvalidRange
+	case Misaligned:
+		return p.Misaligned
+	}
+	return RepairIgnore
+}
+
+func (p CleanPolicy) validate() error {
+	valid := map[AnomalyKind][]Repair{
+		OutOfOrder:   {RepairSort, RepairDrop},
+		Duplicate:    {RepairDedupe},
+		Gap:          {RepairFill, RepairFlag},
+		ZeroVolume:   {RepairDrop},
+		InvalidRange: {RepairDrop},
+		Misaligned:   {RepairAlign},
+	}
+	for _, kind := range AnomalyKinds {
+		repair := p.repair(kind)
+		if repair == RepairIgnore || repair == RepairFail {
+			continue
+		}
+		var ok bool
+		for _, r := range valid[kind] {
+			ok = ok || r == repair
+		}
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrInvalidRepair, kind)
+		}
+	}
+	return nil
+}
+
+// fillGaps inserts synthetic flat klines at the previous close into gaps in sorted klines.
+func fillGaps(klines []Kline, interval time.Duration) []Kline {
+	if len(klines) == 0 {
+		return klines
+	}
+	filled := make([]Kline, 0, len(klines))
+	filled = append(filled, klines[0])
+	for _, k := range klines[1:] {
+		prev := filled[len(filled)-1]
+		for t := prev.Start.Add(interval); t.Before(k.Start); t = t.Add(interval) {
+			filled = append(filled, Kline{Start: t, O: prev.C, H: prev.C, L: prev.C, C: prev.C, Synthetic: true})
+		}
+		filled = append(filled, k)
+	}
+	return filled
+}
+
+// flagGaps marks each kline of sorted klines that follows a gap.
+func flagGaps(klines []Kline, interval time.Duration) {
+	for i := 1; i < len(klines); i++ {
+		if klines[i].Start.Sub(klines[i-1].Start) > interval {
+			klines[i].AfterGap = true
+		}
+	}
+}
+
+// alignStart truncates the start to a multiple of an interval that divides a day.
+// Other intervals are returned unchanged.
+func alignStart(start time.Time, interval time.Duration) time.Time {
+	if interval <= 0 || (24*time.Hour)%interval != 0 {
+		return start
+	}
+	return start.Truncate(interval)
+}
+
+func invalidRange(k Kline) string {
+	switch {
+	case !k.O.IsPositive() || !k.H.IsPositive() || !k.L.IsPositive() || !k.C.IsPositive():
+		return "non-positive price"
+	case k.H.LessThan(k.L):
+		return "high less than low"
+	case k.O.GreaterThan(k.H) || k.O.LessThan(k.L):
+		return "open outside range"
+	case k.C.GreaterThan(k.H) || k.C.LessThan(k.L):
+		return "close outside range"
+	}
+	return ""
+}
+
+func uniqueSortedStarts(klines []Kline) []time.Time {
+	starts := make([]time.Time, 0, len(klines))
+	seen := make(map[int64]struct{}, len(klines))
+	for _, k := range klines {
+		key := k.Start.UnixNano()
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		starts = append(starts, k.Start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	return starts
+}
+
+// inferInterval returns the most common positive duration between sorted unique start times.
+func inferInterval(klines []Kline) time.Duration {
+	starts := uniqueSortedStarts(klines)
+	counts := make(map[time.Duration]int)
+	var mode time.Duration
+	for i := 1; i < len(starts); i++ {
+		d := starts[i].Sub(starts[i-1])
+		counts[d]++
+		if counts[d] > counts[mode] || (counts[d] == counts[mode] && d < mode) {
+			mode = d
+		}
+	}
+	return mode
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var _startForQualityTest = time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func klineForQualityTest(hour int, c float64) Kline {
+	return Kline{
+		Start:  _startForQualityTest.Add(time.Duration(hour) * time.Hour),
+		O:      dec.New(c),
+		H:      dec.New(c + 1),
+		L:      dec.New(c - 1),
+		C:      dec.New(c),
+		Volume: 1,
+	}
+}
+
+func defectiveKlinesForQualityTest() []Kline {
+	zero := klineForQualityTest(4, 10)
+	zero.Volume = 0
+	invalid := klineForQualityTest(5, 10)
+	invalid.H = dec.New(1)
+
+	return []Kline{
+		klineForQualityTest(0, 10),
+		klineForQualityTest(2, 12),
+		klineForQualityTest(1, 11), // Out of order
+		klineForQualityTest(2, 99), // Duplicate
+		zero,
+		invalid,
+		klineForQualityTest(8, 15), // Gap of 2 bars
+	}
+}
+
+func TestValidate(t *testing.T) {
+	act := Validate(defectiveKlinesForQualityTest(), 0)
+
+	assert.Equal(t, time.Hour, act.Interval)
+	assert.Equal(t, 7, act.KlineCount)
+	assert.False(t, act.OK())
+	for kind, want := range map[AnomalyKind]int{
+		OutOfOrder:   1,
+		Duplicate:    1,
+		Gap:          2, // Hour 3 and hours 6-7
+		ZeroVolume:   1,
+		InvalidRange: 1,
+		Misaligned:   0,
+	} {
+		assert.Equal(t, want, act.Count(kind), kind.String())
+	}
+
+	for _, a := range act.Anomalies {
+		if a.Kind == OutOfOrder {
+			assert.Equal(t, 2, a.Index)
+			assert.Equal(t, _startForQualityTest.Add(time.Hour), a.Start)
+		}
+	}
+
+	assert.True(t, Validate([]Kline{klineForQualityTest(0, 10), klineForQualityTest(1, 10)}, time.Hour).OK())
+}
+
+func TestClean(t *testing.T) {
+	policy := CleanPolicy{
+		OutOfOrder:   RepairSort,
+		Duplicate:    RepairDedupe,
+		Gap:          RepairFill,
+		ZeroVolume:   RepairDrop,
+		InvalidRange: RepairDrop,
+	}
+
+	act, report, err := Clean(defectiveKlinesForQualityTest(), time.Hour, policy)
+	require.NoError(t, err)
+	assert.Len(t, report.Anomalies, 6)
+
+	require.Len(t, act, 9)
+	for i, k := range act {
+		assert.Equal(t, _startForQualityTest.Add(time.Duration(i)*time.Hour), k.Start)
+	}
+	assert.True(t, act[2].C.Equal(dec.New(12)), "first duplicate is kept")
+	assert.True(t, act[4].C.Equal(dec.New(12)), "gap is filled with previous close")
+	assert.Zero(t, act[4].Volume)
+	assert.True(t, act[4].Synthetic)
+	assert.False(t, act[8].Synthetic)
+	assert.True(t, Validate(act, time.Hour).Count(Gap) == 0)
+}
+
+func TestClean_Flag(t *testing.T) {
+	act, _, err := Clean(defectiveKlinesForQualityTest(), time.Hour, CleanPolicy{
+		OutOfOrder: RepairSort,
+		Duplicate:  RepairDedupe,
+		Gap:        RepairFlag,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, act, 6)
+	var flagged []time.Time
+	for _, k := range act {
+		assert.False(t, k.Synthetic)
+		if k.AfterGap {
+			flagged = append(flagged, k.Start)
+		}
+	}
+	assert.Equal(t, []time.Time{
+		_startForQualityTest.Add(4 * time.Hour),
+		_startForQualityTest.Add(8 * time.Hour),
+	}, flagged)
+}
+
+func TestClean_Align(t *testing.T) {
+	late := klineForQualityTest(1, 11)
+	late.Start = late.Start.Add(3 * time.Second)
+	dupe := klineForQualityTest(2, 12)
+	dupe.Start = dupe.Start.Add(time.Minute)
+	give := []Kline{klineForQualityTest(0, 10), late, klineForQualityTest(2, 12), dupe}
+
+	report := Validate(give, time.Hour)
+	assert.Equal(t, 2, report.Count(Misaligned))
+
+	act, _, err := Clean(give, time.Hour, CleanPolicy{Duplicate: RepairDedupe, Misaligned: RepairAlign})
+	require.NoError(t, err)
+	require.Len(t, act, 3)
+	for i, k := range act {
+		assert.Equal(t, _startForQualityTest.Add(time.Duration(i)*time.Hour), k.Start)
+	}
+	assert.True(t, Validate(act, time.Hour).OK())
+
+	// Weekly klines are not checked as a week does not divide a day
+	assert.Zero(t, Validate(give, 7*24*time.Hour).Count(Misaligned))
+}
+
+func TestClean_Fail(t *testing.T) {
+	_, _, err := Clean(defectiveKlinesForQualityTest(), time.Hour, CleanPolicy{Gap: RepairFail})
+	assert.ErrorIs(t, err, ErrDataQuality)
+
+	_, _, err = Clean(defectiveKlinesForQualityTest(), time.Hour, CleanPolicy{Gap: RepairSort})
+	assert.ErrorIs(t, err, ErrInvalidRepair)
+
+	_, _, err = Clean(defectiveKlinesForQualityTest(), time.Hour, CleanPolicy{Misaligned: RepairFill})
+	assert.ErrorIs(t, err, ErrInvalidRepair)
+}
+
+func TestParseRepair(t *testing.T) {
+	act, err := ParseRepair("fill")
+	assert.NoError(t, err)
+	assert.Equal(t, RepairFill, act)
+
+	_, err = ParseRepair("mend")
+	assert.ErrorIs(t, err, ErrInvalidRepair)
+}
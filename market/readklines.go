@@ -11,14 +11,27 @@ import (
 )
 
 // ReadKlinesFromCSV reads all the .csv files in a given directory or a single file into a slice of Klines.
-// Wraps a default CSVKlineReader with Binance decoder for convenience.
+// Wraps a default CSVKlineReader with Binance decoder for convenience, reading a kline cache written
+// with the "binance" decoder name instead if present (see ReadKlinesFromCSVWithDecoder).
 // For finer grained memory management use the base kline reader.
 func ReadKlinesFromCSV(path string) ([]Kline, error) {
-	return ReadKlinesFromCSVWithDecoder(path, MakeCSVKlineReader(NewBinanceCSVKlineReader))
+	return ReadKlinesFromCSVWithDecoder(path, MakeCSVKlineReader(NewBinanceCSVKlineReader), "binance")
 }
 
 // ReadKlinesFromCSVWithDecoder permits using a custom CSVKlineReader.
-func ReadKlinesFromCSVWithDecoder(path string, maker MakeCSVKlineReader) ([]Kline, error) {
+// The decoder is the name the reader is registered by, e.g. "binance". If a kline cache file (see KlineCacheFilename)
+// is present, was written with the named decoder and is newer than the CSV files it is read instead.
+// Pass an empty name to always decode the CSV files, for example with a reader that has no registered name.
+func ReadKlinesFromCSVWithDecoder(path string, maker MakeCSVKlineReader, decoder string) ([]Kline, error) {
+	if decoder != "" {
+		if prices, ok := readFreshKlineCache(path, decoder); ok {
+			return prices, nil
+		}
+	}
+	return readKlinesFromCSV(path, maker)
+}
+
+func readKlinesFromCSV(path string, maker MakeCSVKlineReader) ([]Kline, error) {
 	var prices []Kline
 
 	err := filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
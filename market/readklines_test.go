@@ -14,3 +14,12 @@ func TestReadKlinesFromCSV(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, prices, 2158)
 }
+
+func TestReadKlinesFromCSV_OrderFlow(t *testing.T) {
+	prices, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	assert.NoError(t, err)
+	assert.Equal(t, 66768830.34010008, prices[0].QuoteVolume)
+	assert.Equal(t, int64(58389), prices[0].TradeCount)
+	assert.Equal(t, 1215.359238, prices[0].TakerBuyVolume)
+	assert.Equal(t, 35103542.78288276, prices[0].TakerBuyQuoteVolume)
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var _ Receiver = (*Resampler)(nil)
+
+// Resample aggregates klines into the larger timeframe.
+// Klines must be in ascending chronological order.
+// Each aggregated kline takes the open of the first kline, the close of the last kline,
+// the highest high and lowest low, and the sum of volume.
+// The final kline may be incomplete if the source klines end part way through a bar.
+func Resample(klines []Kline, tf Timeframe) []Kline {
+	var resampled []Kline
+	var bar Kline
+	var barStart time.Time
+	for i, k := range klines {
+		start := tf.Truncate(k.Start)
+		if i > 0 && start.Equal(barStart) {
+			bar = mergeKline(bar, k)
+			continue
+		}
+		if i > 0 {
+			resampled = append(resampled, bar)
+		}
+		barStart = start
+		bar = k
+		bar.Start = start.In(k.Start.Location())
+	}
+	if len(klines) > 0 {
+		resampled = append(resampled, bar)
+	}
+	return resampled
+}
+
+// Resampler is a Receiver that aggregates a stream of klines into a larger timeframe
+// and forwards each completed bar to the next Receiver.
+// A bar is complete when the first kline of the next bar is received.
+// Call Flush to forward the incomplete bar, for example at the end of a backtest.
+type Resampler struct {
+	Timeframe Timeframe
+
+	next     Receiver
+	bar      Kline
+	barStart time.Time
+	pending  bool
+}
+
+// NewResampler creates a new Resampler that forwards bars of the given timeframe to next.
+func NewResampler(tf Timeframe, next Receiver) *Resampler {
+	return &Resampler{
+		Timeframe: tf,
+		next:      next,
+	}
+}
+
+// ReceivePrice aggregates the kline into the current bar,
+// forwarding the current bar first if the kline starts a new one.
+func (r *Resampler) ReceivePrice(ctx context.Context, k Kline) error {
+	start := r.Timeframe.Truncate(k.Start)
+	if r.pending && start.Equal(r.barStart) {
+		r.bar = mergeKline(r.bar, k)
+		return nil
+	}
+	if err := r.Flush(ctx); err != nil {
+		return err
+	}
+	r.barStart = start
+	r.bar = k
+	r.bar.Start = start.In(k.Start.Location())
+	r.pending = true
+	return nil
+}
+
+// Flush forwards the current bar, complete or not, to the next Receiver.
+func (r *Resampler) Flush(ctx context.Context) error {
+	if !r.pending {
+		return nil
+	}
+	r.pending = false
+	return r.next.ReceivePrice(ctx, r.bar)
+}
+
+// mergeKline extends bar with the next kline k.
+// The bar is Synthetic only if all its klines are, and AfterGap if any gap precedes one of its klines.
+func mergeKline(bar, k Kline) Kline {
+	bar.H = decimal.Max(bar.H, k.H)
+	bar.L = decimal.Min(bar.L, k.L)
+	bar.C = k.C
+	bar.Synthetic = bar.Synthetic && k.Synthetic
+	bar.AfterGap = bar.AfterGap || k.AfterGap
+	return addVolume(bar, k)
+}
+
+// addVolume adds the volume and order flow fields of k to bar.
+func addVolume(bar, k Kline) Kline {
+	bar.Volume += k.Volume
+	bar.QuoteVolume += k.QuoteVolume
+	bar.TradeCount += k.TradeCount
+	bar.TakerBuyVolume += k.TakerBuyVolume
+	bar.TakerBuyQuoteVolume += k.TakerBuyQuoteVolume
+	return bar
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+type receiverForResampleTest struct {
+	klines []Kline
+}
+
+func (r *receiverForResampleTest) ReceivePrice(ctx context.Context, k Kline) error {
+	r.klines = append(r.klines, k)
+	return nil
+}
+
+func TestResample(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	give := []Kline{
+		{Start: start, O: dec.New(10), H: dec.New(12), L: dec.New(9), C: dec.New(11), Volume: 1},
+		{Start: start.Add(time.Hour), O: dec.New(11), H: dec.New(15), L: dec.New(10), C: dec.New(14), Volume: 2},
+		{Start: start.Add(2 * time.Hour), O: dec.New(14), H: dec.New(14), L: dec.New(8), C: dec.New(9), Volume: 3},
+		{Start: start.Add(3 * time.Hour), O: dec.New(9), H: dec.New(10), L: dec.New(9), C: dec.New(10), Volume: 4},
+	}
+
+	act := Resample(give, Timeframe{Unit: Hour, Count: 2})
+	require.Len(t, act, 2)
+
+	assert.Equal(t, start, act[0].Start)
+	assert.True(t, act[0].O.Equal(dec.New(10)))
+	assert.True(t, act[0].H.Equal(dec.New(15)))
+	assert.True(t, act[0].L.Equal(dec.New(9)))
+	assert.True(t, act[0].C.Equal(dec.New(14)))
+	assert.Equal(t, 3.0, act[0].Volume)
+
+	assert.Equal(t, start.Add(2*time.Hour), act[1].Start)
+	assert.True(t, act[1].L.Equal(dec.New(8)))
+	assert.Equal(t, 7.0, act[1].Volume)
+
+	assert.Empty(t, Resample(nil, Timeframe{Unit: Hour, Count: 2}))
+}
+
+func TestResample_GapFlags(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	at := func(h int, synthetic, afterGap bool) Kline {
+		c := dec.New(10)
+		return Kline{Start: start.Add(time.Duration(h) * time.Hour), O: c, H: c, L: c, C: c, Synthetic: synthetic, AfterGap: afterGap}
+	}
+	give := []Kline{
+		// Filled then real
+		at(0, true, false), at(1, false, false),
+		// Real then filled
+		at(2, false, false), at(3, true, false),
+		// All filled
+		at(4, true, false), at(5, true, false),
+		// Real with a flagged gap before the second kline
+		at(6, false, false), at(7, false, true),
+	}
+
+	act := Resample(give, Timeframe{Unit: Hour, Count: 2})
+	require.Len(t, act, 4)
+	wantSynthetic := []bool{false, false, true, false}
+	wantAfterGap := []bool{false, false, false, true}
+	for i := range act {
+		assert.Equal(t, wantSynthetic[i], act[i].Synthetic, "synthetic %d", i)
+		assert.Equal(t, wantAfterGap[i], act[i].AfterGap, "after gap %d", i)
+	}
+}
+
+func TestResample_H1ToD1(t *testing.T) {
+	prices, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+
+	act := Resample(prices, Timeframe{Unit: Day, Count: 1})
+	assert.Len(t, act, 90)
+
+	var volume float64
+	for _, k := range prices {
+		volume += k.Volume
+	}
+	var resampledVolume float64
+	for _, k := range act {
+		resampledVolume += k.Volume
+	}
+	assert.InDelta(t, volume, resampledVolume, 0.0001)
+}
+
+func TestResampler(t *testing.T) {
+	prices, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	tf := Timeframe{Unit: Hour, Count: 4}
+
+	var receiver receiverForResampleTest
+	resampler := NewResampler(tf, &receiver)
+	ctx := context.Background()
+	for _, k := range prices {
+		require.NoError(t, resampler.ReceivePrice(ctx, k))
+	}
+	want := Resample(prices, tf)
+	assert.Len(t, receiver.klines, len(want)-1)
+
+	require.NoError(t, resampler.Flush(ctx))
+	assert.Equal(t, want, receiver.klines)
+}
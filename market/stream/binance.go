@@ -0,0 +1,226 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ MessageDecoder = BinanceMessageDecoder
+var _ Backfiller = (*BinanceBackfiller)(nil)
+
+const _binanceBackfillLimit = 1000
+
+// binanceKlineEvent is a kline event of the Binance WebSocket API.
+// Prices and volumes are strings to preserve precision.
+// Every field is declared since JSON keys match case-insensitively, e.g. E would otherwise decode into e.
+type binanceKlineEvent struct {
+	Event     string `json:"e"`
+	EventTime int64  `json:"E"`
+	Symbol    string `json:"s"`
+	Kline     struct {
+		Start               int64  `json:"t"`
+		Close               int64  `json:"T"`
+		Symbol              string `json:"s"`
+		Interval            string `json:"i"`
+		FirstTradeID        int64  `json:"f"`
+		LastTradeID         int64  `json:"L"`
+		O                   string `json:"o"`
+		H                   string `json:"h"`
+		L                   string `json:"l"`
+		C                   string `json:"c"`
+		Volume              string `json:"v"`
+		TradeCount          int64  `json:"n"`
+		Closed              bool   `json:"x"`
+		QuoteVolume         string `json:"q"`
+		TakerBuyVolume      string `json:"V"`
+		TakerBuyQuoteVolume string `json:"Q"`
+		Ignore              string `json:"B"`
+	} `json:"k"`
+}
+
+// BinanceSubscribe returns a Binance subscription message for the kline stream of a symbol, e.g. btcusdt,
+// at the interval, e.g. 1m.
+// A KlineStream tracks the last kline of a single series, so run a stream per symbol to stream several.
+func BinanceSubscribe(interval string, symbol string) []byte {
+	params := []string{fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)}
+	//nolint:errchkjson // Marshalling strings cannot fail
+	msg, _ := json.Marshal(map[string]any{"method": "SUBSCRIBE", "params": params, "id": 1})
+	return msg
+}
+
+// BinanceMessageDecoder decodes a kline event of the Binance WebSocket API, from a raw or combined stream.
+// Other messages, such as subscription replies, are skipped.
+func BinanceMessageDecoder(msg []byte) (market.Kline, bool, error) {
+	var empty market.Kline
+
+	// Combined streams wrap the event in a data field
+	var combined struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(msg, &combined); err != nil {
+		return empty, false, err
+	}
+	if combined.Data != nil {
+		msg = combined.Data
+	}
+
+	var event binanceKlineEvent
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return empty, false, err
+	}
+	if event.Event != "kline" {
+		return empty, false, ErrSkipMessage
+	}
+
+	e := event.Kline
+	k, err := market.BinanceCSVKlineDecoder([]string{
+		strconv.FormatInt(e.Start, 10), e.O, e.H, e.L, e.C, e.Volume, strconv.FormatInt(e.Close, 10),
+		e.QuoteVolume, strconv.FormatInt(e.TradeCount, 10), e.TakerBuyVolume, e.TakerBuyQuoteVolume,
+	})
+	if err != nil {
+		return empty, false, err
+	}
+	return k, e.Closed, nil
+}
+
+// encodeBinanceMessage encodes a kline as a Binance WebSocket kline event.
+func encodeBinanceMessage(symbol, interval string, k market.Kline, closed bool) []byte {
+	var event binanceKlineEvent
+	event.Event = "kline"
+	event.Symbol = strings.ToUpper(symbol)
+	e := &event.Kline
+	e.Start = k.Start.UnixMilli()
+	e.Interval = interval
+	e.O, e.H, e.L, e.C = k.O.String(), k.H.String(), k.L.String(), k.C.String()
+	e.Volume = formatFloat(k.Volume)
+	e.TradeCount = k.TradeCount
+	e.Closed = closed
+	e.QuoteVolume = formatFloat(k.QuoteVolume)
+	e.TakerBuyVolume = formatFloat(k.TakerBuyVolume)
+	e.TakerBuyQuoteVolume = formatFloat(k.TakerBuyQuoteVolume)
+	//nolint:errchkjson // Marshalling strings and numbers cannot fail
+	msg, _ := json.Marshal(event)
+	return msg
+}
+
+// encodeBinanceKlines encodes klines as a Binance REST API klines response.
+func encodeBinanceKlines(klines []market.Kline, interval time.Duration) []byte {
+	rows := make([][]any, len(klines))
+	for i, k := range klines {
+		rows[i] = []any{
+			k.Start.UnixMilli(), k.O.String(), k.H.String(), k.L.String(), k.C.String(), formatFloat(k.Volume),
+			k.Start.Add(interval).UnixMilli() - 1, formatFloat(k.QuoteVolume), k.TradeCount,
+			formatFloat(k.TakerBuyVolume), formatFloat(k.TakerBuyQuoteVolume), "0",
+		}
+	}
+	//nolint:errchkjson // Marshalling strings and numbers cannot fail
+	msg, _ := json.Marshal(rows)
+	return msg
+}
+
+func formatFloat(v float64) string {
+	return decimal.NewFromFloat(v).String()
+}
+
+// BinanceBackfiller backfills klines from the Binance REST API klines endpoint.
+type BinanceBackfiller struct {
+	// BaseURL is the REST API root, e.g. https://api.binance.com.
+	BaseURL string
+
+	// Symbol is the symbol of the kline stream, e.g. BTCUSDT.
+	Symbol string
+
+	// Interval is the kline interval in Binance notation, e.g. 1m.
+	Interval string
+
+	// Client is the HTTP client. Nil defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// NewBinanceBackfiller creates a new BinanceBackfiller.
+func NewBinanceBackfiller(baseURL, symbol, interval string) *BinanceBackfiller {
+	return &BinanceBackfiller{
+		BaseURL:  baseURL,
+		Symbol:   symbol,
+		Interval: interval,
+	}
+}
+
+// Backfill requests the klines that start in the range [from, to), one page of up to 1000 klines at a time.
+func (b *BinanceBackfiller) Backfill(ctx context.Context, from, to time.Time) ([]market.Kline, error) {
+	var klines []market.Kline
+	for from.Before(to) {
+		page, err := b.request(ctx, from, to)
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, page...)
+		if len(page) < _binanceBackfillLimit {
+			break
+		}
+		from = page[len(page)-1].Start.Add(time.Millisecond)
+	}
+	return klines, nil
+}
+
+func (b *BinanceBackfiller) request(ctx context.Context, from, to time.Time) ([]market.Kline, error) {
+	query := url.Values{}
+	query.Set("symbol", strings.ToUpper(b.Symbol))
+	query.Set("interval", b.Interval)
+	query.Set("startTime", strconv.FormatInt(from.UnixMilli(), 10))
+	query.Set("endTime", strconv.FormatInt(to.UnixMilli()-1, 10))
+	query.Set("limit", strconv.Itoa(_binanceBackfillLimit))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(b.BaseURL, "/")+"/api/v3/klines?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("backfill request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	// Each kline is an array of the same fields as a Binance CSV record, with numbers or strings
+	var rows [][]json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, err
+	}
+	klines := make([]market.Kline, 0, len(rows))
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, v := range row {
+			record[i] = strings.Trim(string(v), `"`)
+		}
+		k, err := market.BinanceCSVKlineDecoder(record)
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, k)
+	}
+	return klines, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+const _binanceKlineEvent = `{"e":"kline","E":1638747660000,"s":"BTCUSDT","k":{"t":1638747660000,"T":1638747719999,"s":"BTCUSDT","i":"1m","f":100,"L":200,"o":"0.0010","c":"0.0020","h":"0.0025","l":"0.0015","v":"1000","n":100,"x":true,"q":"1.0000","V":"500","Q":"0.500","B":"123456"}}`
+
+func TestBinanceMessageDecoder(t *testing.T) {
+	tests := []struct {
+		name       string
+		give       string
+		wantKline  market.Kline
+		wantClosed bool
+		wantErr    error
+	}{
+		{
+			name: "raw stream",
+			give: _binanceKlineEvent,
+			wantKline: market.Kline{
+				Start: time.UnixMilli(1638747660000).UTC(),
+				O:     dec.New(0.001), H: dec.New(0.0025), L: dec.New(0.0015), C: dec.New(0.002),
+				Volume: 1000, QuoteVolume: 1, TradeCount: 100, TakerBuyVolume: 500, TakerBuyQuoteVolume: 0.5,
+			},
+			wantClosed: true,
+		},
+		{
+			name: "combined stream",
+			give: `{"stream":"btcusdt@kline_1m","data":` + _binanceKlineEvent + `}`,
+			wantKline: market.Kline{
+				Start: time.UnixMilli(1638747660000).UTC(),
+				O:     dec.New(0.001), H: dec.New(0.0025), L: dec.New(0.0015), C: dec.New(0.002),
+				Volume: 1000, QuoteVolume: 1, TradeCount: 100, TakerBuyVolume: 500, TakerBuyQuoteVolume: 0.5,
+			},
+			wantClosed: true,
+		},
+		{
+			name:    "subscription reply",
+			give:    `{"result":null,"id":1}`,
+			wantErr: ErrSkipMessage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, closed, err := BinanceMessageDecoder([]byte(tt.give))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantClosed, closed)
+			assertKlineEqual(t, tt.wantKline, k)
+		})
+	}
+}
+
+func TestBinanceMessageDecoder_Invalid(t *testing.T) {
+	_, _, err := BinanceMessageDecoder([]byte(`{"e":"kline","k":{"t":1,"o":"bad"}}`))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrSkipMessage)
+}
+
+func TestBinanceSubscribe(t *testing.T) {
+	act := BinanceSubscribe("1m", "BTCUSDT")
+	assert.JSONEq(t, `{"method":"SUBSCRIBE","params":["btcusdt@kline_1m"],"id":1}`, string(act))
+}
+
+func TestBinanceBackfiller(t *testing.T) {
+	server := NewStubServer("BTCUSDT", time.Minute)
+	addr, err := server.Listen()
+	require.NoError(t, err)
+	defer server.Close()
+
+	// More klines than a page to test paging
+	klines := klinesForStreamTest(_binanceBackfillLimit + 500)
+	server.AddHistory(klines...)
+
+	backfiller := NewBinanceBackfiller("http://"+addr, "BTCUSDT", "1m")
+	from, to := klines[10].Start, klines[len(klines)-10].Start
+	act, err := backfiller.Backfill(context.Background(), from, to)
+	require.NoError(t, err)
+
+	want := klines[10 : len(klines)-10]
+	require.Len(t, act, len(want))
+	for i := range want {
+		assertKlineEqual(t, want[i], act[i])
+	}
+}
+
+// klinesForStreamTest returns n one minute klines from the 1st of January 2022, with an increasing close.
+func klinesForStreamTest(n int) []market.Kline {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]market.Kline, n)
+	for i := range klines {
+		p := dec.New(100 + float64(i))
+		klines[i] = market.Kline{
+			Start: start.Add(time.Duration(i) * time.Minute),
+			O:     p, H: p, L: p, C: p,
+			Volume: float64(i), TradeCount: int64(i),
+		}
+	}
+	return klines
+}
+
+func assertKlineEqual(t *testing.T, want, act market.Kline) {
+	t.Helper()
+	assert.True(t, want.Start.Equal(act.Start), "start want %v got %v", want.Start, act.Start)
+	assert.True(t, want.O.Equal(act.O), "open want %v got %v", want.O, act.O)
+	assert.True(t, want.H.Equal(act.H), "high want %v got %v", want.H, act.H)
+	assert.True(t, want.L.Equal(act.L), "low want %v got %v", want.L, act.L)
+	assert.True(t, want.C.Equal(act.C), "close want %v got %v", want.C, act.C)
+	assert.Equal(t, want.Volume, act.Volume)
+	assert.Equal(t, want.QuoteVolume, act.QuoteVolume)
+	assert.Equal(t, want.TradeCount, act.TradeCount)
+	assert.Equal(t, want.TakerBuyVolume, act.TakerBuyVolume)
+	assert.Equal(t, want.TakerBuyQuoteVolume, act.TakerBuyQuoteVolume)
+}
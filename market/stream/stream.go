@@ -0,0 +1,257 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package stream provides a live market data feed of klines over a WebSocket connection.
+// A KlineStream subscribes to a kline stream, decodes each message into a market.Kline
+// and sends only closed klines to a market.Receiver, such as a bot or a paper trading dealer.
+// A KlineStream follows the klines of a single symbol and interval.
+// A dropped connection is re-established with exponential backoff,
+// and any klines missed while disconnected are backfilled from a REST endpoint.
+// A StubServer is included as a local stand-in for a venue to enable offline integration tests.
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+const (
+	_defaultMinBackoff  = time.Second
+	_defaultMaxBackoff  = time.Minute
+	_defaultReadTimeout = time.Minute
+
+	_writeTimeout = 10 * time.Second
+)
+
+// ErrSkipMessage is returned by a MessageDecoder for a message that is not a kline, such as a subscription reply.
+var ErrSkipMessage = errors.New("skip message")
+
+// MessageDecoder decodes a WebSocket message into a kline.
+// Closed is true if the kline is final, false if it is an update of a kline that is still forming.
+type MessageDecoder func(msg []byte) (k market.Kline, closed bool, err error)
+
+// Backfiller returns the closed klines that start in the range [from, to), in chronological order.
+// Used by KlineStream to recover klines missed while disconnected.
+type Backfiller interface {
+	Backfill(ctx context.Context, from, to time.Time) ([]market.Kline, error)
+}
+
+// Config configures a KlineStream.
+type Config struct {
+	// URL is the WebSocket endpoint, e.g. wss://stream.binance.com:9443/ws.
+	URL string
+
+	// Subscribe is sent after each connect to subscribe to the kline stream of a single symbol.
+	// Klines of other symbols would be taken as duplicates or gaps of the same series.
+	// Optional: leave nil if the URL subscribes, e.g. wss://stream.binance.com:9443/ws/btcusdt@kline_1m.
+	Subscribe []byte
+
+	// Decoder decodes each message into a kline.
+	Decoder MessageDecoder
+
+	// Interval is the duration of a kline, used to detect missed klines.
+	Interval time.Duration
+
+	// Backfill recovers missed klines. Optional: leave nil to skip missed klines.
+	Backfill Backfiller
+
+	// MinBackoff and MaxBackoff bound the delay before reconnecting, which doubles after each failed attempt.
+	// Default to 1 second and 1 minute.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// ReadTimeout is the longest wait for a message or pong before the connection is treated as dead and reconnected.
+	// The server is pinged at half this interval, so a quiet but live connection is kept open. Defaults to 1 minute.
+	ReadTimeout time.Duration
+}
+
+// KlineStream sends the closed klines of a WebSocket stream to a Receiver.
+type KlineStream struct {
+	config   Config
+	receiver market.Receiver
+	dialer   *websocket.Dialer
+
+	last market.Kline
+}
+
+// NewKlineStream creates a new KlineStream. Call Run to start streaming.
+func NewKlineStream(config Config, receiver market.Receiver) *KlineStream {
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = _defaultMinBackoff
+	}
+	if config.MaxBackoff < config.MinBackoff {
+		config.MaxBackoff = _defaultMaxBackoff
+		if config.MaxBackoff < config.MinBackoff {
+			config.MaxBackoff = config.MinBackoff
+		}
+	}
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = _defaultReadTimeout
+	}
+	return &KlineStream{
+		config:   config,
+		receiver: receiver,
+		dialer:   websocket.DefaultDialer,
+	}
+}
+
+// Run connects to the stream and sends closed klines to the receiver until the context is done,
+// reconnecting whenever the connection fails.
+// A backfill that fails is retried after reconnecting.
+// Returns the context error, or the first error returned by the receiver or decoder.
+func (s *KlineStream) Run(ctx context.Context) error {
+	backoff := s.config.MinBackoff
+	for {
+		connected, err := s.stream(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var connErr connectionError
+		if !errors.As(err, &connErr) {
+			return err
+		}
+		if connected {
+			backoff = s.config.MinBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > s.config.MaxBackoff {
+			backoff = s.config.MaxBackoff
+		}
+	}
+}
+
+// connectionError is a failure of the WebSocket connection, after which the stream reconnects.
+type connectionError struct {
+	err error
+}
+
+func (e connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e connectionError) Unwrap() error {
+	return e.err
+}
+
+// stream connects and processes messages until the connection fails.
+// Returns true if the connection was established.
+func (s *KlineStream) stream(ctx context.Context) (bool, error) {
+	conn, _, err := s.dialer.DialContext(ctx, s.config.URL, nil)
+	if err != nil {
+		return false, connectionError{err}
+	}
+	defer conn.Close()
+
+	// Each message, ping or pong from the server shows the connection is alive and extends the read deadline
+	extend := func() error {
+		return conn.SetReadDeadline(time.Now().Add(s.config.ReadTimeout))
+	}
+	if err := extend(); err != nil {
+		return true, connectionError{err}
+	}
+	conn.SetPongHandler(func(string) error { return extend() })
+	conn.SetPingHandler(func(data string) error {
+		if err := extend(); err != nil {
+			return err
+		}
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(_writeTimeout))
+		if errors.Is(err, websocket.ErrCloseSent) {
+			return nil
+		}
+		return err
+	})
+
+	// Unblock the read loop when the context is done, and ping the server to keep a quiet connection alive
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(s.config.ReadTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				//nolint:errcheck // Closing to interrupt a blocked read
+				conn.Close()
+				return
+			case <-ticker.C:
+				//nolint:errcheck // A failed ping is detected by the read deadline
+				conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(_writeTimeout))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if s.config.Subscribe != nil {
+		if err := conn.WriteMessage(websocket.TextMessage, s.config.Subscribe); err != nil {
+			return true, connectionError{err}
+		}
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return true, connectionError{err}
+		}
+		if err := extend(); err != nil {
+			return true, connectionError{err}
+		}
+		k, closed, err := s.config.Decoder(msg)
+		if errors.Is(err, ErrSkipMessage) || (err == nil && !closed) {
+			continue
+		}
+		if err != nil {
+			return true, err
+		}
+		if err := s.receive(ctx, k); err != nil {
+			return true, err
+		}
+	}
+}
+
+// receive sends a closed kline to the receiver, after backfilling any klines missed since the last.
+// Klines at or before the last are duplicates and are ignored.
+func (s *KlineStream) receive(ctx context.Context, k market.Kline) error {
+	if !s.last.Start.IsZero() && !k.Start.After(s.last.Start) {
+		return nil
+	}
+
+	if s.config.Backfill != nil && s.config.Interval > 0 && !s.last.Start.IsZero() {
+		from := s.last.Start.Add(s.config.Interval)
+		if k.Start.After(from) {
+			missed, err := s.config.Backfill.Backfill(ctx, from, k.Start)
+			if err != nil {
+				// Reconnect and retry, the next kline received will backfill this kline too
+				return connectionError{err}
+			}
+			for _, m := range missed {
+				if !m.Start.After(s.last.Start) || !m.Start.Before(k.Start) {
+					continue
+				}
+				if err := s.send(ctx, m); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return s.send(ctx, k)
+}
+
+func (s *KlineStream) send(ctx context.Context, k market.Kline) error {
+	if err := s.receiver.ReceivePrice(ctx, k); err != nil {
+		return err
+	}
+	s.last = k
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// receiverForStreamTest sends each kline received to a channel.
+type receiverForStreamTest struct {
+	klines chan market.Kline
+	err    error
+}
+
+func (r *receiverForStreamTest) ReceivePrice(_ context.Context, k market.Kline) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.klines <- k
+	return nil
+}
+
+// startStreamForTest starts a stub server and a KlineStream connected to it.
+// Returns the stub server, the receiver and a channel of the result of Run.
+func startStreamForTest(t *testing.T, ctx context.Context, receiver *receiverForStreamTest) (*StubServer, chan error) {
+	t.Helper()
+	server := NewStubServer("BTCUSDT", time.Minute)
+	addr, err := server.Listen()
+	require.NoError(t, err)
+	t.Cleanup(func() { server.Close() })
+
+	stream := NewKlineStream(Config{
+		URL:        "ws://" + addr + "/ws",
+		Subscribe:  BinanceSubscribe("1m", "BTCUSDT"),
+		Decoder:    BinanceMessageDecoder,
+		Interval:   time.Minute,
+		Backfill:   NewBinanceBackfiller("http://"+addr, "BTCUSDT", "1m"),
+		MinBackoff: 5 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+
+		ReadTimeout: 100 * time.Millisecond,
+	}, receiver)
+
+	done := make(chan error, 1)
+	go func() { done <- stream.Run(ctx) }()
+	return server, done
+}
+
+func receiveForStreamTest(t *testing.T, receiver *receiverForStreamTest) market.Kline {
+	t.Helper()
+	select {
+	case k := <-receiver.klines:
+		return k
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for kline")
+	}
+	return market.Kline{}
+}
+
+func TestKlineStream_ClosedOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	receiver := &receiverForStreamTest{klines: make(chan market.Kline, 10)}
+	server, done := startStreamForTest(t, ctx, receiver)
+	require.NoError(t, server.WaitForConnections(ctx, 1))
+
+	klines := klinesForStreamTest(2)
+	require.NoError(t, server.Publish(klines[0], false))
+	require.NoError(t, server.Publish(klines[0], true))
+	require.NoError(t, server.Publish(klines[1], false))
+	require.NoError(t, server.Publish(klines[0], true))
+	require.NoError(t, server.Publish(klines[1], true))
+
+	// The updates and the duplicate are ignored
+	assertKlineEqual(t, klines[0], receiveForStreamTest(t, receiver))
+	assertKlineEqual(t, klines[1], receiveForStreamTest(t, receiver))
+	assert.Len(t, receiver.klines, 0)
+
+	assert.JSONEq(t, string(BinanceSubscribe("1m", "BTCUSDT")), string(server.Subscriptions()[0]))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestKlineStream_ReconnectAndBackfill(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	receiver := &receiverForStreamTest{klines: make(chan market.Kline, 10)}
+	server, done := startStreamForTest(t, ctx, receiver)
+	require.NoError(t, server.WaitForConnections(ctx, 1))
+
+	klines := klinesForStreamTest(5)
+	require.NoError(t, server.Publish(klines[0], true))
+	assertKlineEqual(t, klines[0], receiveForStreamTest(t, receiver))
+
+	// Klines close while disconnected and are only available from REST
+	server.Disconnect()
+	server.AddHistory(klines[1:4]...)
+	require.NoError(t, server.WaitForConnections(ctx, 2))
+	require.NoError(t, server.Publish(klines[4], true))
+
+	for _, want := range klines[1:] {
+		assertKlineEqual(t, want, receiveForStreamTest(t, receiver))
+	}
+	assert.Len(t, server.Subscriptions(), 2)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestKlineStream_ReceiverError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	wantErr := errors.New("receiver failed")
+	receiver := &receiverForStreamTest{err: wantErr}
+	server, done := startStreamForTest(t, ctx, receiver)
+	require.NoError(t, server.WaitForConnections(ctx, 1))
+
+	require.NoError(t, server.Publish(klinesForStreamTest(1)[0], true))
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, wantErr)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for error")
+	}
+}
+
+func TestKlineStream_ReadTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	receiver := &receiverForStreamTest{klines: make(chan market.Kline, 10)}
+	server, done := startStreamForTest(t, ctx, receiver)
+	require.NoError(t, server.WaitForConnections(ctx, 1))
+
+	// A quiet connection is kept open by pings for several read timeouts
+	quiet, cancelQuiet := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancelQuiet()
+	assert.ErrorIs(t, server.WaitForConnections(quiet, 2), context.DeadlineExceeded)
+	klines := klinesForStreamTest(1)
+	require.NoError(t, server.Publish(klines[0], true))
+	assertKlineEqual(t, klines[0], receiveForStreamTest(t, receiver))
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestKlineStream_ReadTimeoutReconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The server accepts connections but never reads, so it does not answer pings
+	var accepted int32
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		atomic.AddInt32(&accepted, 1)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	stream := NewKlineStream(Config{
+		URL:         "ws" + strings.TrimPrefix(server.URL, "http"),
+		Decoder:     BinanceMessageDecoder,
+		MinBackoff:  5 * time.Millisecond,
+		MaxBackoff:  20 * time.Millisecond,
+		ReadTimeout: 50 * time.Millisecond,
+	}, &receiverForStreamTest{})
+	done := make(chan error, 1)
+	go func() { done <- stream.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&accepted) >= 3 }, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package stream
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// StubServer is a local stand-in for the Binance kline WebSocket and REST APIs of a single symbol,
+// for integration testing a KlineStream offline.
+// Klines are pushed to connected clients with Publish, and closed klines are kept to serve backfill requests.
+type StubServer struct {
+	symbol   string
+	interval time.Duration
+
+	listener net.Listener
+	server   *http.Server
+	upgrader websocket.Upgrader
+
+	mu            sync.Mutex
+	conns         map[*websocket.Conn]struct{}
+	accepted      int
+	acceptedCh    chan struct{}
+	subscriptions [][]byte
+	history       []market.Kline
+}
+
+// NewStubServer creates a new StubServer for the klines of the symbol at the interval.
+func NewStubServer(symbol string, interval time.Duration) *StubServer {
+	return &StubServer{
+		symbol:     symbol,
+		interval:   interval,
+		conns:      make(map[*websocket.Conn]struct{}),
+		acceptedCh: make(chan struct{}),
+	}
+}
+
+// Listen starts serving on a random local port. Returns the address, e.g. 127.0.0.1:54321.
+// The WebSocket endpoint is ws://address/ws and the REST root is http://address.
+func (s *StubServer) Listen() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.serveWebSocket)
+	mux.HandleFunc("/api/v3/klines", s.serveKlines)
+	s.listener = listener
+	s.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		//nolint:errcheck // Always returns an error when the server is closed
+		s.server.Serve(listener)
+	}()
+	return listener.Addr().String(), nil
+}
+
+// Close disconnects all clients and stops the server.
+func (s *StubServer) Close() error {
+	s.Disconnect()
+	return s.server.Close()
+}
+
+// Publish sends a kline event to every connected client. Closed klines are kept for backfill.
+func (s *StubServer) Publish(k market.Kline, closed bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if closed {
+		s.history = append(s.history, k)
+	}
+	msg := encodeBinanceMessage(s.symbol, formatBinanceInterval(s.interval), k, closed)
+	for conn := range s.conns {
+		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddHistory adds closed klines for backfill without publishing them, as if they closed while a client was disconnected.
+func (s *StubServer) AddHistory(klines ...market.Kline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(s.history, klines...)
+}
+
+// Disconnect drops the connection of every client.
+func (s *StubServer) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		//nolint:errcheck // Dropping the connection
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// WaitForConnections waits until n connections in total have been accepted since the server started.
+func (s *StubServer) WaitForConnections(ctx context.Context, n int) error {
+	for {
+		s.mu.Lock()
+		accepted, ch := s.accepted, s.acceptedCh
+		s.mu.Unlock()
+		if accepted >= n {
+			return nil
+		}
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Subscriptions returns the messages received from clients.
+func (s *StubServer) Subscriptions() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.subscriptions...)
+}
+
+func (s *StubServer) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	// Wait for the subscription, if any, before accepting so that no event is published before the client is ready
+	//nolint:errcheck // A client that does not subscribe times out
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, msg, err := conn.ReadMessage()
+	//nolint:errcheck // Clearing the deadline
+	conn.SetReadDeadline(time.Time{})
+
+	s.mu.Lock()
+	if err == nil {
+		s.subscriptions = append(s.subscriptions, msg)
+	}
+	s.conns[conn] = struct{}{}
+	s.accepted++
+	close(s.acceptedCh)
+	s.acceptedCh = make(chan struct{})
+	s.mu.Unlock()
+
+	// Discard any further messages until the client disconnects
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			//nolint:errcheck // Connection already failed
+			conn.Close()
+			return
+		}
+	}
+}
+
+func (s *StubServer) serveKlines(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	startTime, err := strconv.ParseInt(query.Get("startTime"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid startTime", http.StatusBadRequest)
+		return
+	}
+	endTime, err := strconv.ParseInt(query.Get("endTime"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid endTime", http.StatusBadRequest)
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil {
+		limit = 500
+	}
+
+	s.mu.Lock()
+	var klines []market.Kline
+	for _, k := range s.history {
+		start := k.Start.UnixMilli()
+		if start >= startTime && start <= endTime && len(klines) < limit {
+			klines = append(klines, k)
+		}
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	//nolint:errcheck // Client may have gone away
+	w.Write(encodeBinanceKlines(klines, s.interval))
+}
+
+// formatBinanceInterval returns the interval in Binance notation, e.g. 1m, 4h or 1d.
+func formatBinanceInterval(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d"
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	default:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTimeframe is returned when a timeframe cannot be parsed or has a non-positive count.
+var ErrInvalidTimeframe = errors.New("invalid timeframe")
+
+// TimeframeUnit is the calendar unit of a Timeframe.
+type TimeframeUnit int
+
+const (
+	// Minute timeframe unit (M).
+	Minute TimeframeUnit = iota + 1
+
+	// Hour timeframe unit (H).
+	Hour
+
+	// Day timeframe unit (D).
+	Day
+
+	// Week timeframe unit (W). Weeks start on Monday.
+	Week
+
+	// Month timeframe unit (MN).
+	Month
+)
+
+var _timeframeUnitCodes = [...]string{"", "M", "H", "D", "W", "MN"}
+
+// Timeframe defines calendar-aligned bar boundaries, such as H4 or D1.
+//
+// Boundaries are aligned to the calendar in Location, which defaults to UTC.
+// Offset shifts the start of each calendar day, to align bars to a trading session.
+// For example a D1 timeframe in America/New_York with a 17h offset starts each daily bar at 5pm New York time.
+//
+// Intraday timeframes (minutes and hours) are aligned to the start of each (offset) day,
+// so should evenly divide 24 hours. Multi-day, multi-week and multi-month timeframes are aligned to the unix epoch.
+type Timeframe struct {
+	Unit     TimeframeUnit
+	Count    int
+	Location *time.Location
+	Offset   time.Duration
+}
+
+// ParseTimeframe parses a timeframe code in MetaTrader notation: a unit (M, H, D, W, MN) followed by a count.
+// For example M15, H4, D1, W1 or MN1. The returned timeframe uses UTC with no offset.
+func ParseTimeframe(code string) (Timeframe, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	tf := Timeframe{Location: time.UTC}
+
+	var count string
+	switch {
+	case strings.HasPrefix(code, "MN"):
+		tf.Unit, count = Month, code[2:]
+	case strings.HasPrefix(code, "M"):
+		tf.Unit, count = Minute, code[1:]
+	case strings.HasPrefix(code, "H"):
+		tf.Unit, count = Hour, code[1:]
+	case strings.HasPrefix(code, "D"):
+		tf.Unit, count = Day, code[1:]
+	case strings.HasPrefix(code, "W"):
+		tf.Unit, count = Week, code[1:]
+	default:
+		return tf, fmt.Errorf("%w: %s", ErrInvalidTimeframe, code)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return tf, fmt.Errorf("%w: %s", ErrInvalidTimeframe, code)
+	}
+	tf.Count = n
+
+	return tf, nil
+}
+
+// String returns the timeframe code in MetaTrader notation.
+func (tf Timeframe) String() string {
+	if tf.Unit < Minute || tf.Unit > Month {
+		return ""
+	}
+	return _timeframeUnitCodes[tf.Unit] + strconv.Itoa(tf.Count)
+}
+
+// Truncate returns the start of the bar that contains t.
+// The returned time is in the timeframe Location.
+//
+// Boundaries are computed in wall-clock time, so a D1 bar with a 17h offset starts at 5pm local time
+// on either side of a daylight saving transition. A boundary that falls in the skipped hour
+// of a transition starts at the end of the skipped hour.
+func (tf Timeframe) Truncate(t time.Time) time.Time {
+	loc := tf.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	count := tf.Count
+	if count <= 0 {
+		count = 1
+	}
+
+	// Work in wall-clock time, held as UTC to avoid daylight saving transitions,
+	// and shift so that the session start falls on midnight
+	local := wallClock(t.In(loc), time.UTC).Add(-tf.Offset)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	var start time.Time
+	switch tf.Unit {
+	case Minute, Hour:
+		interval := time.Duration(count) * time.Minute
+		if tf.Unit == Hour {
+			interval = time.Duration(count) * time.Hour
+		}
+		start = midnight.Add(local.Sub(midnight) / interval * interval)
+	case Day:
+		day := epochDays(midnight)
+		start = midnight.AddDate(0, 0, -floorMod(day, count))
+	case Week:
+		// Unix epoch was a Thursday, so offset by 3 days to align weeks to Monday
+		monday := midnight.AddDate(0, 0, -floorMod(int(midnight.Weekday())+6, 7))
+		week := floorDiv(epochDays(monday)+3, 7)
+		start = monday.AddDate(0, 0, -7*floorMod(week, count))
+	case Month:
+		month := (local.Year()-1970)*12 + int(local.Month()) - 1
+		first := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.UTC)
+		start = first.AddDate(0, -floorMod(month, count), 0)
+	default:
+		start = local
+	}
+
+	return wallClock(start.Add(tf.Offset), loc)
+}
+
+// wallClock returns the time with the same wall-clock fields as t in the location.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// epochDays returns the number of calendar days since the unix epoch of a local midnight.
+func epochDays(midnight time.Time) int {
+	y, m, d := midnight.Date()
+	return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func floorMod(a, b int) int {
+	return a - floorDiv(a, b)*b
+}
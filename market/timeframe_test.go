@@ -0,0 +1,164 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeframe(t *testing.T) {
+	tests := []struct {
+		give string
+		want Timeframe
+		err  error
+	}{
+		{give: "M15", want: Timeframe{Unit: Minute, Count: 15, Location: time.UTC}},
+		{give: "h4", want: Timeframe{Unit: Hour, Count: 4, Location: time.UTC}},
+		{give: "D1", want: Timeframe{Unit: Day, Count: 1, Location: time.UTC}},
+		{give: "W1", want: Timeframe{Unit: Week, Count: 1, Location: time.UTC}},
+		{give: "MN3", want: Timeframe{Unit: Month, Count: 3, Location: time.UTC}},
+		{give: "X1", err: ErrInvalidTimeframe},
+		{give: "H0", err: ErrInvalidTimeframe},
+		{give: "H", err: ErrInvalidTimeframe},
+	}
+	for _, tt := range tests {
+		t.Run(tt.give, func(t *testing.T) {
+			act, err := ParseTimeframe(tt.give)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.err == nil {
+				assert.Equal(t, tt.want, act)
+				assert.Equal(t, tt.want.String(), act.String())
+			}
+		})
+	}
+}
+
+func TestTimeframe_Truncate(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	give := time.Date(2022, time.March, 16, 14, 37, 0, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		name string
+		give Timeframe
+		want time.Time
+	}{
+		{
+			name: "M15",
+			give: Timeframe{Unit: Minute, Count: 15},
+			want: time.Date(2022, time.March, 16, 14, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "H4",
+			give: Timeframe{Unit: Hour, Count: 4},
+			want: time.Date(2022, time.March, 16, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "D1",
+			give: Timeframe{Unit: Day, Count: 1},
+			want: time.Date(2022, time.March, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "W1 starts Monday",
+			give: Timeframe{Unit: Week, Count: 1},
+			want: time.Date(2022, time.March, 14, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "MN1",
+			give: Timeframe{Unit: Month, Count: 1},
+			want: time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "MN3 aligned to quarter",
+			give: Timeframe{Unit: Month, Count: 3},
+			want: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "D1 in New York",
+			give: Timeframe{Unit: Day, Count: 1, Location: ny},
+			want: time.Date(2022, time.March, 16, 0, 0, 0, 0, ny),
+		},
+		{
+			name: "D1 in New York with 17h session offset",
+			give: Timeframe{Unit: Day, Count: 1, Location: ny, Offset: 17 * time.Hour},
+			want: time.Date(2022, time.March, 15, 17, 0, 0, 0, ny),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.give.Truncate(give)
+			assert.True(t, tt.want.Equal(act), "want %s, got %s", tt.want, act)
+		})
+	}
+}
+
+func TestTimeframe_TruncateDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	session := Timeframe{Unit: Day, Count: 1, Location: ny, Offset: 17 * time.Hour}
+
+	// Clocks go forward at 2am on 13 March 2022 and back at 2am on 6 November 2022
+	tests := []struct {
+		name string
+		give time.Time
+		tf   Timeframe
+		want time.Time
+	}{
+		{
+			name: "D1 session starts at 5pm after spring forward",
+			give: time.Date(2022, time.March, 13, 17, 30, 0, 0, ny),
+			tf:   session,
+			want: time.Date(2022, time.March, 13, 17, 0, 0, 0, ny),
+		},
+		{
+			name: "D1 session spanning spring forward",
+			give: time.Date(2022, time.March, 13, 16, 30, 0, 0, ny),
+			tf:   session,
+			want: time.Date(2022, time.March, 12, 17, 0, 0, 0, ny),
+		},
+		{
+			name: "D1 session spanning fall back",
+			give: time.Date(2022, time.November, 6, 16, 30, 0, 0, ny),
+			tf:   session,
+			want: time.Date(2022, time.November, 5, 17, 0, 0, 0, ny),
+		},
+		{
+			name: "D1 session starts at 5pm after fall back",
+			give: time.Date(2022, time.November, 6, 17, 0, 0, 0, ny),
+			tf:   session,
+			want: time.Date(2022, time.November, 6, 17, 0, 0, 0, ny),
+		},
+		{
+			name: "D1 on spring forward day",
+			give: time.Date(2022, time.March, 13, 12, 0, 0, 0, ny),
+			tf:   Timeframe{Unit: Day, Count: 1, Location: ny},
+			want: time.Date(2022, time.March, 13, 0, 0, 0, 0, ny),
+		},
+		{
+			name: "H4 on spring forward day",
+			give: time.Date(2022, time.March, 13, 4, 30, 0, 0, ny),
+			tf:   Timeframe{Unit: Hour, Count: 4, Location: ny},
+			want: time.Date(2022, time.March, 13, 4, 0, 0, 0, ny),
+		},
+		{
+			name: "W1 spanning spring forward",
+			give: time.Date(2022, time.March, 16, 9, 0, 0, 0, ny),
+			tf:   Timeframe{Unit: Week, Count: 1, Location: ny},
+			want: time.Date(2022, time.March, 14, 0, 0, 0, 0, ny),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := tt.tf.Truncate(tt.give)
+			assert.True(t, tt.want.Equal(act), "want %s, got %s", tt.want, act)
+			assert.Equal(t, ny, act.Location())
+		})
+	}
+}
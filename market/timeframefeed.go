@@ -0,0 +1,126 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"time"
+)
+
+var _ Receiver = (*MultiTimeframeFeed)(nil)
+
+// TimeframeReceiver receives completed bars of a higher timeframe in addition to the base klines it receives as a Receiver.
+type TimeframeReceiver interface {
+	ReceiveTimeframePrice(ctx context.Context, tf Timeframe, k Kline) error
+}
+
+// MultiTimeframeFeed is a Receiver that forwards a stream of base klines to the next Receiver
+// and also aggregates them into bars of one or more higher timeframes.
+//
+// A higher timeframe bar is delivered only when complete, so there is no look-ahead:
+// it is forwarded to next by ReceiveTimeframePrice, if next implements TimeframeReceiver,
+// immediately before the base kline that completes it.
+// A bar is complete when the end of a base kline (start plus interval) reaches the end of the bar,
+// or, if klines are missing, when the first kline of the following bar is received.
+//
+// The first bar of each timeframe is discarded unless the first kline starts on the bar boundary,
+// as a bar built from part of its klines would misrepresent the timeframe.
+type MultiTimeframeFeed struct {
+	// Interval is the duration of each base kline.
+	// If zero it is inferred as the shortest time between consecutive klines received.
+	Interval time.Duration
+
+	next     Receiver
+	bars     []timeframeBar
+	last     time.Time
+	inferred time.Duration
+}
+
+type timeframeBar struct {
+	tf       Timeframe
+	bar      Kline
+	barStart time.Time
+	pending  bool
+	partial  bool
+	started  bool
+}
+
+// NewMultiTimeframeFeed creates a new feed that forwards base klines and bars of the given timeframes to next.
+func NewMultiTimeframeFeed(interval time.Duration, next Receiver, timeframes ...Timeframe) *MultiTimeframeFeed {
+	f := &MultiTimeframeFeed{
+		Interval: interval,
+		next:     next,
+	}
+	for _, tf := range timeframes {
+		f.bars = append(f.bars, timeframeBar{tf: tf})
+	}
+	return f
+}
+
+// Timeframes returns the higher timeframes aggregated by the feed.
+func (f *MultiTimeframeFeed) Timeframes() []Timeframe {
+	timeframes := make([]Timeframe, len(f.bars))
+	for i := range f.bars {
+		timeframes[i] = f.bars[i].tf
+	}
+	return timeframes
+}
+
+// ReceivePrice aggregates the kline into each higher timeframe, forwards any completed bars
+// and then forwards the kline itself.
+func (f *MultiTimeframeFeed) ReceivePrice(ctx context.Context, k Kline) error {
+	if gap := k.Start.Sub(f.last); !f.last.IsZero() && gap > 0 && (f.inferred == 0 || gap < f.inferred) {
+		f.inferred = gap
+	}
+	f.last = k.Start
+
+	tr, _ := f.next.(TimeframeReceiver)
+	for i := range f.bars {
+		b := &f.bars[i]
+		start := b.tf.Truncate(k.Start)
+
+		// Missing klines: the pending bar ended without a kline reaching its end
+		if b.pending && !start.Equal(b.barStart) {
+			if err := b.deliver(ctx, tr); err != nil {
+				return err
+			}
+		}
+
+		if b.pending {
+			b.bar = mergeKline(b.bar, k)
+		} else {
+			b.bar = k
+			b.bar.Start = start.In(k.Start.Location())
+			b.barStart = start
+			b.pending = true
+			b.partial = !b.started && !start.Equal(k.Start)
+			b.started = true
+		}
+
+		if interval := f.interval(); interval > 0 && !b.tf.Truncate(k.Start.Add(interval)).Equal(start) {
+			if err := b.deliver(ctx, tr); err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.next.ReceivePrice(ctx, k)
+}
+
+// interval returns the configured or inferred duration of a base kline, or zero if not yet known.
+func (f *MultiTimeframeFeed) interval() time.Duration {
+	if f.Interval > 0 {
+		return f.Interval
+	}
+	return f.inferred
+}
+
+func (b *timeframeBar) deliver(ctx context.Context, tr TimeframeReceiver) error {
+	b.pending = false
+	if b.partial || tr == nil {
+		b.partial = false
+		return nil
+	}
+	return tr.ReceiveTimeframePrice(ctx, b.tf, b.bar)
+}
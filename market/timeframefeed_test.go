@@ -0,0 +1,92 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+type receiverForTimeframeFeedTest struct {
+	base []Kline
+	bars []Kline
+	// seen is the start of the last base kline received when each bar was delivered
+	seen []time.Time
+}
+
+func (r *receiverForTimeframeFeedTest) ReceivePrice(ctx context.Context, k Kline) error {
+	r.base = append(r.base, k)
+	return nil
+}
+
+func (r *receiverForTimeframeFeedTest) ReceiveTimeframePrice(ctx context.Context, tf Timeframe, k Kline) error {
+	r.bars = append(r.bars, k)
+	var last time.Time
+	if len(r.base) > 0 {
+		last = r.base[len(r.base)-1].Start
+	}
+	r.seen = append(r.seen, last)
+	return nil
+}
+
+func TestMultiTimeframeFeed(t *testing.T) {
+	prices, err := ReadKlinesFromCSV("./testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	d1 := Timeframe{Unit: Day, Count: 1}
+
+	var receiver receiverForTimeframeFeedTest
+	feed := NewMultiTimeframeFeed(0, &receiver, d1)
+	ctx := context.Background()
+	for _, k := range prices {
+		require.NoError(t, feed.ReceivePrice(ctx, k))
+	}
+
+	assert.Equal(t, prices, receiver.base)
+
+	// The sample ends with the last hour of a day so every daily bar is complete
+	assertKlinesEqual(t, Resample(prices, d1), receiver.bars)
+
+	// Each bar is delivered before the last kline of the bar is forwarded, so no later kline has been seen
+	for i, bar := range receiver.bars {
+		assert.True(t, receiver.seen[i].Before(bar.Start.Add(23*time.Hour)), "bar %d", i)
+	}
+}
+
+func TestMultiTimeframeFeed_PartialFirstBar(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 5, 0, 0, 0, time.UTC)
+	var receiver receiverForTimeframeFeedTest
+	feed := NewMultiTimeframeFeed(time.Hour, &receiver, Timeframe{Unit: Hour, Count: 4})
+	ctx := context.Background()
+	for i := 0; i < 8; i++ {
+		k := Kline{Start: start.Add(time.Duration(i) * time.Hour), O: dec.New(1), H: dec.New(1), L: dec.New(1), C: dec.New(float64(i))}
+		require.NoError(t, feed.ReceivePrice(ctx, k))
+	}
+
+	// 05:00-07:00 is partial and discarded, 08:00-11:00 is complete, 12:00 is incomplete
+	require.Len(t, receiver.bars, 1)
+	assert.Equal(t, start.Add(3*time.Hour), receiver.bars[0].Start)
+	assert.True(t, receiver.bars[0].C.Equal(dec.New(6)))
+}
+
+func TestMultiTimeframeFeed_MissingKlines(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var receiver receiverForTimeframeFeedTest
+	feed := NewMultiTimeframeFeed(time.Hour, &receiver, Timeframe{Unit: Hour, Count: 4})
+	ctx := context.Background()
+	// The 03:00 kline that ends the first bar is missing
+	for _, h := range []int{0, 1, 2, 4} {
+		k := Kline{Start: start.Add(time.Duration(h) * time.Hour), O: dec.New(1), H: dec.New(1), L: dec.New(1), C: dec.New(float64(h))}
+		require.NoError(t, feed.ReceivePrice(ctx, k))
+	}
+
+	require.Len(t, receiver.bars, 1)
+	assert.Equal(t, start, receiver.bars[0].Start)
+	assert.True(t, receiver.bars[0].C.Equal(dec.New(2)))
+	assert.Equal(t, start.Add(2*time.Hour), receiver.seen[0])
+}
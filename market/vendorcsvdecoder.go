@@ -0,0 +1,362 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// YahooTimeFormat is the date format of Yahoo Finance daily history downloads.
+const YahooTimeFormat = "2006-01-02"
+
+// DukascopyTimeFormat is the time format of Dukascopy candle exports.
+// Local time exports append the UTC offset, e.g. "03.01.2022 00:00:00.000 GMT+0100".
+const DukascopyTimeFormat = "02.01.2006 15:04:05.000"
+
+// NewYahooCSVKlineReader creates a new CSVKlineReader for Yahoo Finance daily history CSV files.
+func NewYahooCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: YahooCSVKlineDecoder,
+	}
+}
+
+// NewYahooAdjustedCSVKlineReader creates a new CSVKlineReader for Yahoo Finance daily history CSV files
+// that adjusts prices for dividends and splits.
+func NewYahooAdjustedCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: YahooAdjustedCSVKlineDecoder,
+	}
+}
+
+// YahooCSVKlineDecoder decodes a CSV record from Yahoo Finance into a Kline.
+// Columns are Date, Open, High, Low, Close, Adj Close, Volume.
+// Prices are the quoted OHLC and Adj Close is ignored. Note that Yahoo quotes prices adjusted for splits
+// but not dividends, so only dividends remain to be adjusted with AdjustForCorporateActions.
+// The header row and rows with null prices, such as non-trading days, are skipped.
+func YahooCSVKlineDecoder(record []string) (Kline, error) {
+	k, _, err := decodeYahooRecord(record)
+	return k, err
+}
+
+// YahooAdjustedCSVKlineDecoder decodes a CSV record from Yahoo Finance into a Kline
+// adjusted for dividends and splits, by scaling OHLC by the ratio of the adjusted close to the close.
+// Prices are already adjusted so must not be adjusted again with AdjustForCorporateActions.
+func YahooAdjustedCSVKlineDecoder(record []string) (Kline, error) {
+	k, adjClose, err := decodeYahooRecord(record)
+	if err != nil {
+		return k, err
+	}
+	if !k.C.IsZero() && !adjClose.Equal(k.C) {
+		factor := adjClose.Div(k.C)
+		k.O = k.O.Mul(factor)
+		k.H = k.H.Mul(factor)
+		k.L = k.L.Mul(factor)
+		k.C = adjClose
+	}
+	return k, nil
+}
+
+// decodeYahooRecord decodes a CSV record from Yahoo Finance into a Kline of the quoted prices and the adjusted close.
+func decodeYahooRecord(record []string) (Kline, decimal.Decimal, error) {
+	var k, empty Kline
+	var err error
+
+	if isHeaderRecord(record, "Date") {
+		return empty, decimal.Zero, ErrSkipRecord
+	}
+	if len(record) < 7 {
+		return empty, decimal.Zero, ErrNotEnoughColumns
+	}
+	for _, v := range record[1:] {
+		if v == "null" {
+			return empty, decimal.Zero, ErrSkipRecord
+		}
+	}
+
+	if k.Start, err = time.Parse(YahooTimeFormat, record[0]); err != nil {
+		return empty, decimal.Zero, ErrInvalidTimeFormat
+	}
+
+	prices, err := parsePrices(record[1:6]...)
+	if err != nil {
+		return empty, decimal.Zero, err
+	}
+	k.O, k.H, k.L, k.C = prices[0], prices[1], prices[2], prices[3]
+
+	if k.Volume, err = strconv.ParseFloat(record[6], 64); err != nil {
+		return empty, decimal.Zero, ErrInvalidVolumeFormat
+	}
+
+	return k, prices[4], nil
+}
+
+// NewDukascopyCSVKlineReader creates a new CSVKlineReader for Dukascopy candle CSV files.
+func NewDukascopyCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: DukascopyCSVKlineDecoder,
+	}
+}
+
+// DukascopyCSVKlineDecoder decodes a CSV record from a Dukascopy candle export into a Kline.
+// Columns are Time, Open, High, Low, Close, Volume. Bid and ask candles are exported to separate files in the same format.
+// The header row is skipped.
+func DukascopyCSVKlineDecoder(record []string) (Kline, error) {
+	var k, empty Kline
+	var err error
+
+	if isHeaderRecord(record, "Gmt time", "Local time") {
+		return empty, ErrSkipRecord
+	}
+	if len(record) < 6 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	layout := DukascopyTimeFormat
+	if len(record[0]) > len(layout) {
+		layout += " GMT-0700"
+	}
+	t, err := time.Parse(layout, record[0])
+	if err != nil {
+		return empty, ErrInvalidTimeFormat
+	}
+	k.Start = t.UTC()
+
+	prices, err := parsePrices(record[1:5]...)
+	if err != nil {
+		return empty, err
+	}
+	k.O, k.H, k.L, k.C = prices[0], prices[1], prices[2], prices[3]
+
+	if k.Volume, err = strconv.ParseFloat(record[5], 64); err != nil {
+		return empty, ErrInvalidVolumeFormat
+	}
+
+	return k, nil
+}
+
+// NewKrakenCSVKlineReader creates a new CSVKlineReader for Kraken OHLCVT CSV files.
+func NewKrakenCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: KrakenCSVKlineDecoder,
+	}
+}
+
+// KrakenCSVKlineDecoder decodes a CSV record from a Kraken OHLCVT history file into a Kline.
+// Columns are unix seconds, Open, High, Low, Close, Volume and optionally Trades.
+func KrakenCSVKlineDecoder(record []string) (Kline, error) {
+	var k, empty Kline
+	var err error
+
+	if len(record) < 6 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	if k.Start, err = parseUnix(record[0], time.Second); err != nil {
+		return empty, err
+	}
+
+	prices, err := parsePrices(record[1:5]...)
+	if err != nil {
+		return empty, err
+	}
+	k.O, k.H, k.L, k.C = prices[0], prices[1], prices[2], prices[3]
+
+	if k.Volume, err = strconv.ParseFloat(record[5], 64); err != nil {
+		return empty, ErrInvalidVolumeFormat
+	}
+
+	if len(record) > 6 {
+		if k.TradeCount, err = strconv.ParseInt(record[6], 10, 64); err != nil {
+			return empty, ErrInvalidTradeCountFormat
+		}
+	}
+
+	return k, nil
+}
+
+// NewCoinbaseCSVKlineReader creates a new CSVKlineReader for Coinbase candle CSV files.
+func NewCoinbaseCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: CoinbaseCSVKlineDecoder,
+	}
+}
+
+// CoinbaseCSVKlineDecoder decodes a CSV record of Coinbase candles into a Kline.
+// Columns follow the Coinbase API order: unix seconds, Low, High, Open, Close, Volume.
+// The API returns candles newest first so the klines must be sorted, which is the default clean policy.
+// The header row is skipped.
+func CoinbaseCSVKlineDecoder(record []string) (Kline, error) {
+	var k, empty Kline
+	var err error
+
+	if isHeaderRecord(record, "time") {
+		return empty, ErrSkipRecord
+	}
+	if len(record) < 6 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	if k.Start, err = parseUnix(record[0], time.Second); err != nil {
+		return empty, err
+	}
+
+	prices, err := parsePrices(record[1:5]...)
+	if err != nil {
+		return empty, err
+	}
+	k.L, k.H, k.O, k.C = prices[0], prices[1], prices[2], prices[3]
+
+	if k.Volume, err = strconv.ParseFloat(record[5], 64); err != nil {
+		return empty, ErrInvalidVolumeFormat
+	}
+
+	return k, nil
+}
+
+// NewBybitCSVKlineReader creates a new CSVKlineReader for Bybit kline CSV files.
+func NewBybitCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: BybitCSVKlineDecoder,
+	}
+}
+
+// BybitCSVKlineDecoder decodes a CSV record from a Bybit kline dump into a Kline.
+// Columns follow the Bybit API order: unix milliseconds, Open, High, Low, Close, Volume and optionally Turnover (quote volume).
+// The API returns klines newest first so the klines must be sorted, which is the default clean policy.
+// The header row is skipped.
+func BybitCSVKlineDecoder(record []string) (Kline, error) {
+	var k, empty Kline
+	var err error
+
+	if isHeaderRecord(record, "startTime", "start_time", "timestamp") {
+		return empty, ErrSkipRecord
+	}
+	if len(record) < 6 {
+		return empty, ErrNotEnoughColumns
+	}
+
+	if k.Start, err = parseUnix(record[0], time.Millisecond); err != nil {
+		return empty, err
+	}
+
+	prices, err := parsePrices(record[1:5]...)
+	if err != nil {
+		return empty, err
+	}
+	k.O, k.H, k.L, k.C = prices[0], prices[1], prices[2], prices[3]
+
+	if k.Volume, err = strconv.ParseFloat(record[5], 64); err != nil {
+		return empty, ErrInvalidVolumeFormat
+	}
+
+	if len(record) > 6 {
+		if k.QuoteVolume, err = strconv.ParseFloat(record[6], 64); err != nil {
+			return empty, ErrInvalidVolumeFormat
+		}
+	}
+
+	return k, nil
+}
+
+// NewTradingViewCSVKlineReader creates a new CSVKlineReader for TradingView chart export CSV files.
+func NewTradingViewCSVKlineReader(csv *csv.Reader) *CSVKlineReader {
+	// Exports include a column for each indicator on the chart
+	csv.FieldsPerRecord = -1
+	return &CSVKlineReader{
+		csv:     csv,
+		decoder: NewTradingViewCSVKlineDecoder(),
+	}
+}
+
+// NewTradingViewCSVKlineDecoder creates a decoder for a TradingView chart export.
+// Columns are time, open, high, low, close, followed by a column for each indicator on the chart.
+// Time is unix seconds or RFC 3339 depending on the export setting.
+// Volume is read from the column named "Volume" in the header row if present.
+// The decoder holds the header state so a new decoder is required for each file.
+func NewTradingViewCSVKlineDecoder() CSVKlineDecoder {
+	volume := -1
+	return func(record []string) (Kline, error) {
+		var k, empty Kline
+		var err error
+
+		if isHeaderRecord(record, "time") {
+			for i, name := range record {
+				if strings.EqualFold(strings.TrimSpace(name), "Volume") {
+					volume = i
+				}
+			}
+			return empty, ErrSkipRecord
+		}
+		if len(record) < 5 || volume >= len(record) {
+			return empty, ErrNotEnoughColumns
+		}
+
+		if k.Start, err = parseUnix(record[0], time.Second); err != nil {
+			t, err := time.Parse(time.RFC3339, record[0])
+			if err != nil {
+				return empty, ErrInvalidTimeFormat
+			}
+			k.Start = t.UTC()
+		}
+
+		prices, err := parsePrices(record[1:5]...)
+		if err != nil {
+			return empty, err
+		}
+		k.O, k.H, k.L, k.C = prices[0], prices[1], prices[2], prices[3]
+
+		if volume >= 0 && record[volume] != "" && record[volume] != "NaN" {
+			if k.Volume, err = strconv.ParseFloat(record[volume], 64); err != nil {
+				return empty, ErrInvalidVolumeFormat
+			}
+		}
+
+		return k, nil
+	}
+}
+
+// isHeaderRecord returns true if the first field of the record matches one of the given column names, ignoring case.
+func isHeaderRecord(record []string, names ...string) bool {
+	if len(record) == 0 {
+		return false
+	}
+	// Trim a UTF-8 byte order mark written by some exports
+	first := strings.TrimSpace(strings.TrimPrefix(record[0], "\uFEFF"))
+	for _, name := range names {
+		if strings.EqualFold(first, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUnix(v string, unit time.Duration) (time.Time, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidTimeFormat
+	}
+	return time.Unix(0, n*int64(unit)).UTC(), nil
+}
+
+func parsePrices(values ...string) ([]decimal.Decimal, error) {
+	prices := make([]decimal.Decimal, len(values))
+	for i, v := range values {
+		var err error
+		if prices[i], err = decimal.NewFromString(v); err != nil {
+			return nil, ErrInvalidPriceFormat
+		}
+	}
+	return prices, nil
+}
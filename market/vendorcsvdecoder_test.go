@@ -0,0 +1,132 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package market
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestVendorCSVKlineReaders(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveMaker MakeCSVKlineReader
+		givePath  string
+		wantCount int
+		wantFirst Kline
+	}{
+		{
+			name:      "yahoo",
+			giveMaker: NewYahooCSVKlineReader,
+			givePath:  "./testdata/yahoo/SPY.csv",
+			wantCount: 5,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(476.299988), H: dec.New(477.850006), L: dec.New(473.850006), C: dec.New(477.709991), Volume: 72668200,
+			},
+		},
+		{
+			name:      "yahoo adjusted close",
+			giveMaker: NewYahooAdjustedCSVKlineReader,
+			givePath:  "./testdata/yahoo/SPY.csv",
+			wantCount: 5,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(476.299988).Mul(dec.New(466.507477).Div(dec.New(477.709991))),
+				H:     dec.New(477.850006).Mul(dec.New(466.507477).Div(dec.New(477.709991))),
+				L:     dec.New(473.850006).Mul(dec.New(466.507477).Div(dec.New(477.709991))),
+				C:     dec.New(466.507477), Volume: 72668200,
+			},
+		},
+		{
+			name:      "dukascopy gmt",
+			giveMaker: NewDukascopyCSVKlineReader,
+			givePath:  "./testdata/dukascopy/EURUSD_Candlestick_1_Hour_BID.csv",
+			wantCount: 4,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(1.13693), H: dec.New(1.13717), L: dec.New(1.13635), C: dec.New(1.13668), Volume: 1523.46,
+			},
+		},
+		{
+			name:      "dukascopy local time",
+			giveMaker: NewDukascopyCSVKlineReader,
+			givePath:  "./testdata/dukascopy/EURUSD_Candlestick_1_Hour_ASK_local.csv",
+			wantCount: 3,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 3, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(1.13705), H: dec.New(1.13731), L: dec.New(1.13648), C: dec.New(1.13681), Volume: 1602.11,
+			},
+		},
+		{
+			name:      "kraken",
+			giveMaker: NewKrakenCSVKlineReader,
+			givePath:  "./testdata/kraken/XBTUSD_60.csv",
+			wantCount: 4,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(46217.5), H: dec.New(46800), L: dec.New(46200), C: dec.New(46672.1), Volume: 145.08541872,
+				TradeCount: 3521,
+			},
+		},
+		{
+			name:      "coinbase newest first",
+			giveMaker: NewCoinbaseCSVKlineReader,
+			givePath:  "./testdata/coinbase/BTC-USD_3600.csv",
+			wantCount: 4,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 1, 3, 0, 0, 0, time.UTC),
+				O:     dec.New(46949.81), H: dec.New(47094.39), L: dec.New(46771.02), C: dec.New(46868.87), Volume: 291.93452771,
+			},
+		},
+		{
+			name:      "bybit",
+			giveMaker: NewBybitCSVKlineReader,
+			givePath:  "./testdata/bybit/BTCUSDT_60.csv",
+			wantCount: 4,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(46210.5), H: dec.New(46795), L: dec.New(46200), C: dec.New(46666), Volume: 2513.452,
+				QuoteVolume: 116812373.67,
+			},
+		},
+		{
+			name:      "tradingview unix time with volume",
+			giveMaker: NewTradingViewCSVKlineReader,
+			givePath:  "./testdata/tradingview/BINANCE_BTCUSDT_60.csv",
+			wantCount: 4,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC),
+				O:     dec.New(46216.93), H: dec.New(46800), L: dec.New(46208.37), C: dec.New(46656.13), Volume: 2523.18721,
+			},
+		},
+		{
+			name:      "tradingview iso time without volume",
+			giveMaker: NewTradingViewCSVKlineReader,
+			givePath:  "./testdata/tradingview/NASDAQ_AAPL_1D_iso.csv",
+			wantCount: 2,
+			wantFirst: Kline{
+				Start: time.Date(2022, time.January, 3, 14, 30, 0, 0, time.UTC),
+				O:     dec.New(177.83), H: dec.New(182.88), L: dec.New(177.71), C: dec.New(182.01),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := ReadKlinesFromCSVWithDecoder(tt.givePath, tt.giveMaker, "")
+			require.NoError(t, err)
+			assert.Len(t, act, tt.wantCount)
+			assertKlinesEqual(t, []Kline{tt.wantFirst}, act[:1])
+		})
+	}
+}
+
+func TestYahooCSVKlineDecoder_SkipsNullRow(t *testing.T) {
+	_, err := YahooCSVKlineDecoder([]string{"2022-01-08", "null", "null", "null", "null", "null", "null"})
+	assert.ErrorIs(t, err, ErrSkipRecord)
+}
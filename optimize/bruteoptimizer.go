@@ -6,12 +6,14 @@ package optimize
 import (
 	"context"
 	"errors"
+	"io"
 	"math"
 	"runtime"
 
 	"github.com/gammazero/workerpool"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/broker/backtest"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/perf"
 	"github.com/thecolngroup/alphakit/trader"
@@ -53,6 +55,14 @@ type BruteOptimizer struct {
 	MakeDealer     broker.MakeSimulatedDealer
 	Ranker         ObjectiveRanker
 
+	// Dividends are the cash dividends of each asset, for price samples adjusted for splits only.
+	// Set on each dealer for the asset, which must implement DividendSetter.
+	Dividends map[AssetID][]market.CorporateAction
+
+	// Calendar is the optional trading calendar of the assets. If set, the daily returns and CAGR
+	// of each backtest are measured by its sessions rather than UTC days.
+	Calendar *calendar.Calendar
+
 	MaxWorkers int
 
 	study *Study
@@ -61,10 +71,17 @@ type BruteOptimizer struct {
 type bruteOptimizerJob struct {
 	ParamSet       ParamSet
 	Asset          market.Asset
-	Sample         []market.Kline
+	Sample         market.KlineSource
 	WarmupBarCount int
 	MakeBot        trader.MakeFromConfig
 	MakeDealer     broker.MakeSimulatedDealer
+	Dividends      []market.CorporateAction
+	Calendar       *calendar.Calendar
+}
+
+// DividendSetter is implemented by a simulated dealer that pays dividends to open positions, such as backtest.Dealer.
+type DividendSetter interface {
+	SetDividends(dividends []market.CorporateAction)
 }
 
 // NewBruteOptimizer creates a new BruteOptimizer instance with sensible defaults.
@@ -84,23 +101,52 @@ func NewBruteOptimizer() BruteOptimizer {
 // Returned is the estimated number of trials to be performed.
 func (o *BruteOptimizer) Prepare(in ParamMap, samples map[AssetID][]market.Kline) (int, error) {
 
-	products := CartesianBuilder(in)
-	for i := range products {
-		pSet := NewParamSet()
-		pSet.Params = ParamMap(products[i])
-		o.study.Training = append(o.study.Training, pSet)
-	}
+	o.addParamSets(in)
 
 	for k := range samples {
 		training, validation := splitSample(samples[k], o.SampleSplitPct)
 		o.study.TrainingSamples[k] = training
+		o.study.TrainingSources[k] = market.SliceKlineSource(training)
 		o.study.ValidationSamples[k] = validation
+		o.study.ValidationSources[k] = market.SliceKlineSource(validation)
+	}
+
+	return o.steps(len(samples)), nil
+}
+
+// PrepareStream prepares a study in the same way as Prepare, but using price data sources that are streamed
+// lazily by each backtest rather than held in memory. Each source is streamed once to count its klines
+// in order to split it into in-sample and out-of-sample ranges.
+func (o *BruteOptimizer) PrepareStream(in ParamMap, sources map[AssetID]market.KlineSource) (int, error) {
+
+	o.addParamSets(in)
+
+	for k := range sources {
+		count, err := market.CountKlines(sources[k])
+		if err != nil {
+			return 0, err
+		}
+		training, validation := splitSource(sources[k], count, o.SampleSplitPct)
+		o.study.TrainingSources[k] = training
+		o.study.ValidationSources[k] = validation
 	}
 
-	steps := len(o.study.Training) * len(samples) // Training phase
-	steps += len(samples)                         // Validation phase for optimum
+	return o.steps(len(sources)), nil
+}
+
+func (o *BruteOptimizer) addParamSets(in ParamMap) {
+	products := CartesianBuilder(in)
+	for i := range products {
+		pSet := NewParamSet()
+		pSet.Params = ParamMap(products[i])
+		o.study.Training = append(o.study.Training, pSet)
+	}
+}
 
-	return steps, nil
+func (o *BruteOptimizer) steps(sampleCount int) int {
+	steps := len(o.study.Training) * sampleCount // Training phase
+	steps += sampleCount                         // Validation phase for optimum
+	return steps
 }
 
 // Start starts the prepared optimization process and returns with a channel to monitor the progress.
@@ -128,7 +174,7 @@ func (o *BruteOptimizer) Start(ctx context.Context) (<-chan OptimizerTrial, erro
 		defer close(doneCh)
 
 		// Training phase
-		trainigJobCh := o.enqueueJobs(o.study.Training, o.study.TrainingSamples)
+		trainigJobCh := o.enqueueJobs(o.study.Training, o.study.TrainingSources)
 		trainingOutCh := processBruteJobs(ctx, doneCh, trainigJobCh, o.MaxWorkers)
 		for step := range trainingOutCh {
 			step.Phase = Training
@@ -159,7 +205,7 @@ func (o *BruteOptimizer) Start(ctx context.Context) (<-chan OptimizerTrial, erro
 		o.study.Validation = append(o.study.Validation, optima)
 
 		// Validation phase
-		validationJobCh := o.enqueueJobs(o.study.Validation, o.study.ValidationSamples)
+		validationJobCh := o.enqueueJobs(o.study.Validation, o.study.ValidationSources)
 		validationOutCh := processBruteJobs(ctx, doneCh, validationJobCh, o.MaxWorkers)
 		for step := range validationOutCh {
 			step.Phase = Validation
@@ -180,7 +226,7 @@ func (o *BruteOptimizer) Study() *Study {
 	return o.study
 }
 
-func (o *BruteOptimizer) enqueueJobs(pSets []ParamSet, samples map[AssetID][]market.Kline) <-chan bruteOptimizerJob {
+func (o *BruteOptimizer) enqueueJobs(pSets []ParamSet, samples map[AssetID]market.KlineSource) <-chan bruteOptimizerJob {
 
 	// A buffered channel enables us to enqueue jobs and close the channel in a single function to simplify the call flow
 	// Without a buffer the loop would block awaiting a ready receiver for the jobs
@@ -197,6 +243,8 @@ func (o *BruteOptimizer) enqueueJobs(pSets []ParamSet, samples map[AssetID][]mar
 				WarmupBarCount: o.WarmupBarCount,
 				MakeBot:        o.MakeBot,
 				MakeDealer:     o.MakeDealer,
+				Dividends:      o.Dividends[k],
+				Calendar:       o.Calendar,
 			}
 		}
 	}
@@ -232,6 +280,15 @@ func processBruteJobs(ctx context.Context, doneCh <-chan struct{}, jobCh <-chan
 							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
 							return
 						}
+						if len(job.Dividends) > 0 {
+							setter, ok := dealer.(DividendSetter)
+							if !ok {
+								err := errors.New("dealer does not support dividends")
+								outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
+								return
+							}
+							setter.SetDividends(job.Dividends)
+						}
 						bot, err := job.MakeBot(job.ParamSet.Params)
 						if err != nil {
 							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
@@ -240,17 +297,30 @@ func processBruteJobs(ctx context.Context, doneCh <-chan struct{}, jobCh <-chan
 						bot.SetAsset(job.Asset)
 						bot.SetDealer(dealer)
 
-						if len(job.Sample) < job.WarmupBarCount {
+						prices, err := job.Sample.Open()
+						if err != nil {
+							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
+							return
+						}
+						//nolint:errcheck // Read ops only so safe to ignore err return
+						defer prices.Close()
+
+						warmup, err := market.ReadN(prices, job.WarmupBarCount)
+						if err != nil {
+							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
+							return
+						}
+						if len(warmup) < job.WarmupBarCount {
 							err := errors.New("price sample length < required warmup bar count")
 							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
 							return
 						}
 
-						if err := bot.Warmup(ctx, job.Sample[:job.WarmupBarCount]); err != nil {
+						if err := bot.Warmup(ctx, warmup); err != nil {
 							outCh <- OptimizerTrial{PSet: job.ParamSet, Err: err}
 							return
 						}
-						perf, err := runBacktest(ctx, bot, dealer, job.Asset, job.Sample[job.WarmupBarCount:])
+						perf, err := runBacktest(ctx, bot, dealer, job.Asset, prices, job.Calendar)
 						outCh <- OptimizerTrial{PSet: job.ParamSet, Result: perf, Err: err}
 					})
 			}
@@ -261,11 +331,18 @@ func processBruteJobs(ctx context.Context, doneCh <-chan struct{}, jobCh <-chan
 	return outCh
 }
 
-func runBacktest(ctx context.Context, bot trader.Bot, dealer broker.SimulatedDealer, asset market.Asset, prices []market.Kline) (perf.PerformanceReport, error) {
+// runBacktest streams prices from the reader to the dealer and bot until io.EOF.
+func runBacktest(ctx context.Context, bot trader.Bot, dealer broker.SimulatedDealer, asset market.Asset, prices market.KlineReader, cal *calendar.Calendar) (perf.PerformanceReport, error) {
 	var empty perf.PerformanceReport
 
-	for i := range prices {
-		price := prices[i]
+	for {
+		price, err := prices.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return empty, err
+		}
 		if err := dealer.ReceivePrice(ctx, price); err != nil {
 			return empty, err
 		}
@@ -283,8 +360,9 @@ func runBacktest(ctx context.Context, bot trader.Bot, dealer broker.SimulatedDea
 		return empty, err
 	}
 	equity := dealer.EquityHistory()
-	report := perf.NewPerformanceReport(roundturns, equity)
+	report := perf.NewPerformanceReportWithCalendar(roundturns, equity, cal)
 	report.Asset = asset
+	report.AccountHistory = dealer.AccountHistory()
 
 	return report, nil
 }
@@ -298,3 +376,13 @@ func splitSample(sample []market.Kline, splitPct float64) (is, oos []market.Klin
 	splitIndex = math.Ceil(splitIndex)
 	return sample[:int(splitIndex)], sample[int(splitIndex):]
 }
+
+// splitSource splits a source of count klines into two ranges in the same way as splitSample.
+func splitSource(src market.KlineSource, count int, splitPct float64) (is, oos market.KlineSource) {
+	if splitPct == 0 {
+		return src, src
+	}
+
+	splitIndex := int(math.Ceil(float64(count) * splitPct))
+	return market.NewSubKlineSource(src, 0, splitIndex), market.NewSubKlineSource(src, splitIndex, -1)
+}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/alphakit/perf"
@@ -63,6 +64,28 @@ func TestBruteOptimizer_Prepare(t *testing.T) {
 	}
 }
 
+func TestBruteOptimizer_PrepareStream(t *testing.T) {
+	giveSources := map[AssetID]market.KlineSource{
+		"asset_x": market.SliceKlineSource{{C: dec.New(10)}, {C: dec.New(20)}, {C: dec.New(30)}, {C: dec.New(40)}},
+		"asset_y": market.SliceKlineSource{{C: dec.New(50)}, {C: dec.New(60)}, {C: dec.New(70)}},
+	}
+
+	optimizer := NewBruteOptimizer()
+	optimizer.SampleSplitPct = 0.5
+	steps, err := optimizer.PrepareStream(map[string]any{"A": []any{1, 2}, "B": []any{10}}, giveSources)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, steps)
+	assert.Empty(t, optimizer.study.TrainingSamples)
+
+	for k, want := range map[AssetID][2]int{"asset_x": {2, 2}, "asset_y": {2, 1}} {
+		training, err := market.CountKlines(optimizer.study.TrainingSources[k])
+		assert.NoError(t, err)
+		validation, err := market.CountKlines(optimizer.study.ValidationSources[k])
+		assert.NoError(t, err)
+		assert.Equal(t, want, [2]int{training, validation}, k)
+	}
+}
+
 func TestBruteOptimizer_Start(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -125,9 +148,9 @@ func TestBruteOptimizer_enqueueJobs(t *testing.T) {
 		{ID: "0", Params: map[string]any{"A": 0, "B": 1}},
 		{ID: "1", Params: map[string]any{"Y": 25, "Z": 26}},
 	}
-	giveSamples := map[AssetID][]market.Kline{
-		"asset_x": {{C: dec.New(10)}, {C: dec.New(20)}},
-		"asset_y": {{C: dec.New(30)}, {C: dec.New(40)}, {C: dec.New(50)}},
+	giveSamples := map[AssetID]market.KlineSource{
+		"asset_x": market.SliceKlineSource{{C: dec.New(10)}, {C: dec.New(20)}},
+		"asset_y": market.SliceKlineSource{{C: dec.New(30)}, {C: dec.New(40)}, {C: dec.New(50)}},
 	}
 	want := 4 // Expect 4 enqueued jobs in buffered channel
 
@@ -139,7 +162,7 @@ func TestBruteOptimizer_enqueueJobs(t *testing.T) {
 
 func TestProcessBruteJobs(t *testing.T) {
 
-	giveSample := []market.Kline{{C: dec.New(10)}, {C: dec.New(20)}, {C: dec.New(30)}, {C: dec.New(40)}, {C: dec.New(50)}}
+	giveSample := market.SliceKlineSource{{C: dec.New(10)}, {C: dec.New(20)}, {C: dec.New(30)}, {C: dec.New(40)}, {C: dec.New(50)}}
 	giveMakeBot := func(map[string]any) (trader.Bot, error) { return &trader.StubBot{}, nil }
 	giveMakeDealer := func() (broker.SimulatedDealer, error) { return &broker.StubDealer{}, nil }
 	giveJobCh := make(chan bruteOptimizerJob)
@@ -172,6 +195,55 @@ func TestProcessBruteJobs(t *testing.T) {
 	assert.ElementsMatch(t, want, act)
 }
 
+type dividendDealerForTest struct {
+	broker.StubDealer
+	dividends []market.CorporateAction
+}
+
+func (d *dividendDealerForTest) SetDividends(dividends []market.CorporateAction) {
+	d.dividends = dividends
+}
+
+func TestProcessBruteJobs_Dividends(t *testing.T) {
+	giveSample := market.SliceKlineSource{{C: dec.New(10)}, {C: dec.New(20)}}
+	giveDividends := []market.CorporateAction{{Kind: market.Dividend, Amount: dec.New(1)}}
+	giveMakeBot := func(map[string]any) (trader.Bot, error) { return &trader.StubBot{}, nil }
+
+	tests := []struct {
+		name    string
+		give    broker.SimulatedDealer
+		wantErr bool
+	}{
+		{name: "set on dealer", give: &dividendDealerForTest{}},
+		{name: "dealer without dividends", give: &broker.StubDealer{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobCh := make(chan bruteOptimizerJob, 1)
+			doneCh := make(chan struct{})
+			defer close(doneCh)
+			jobCh <- bruteOptimizerJob{
+				ParamSet: ParamSet{ID: "0"}, Sample: giveSample, MakeBot: giveMakeBot,
+				MakeDealer: func() (broker.SimulatedDealer, error) { return tt.give, nil },
+				Dividends:  giveDividends,
+			}
+			close(jobCh)
+
+			var errs []error
+			for step := range processBruteJobs(context.Background(), doneCh, jobCh, 1) {
+				errs = append(errs, step.Err)
+			}
+			require.Len(t, errs, 1)
+			if tt.wantErr {
+				assert.Error(t, errs[0])
+				return
+			}
+			assert.NoError(t, errs[0])
+			assert.Equal(t, giveDividends, tt.give.(*dividendDealerForTest).dividends)
+		})
+	}
+}
+
 func TestSplitSample(t *testing.T) {
 	tests := []struct {
 		name         string
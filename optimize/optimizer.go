@@ -4,6 +4,9 @@
 // Package optimize provides a set of services for optimizing algo parameters.
 // Parameter optimization is a process of systematically searching for the optimal set of parameters given a target objective.
 // Multiple methods are available, each implementing the Optimizer interface.
+//
+// Optimizers backtest a single asset trader.Bot, running each sample as a separate backtest.
+// Baskets of assets and trader.MultiAssetBot are not supported; backtest those with a market.MultiAssetFeed.
 package optimize
 
 import (
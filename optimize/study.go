@@ -31,10 +31,12 @@ type Study struct {
 
 	Training        []ParamSet
 	TrainingSamples map[AssetID][]market.Kline
+	TrainingSources map[AssetID]market.KlineSource
 	TrainingResults map[ParamSetID]PhaseReport
 
 	Validation        []ParamSet
 	ValidationSamples map[AssetID][]market.Kline
+	ValidationSources map[AssetID]market.KlineSource
 	ValidationResults map[ParamSetID]PhaseReport
 }
 
@@ -44,8 +46,10 @@ func NewStudy() *Study {
 	return &Study{
 		ID:                string(id.New()),
 		TrainingSamples:   make(map[AssetID][]market.Kline),
+		TrainingSources:   make(map[AssetID]market.KlineSource),
 		TrainingResults:   make(map[ParamSetID]PhaseReport),
 		ValidationSamples: make(map[AssetID][]market.Kline),
+		ValidationSources: make(map[AssetID]market.KlineSource),
 		ValidationResults: make(map[ParamSetID]PhaseReport),
 	}
 }
@@ -24,9 +24,11 @@ import (
 // - roundturns.csv: turns completed for each trial
 //
 // - curve.csv: equity curve for each trial
+//
+// - accounts.csv: account snapshots (cash, exposure, leverage, fees) for each trial
 func WriteStudyResultToCSV(path string, study *Study) error {
 
-	phaseReports, trialReports, roundturns, curves := prepareStudyForCSV(study)
+	phaseReports, trialReports, roundturns, curves, accounts := prepareStudyForCSV(study)
 
 	prefix := study.ID
 
@@ -50,6 +52,11 @@ func WriteStudyResultToCSV(path string, study *Study) error {
 		return err
 	}
 
+	out = filepath.Join(path, fmt.Sprintf("%s-accounts.csv", prefix))
+	if err := saveDataToCSV(out, accounts); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -81,13 +88,22 @@ type curveDetailRow struct {
 	Amount        float64   `csv:"amount"`
 }
 
+type accountDetailRow struct {
+	StudyID       string                 `csv:"study_id"`
+	PhaseReportID string                 `csv:"phasereport_id"`
+	BacktestID    string                 `csv:"backtest_id"`
+	Time          time.Time              `csv:"time"`
+	Snapshot      broker.AccountSnapshot `csv:",inline"`
+}
+
 // prepareStudyForCSV returns data that is ready for saving to CSV.
-func prepareStudyForCSV(study *Study) ([]phaseReport, []trialReport, []roundturnDetailRow, []curveDetailRow) {
+func prepareStudyForCSV(study *Study) ([]phaseReport, []trialReport, []roundturnDetailRow, []curveDetailRow, []accountDetailRow) {
 
 	var phaseReports []phaseReport
 	var trialReports []trialReport
 	var tradeRows []roundturnDetailRow
 	var curveRows []curveDetailRow
+	var accountRows []accountDetailRow
 
 	flattenResults := func(results map[ParamSetID]PhaseReport) {
 		for k := range results {
@@ -126,6 +142,16 @@ func prepareStudyForCSV(study *Study) ([]phaseReport, []trialReport, []roundturn
 						Amount:        curve[k].InexactFloat64(),
 					})
 				}
+				accounts := trial.AccountHistory
+				for _, k := range accounts.SortKeys() {
+					accountRows = append(accountRows, accountDetailRow{
+						StudyID:       study.ID,
+						PhaseReportID: report.ID,
+						BacktestID:    trial.ID,
+						Time:          k.Time(),
+						Snapshot:      accounts[k],
+					})
+				}
 			}
 		}
 	}
@@ -133,7 +159,7 @@ func prepareStudyForCSV(study *Study) ([]phaseReport, []trialReport, []roundturn
 	flattenResults(study.TrainingResults)
 	flattenResults(study.ValidationResults)
 
-	return phaseReports, trialReports, tradeRows, curveRows
+	return phaseReports, trialReports, tradeRows, curveRows, accountRows
 }
 
 func saveDataToCSV(filename string, data interface{}) error {
@@ -0,0 +1,158 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"math"
+)
+
+// Model is an option pricing model.
+type Model int
+
+const (
+	// BlackScholes prices an option on a spot underlying, with an optional continuous dividend yield.
+	BlackScholes Model = iota
+
+	// Black76 prices an option on a forward or futures underlying.
+	Black76
+)
+
+// Inputs are the inputs to price an option with a model.
+type Inputs struct {
+	// Underlying is the spot price for BlackScholes or the forward price for Black76.
+	Underlying float64
+	Strike     float64
+
+	// Time is the time to expiry in years.
+	Time float64
+
+	// Rate is the continuously compounded risk-free rate, e.g. 0.05 for 5%.
+	Rate float64
+
+	// Yield is the continuous dividend yield of the underlying, or the foreign rate of a currency.
+	// Used by BlackScholes only.
+	Yield float64
+
+	// Vol is the annualized volatility of the underlying, e.g. 0.2 for 20%.
+	Vol float64
+}
+
+// Greeks are the sensitivities of an option value.
+type Greeks struct {
+	// Delta is the change in value per unit change in the underlying price.
+	Delta float64
+
+	// Gamma is the change in delta per unit change in the underlying price.
+	Gamma float64
+
+	// Vega is the change in value per unit change in volatility, i.e. divide by 100 for a change of 1%.
+	Vega float64
+
+	// Theta is the change in value per year of time passing, i.e. divide by 365 for a day.
+	Theta float64
+
+	// Rho is the change in value per unit change in the rate.
+	Rho float64
+}
+
+// Price returns the value of a European option.
+// An expired option, or one with zero volatility, is valued at its discounted intrinsic value.
+func Price(model Model, right Right, in Inputs) float64 {
+	carry, discount := model.carry(in), math.Exp(-in.Rate*in.Time)
+	forward := in.Underlying * math.Exp(carry*in.Time)
+	if in.Time <= 0 || in.Vol <= 0 {
+		return discount * intrinsic(right, forward, in.Strike)
+	}
+
+	d1, d2 := d(in, carry)
+	switch right {
+	case Call:
+		return discount * (forward*cdf(d1) - in.Strike*cdf(d2))
+	case Put:
+		return discount * (in.Strike*cdf(-d2) - forward*cdf(-d1))
+	}
+	return 0
+}
+
+// ComputeGreeks returns the Greeks of a European option.
+// An expired option, or one with zero volatility, has a delta of 0 or ±1 and zero other Greeks.
+func ComputeGreeks(model Model, right Right, in Inputs) Greeks {
+	carry := model.carry(in)
+	// Discount factor of the underlying, the dividend yield for BlackScholes or the rate for Black76
+	carryDiscount := math.Exp((carry - in.Rate) * in.Time)
+	discount := math.Exp(-in.Rate * in.Time)
+
+	if in.Time <= 0 || in.Vol <= 0 {
+		var g Greeks
+		forward := in.Underlying * math.Exp(carry*in.Time)
+		if intrinsic(right, forward, in.Strike) > 0 {
+			g.Delta = carryDiscount
+			if right == Put {
+				g.Delta = -carryDiscount
+			}
+		}
+		return g
+	}
+
+	d1, d2 := d(in, carry)
+	sqrtT := math.Sqrt(in.Time)
+	density := carryDiscount * pdf(d1)
+	g := Greeks{
+		Gamma: density / (in.Underlying * in.Vol * sqrtT),
+		Vega:  in.Underlying * density * sqrtT,
+	}
+	decay := -in.Underlying * density * in.Vol / (2 * sqrtT)
+
+	switch right {
+	case Call:
+		g.Delta = carryDiscount * cdf(d1)
+		g.Theta = decay - (carry-in.Rate)*in.Underlying*carryDiscount*cdf(d1) - in.Rate*in.Strike*discount*cdf(d2)
+		g.Rho = in.Time * in.Strike * discount * cdf(d2)
+	case Put:
+		g.Delta = carryDiscount * (cdf(d1) - 1)
+		g.Theta = decay + (carry-in.Rate)*in.Underlying*carryDiscount*cdf(-d1) + in.Rate*in.Strike*discount*cdf(-d2)
+		g.Rho = -in.Time * in.Strike * discount * cdf(-d2)
+	}
+
+	// The forward price of Black76 does not depend on the rate, so only the discounting of the value does
+	if model == Black76 {
+		g.Rho = -in.Time * Price(model, right, in)
+	}
+
+	return g
+}
+
+// carry returns the cost of carry of the underlying: the rate less the yield for BlackScholes, zero for Black76.
+func (m Model) carry(in Inputs) float64 {
+	if m == Black76 {
+		return 0
+	}
+	return in.Rate - in.Yield
+}
+
+func d(in Inputs, carry float64) (float64, float64) {
+	volT := in.Vol * math.Sqrt(in.Time)
+	d1 := (math.Log(in.Underlying/in.Strike) + (carry+in.Vol*in.Vol/2)*in.Time) / volT
+	return d1, d1 - volT
+}
+
+func intrinsic(right Right, underlying, strike float64) float64 {
+	switch right {
+	case Call:
+		return math.Max(0, underlying-strike)
+	case Put:
+		return math.Max(0, strike-underlying)
+	}
+	return 0
+}
+
+// cdf is the standard normal cumulative distribution function.
+func cdf(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// pdf is the standard normal probability density function.
+func pdf(x float64) float64 {
+	return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveModel Model
+		giveRight Right
+		give      Inputs
+		want      float64
+	}{
+		{
+			name:      "black-scholes call",
+			giveModel: BlackScholes,
+			giveRight: Call,
+			give:      Inputs{Underlying: 100, Strike: 100, Time: 1, Rate: 0.05, Vol: 0.2},
+			want:      10.450583572185565,
+		},
+		{
+			name:      "black-scholes put",
+			giveModel: BlackScholes,
+			giveRight: Put,
+			give:      Inputs{Underlying: 100, Strike: 100, Time: 1, Rate: 0.05, Vol: 0.2},
+			want:      5.573526022256971,
+		},
+		{
+			name:      "black-scholes put with yield",
+			giveModel: BlackScholes,
+			giveRight: Put,
+			give:      Inputs{Underlying: 100, Strike: 95, Time: 0.5, Rate: 0.1, Yield: 0.05, Vol: 0.2},
+			want:      2.4648,
+		},
+		{
+			name:      "black-76 call",
+			giveModel: Black76,
+			giveRight: Call,
+			give:      Inputs{Underlying: 19, Strike: 19, Time: 0.75, Rate: 0.1, Vol: 0.28},
+			want:      1.7011,
+		},
+		{
+			name:      "expired put is intrinsic",
+			giveModel: BlackScholes,
+			giveRight: Put,
+			give:      Inputs{Underlying: 90, Strike: 100, Vol: 0.2},
+			want:      10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act := Price(tt.giveModel, tt.giveRight, tt.give)
+			assert.InDelta(t, tt.want, act, 1e-4)
+		})
+	}
+}
+
+func TestPrice_PutCallParity(t *testing.T) {
+	in := Inputs{Underlying: 105, Strike: 100, Time: 0.25, Rate: 0.03, Yield: 0.01, Vol: 0.6}
+	call, put := Price(BlackScholes, Call, in), Price(BlackScholes, Put, in)
+	want := in.Underlying*math.Exp(-in.Yield*in.Time) - in.Strike*math.Exp(-in.Rate*in.Time)
+	assert.InDelta(t, want, call-put, 1e-9)
+
+	call, put = Price(Black76, Call, in), Price(Black76, Put, in)
+	assert.InDelta(t, math.Exp(-in.Rate*in.Time)*(in.Underlying-in.Strike), call-put, 1e-9)
+}
+
+func TestComputeGreeks(t *testing.T) {
+	// Each Greek is compared to a central finite difference of the price
+	in := Inputs{Underlying: 100, Strike: 110, Time: 0.5, Rate: 0.04, Yield: 0.02, Vol: 0.3}
+	const h = 1e-4
+	bump := func(model Model, right Right, f func(*Inputs, float64)) float64 {
+		up, down := in, in
+		f(&up, h)
+		f(&down, -h)
+		return (Price(model, right, up) - Price(model, right, down)) / (2 * h)
+	}
+
+	for _, model := range []Model{BlackScholes, Black76} {
+		for _, right := range []Right{Call, Put} {
+			act := ComputeGreeks(model, right, in)
+			name := right.String()
+			assert.InDelta(t, bump(model, right, func(in *Inputs, d float64) { in.Underlying += d }), act.Delta, 1e-6, name)
+			assert.InDelta(t, bump(model, right, func(in *Inputs, d float64) { in.Vol += d }), act.Vega, 1e-4, name)
+			assert.InDelta(t, -bump(model, right, func(in *Inputs, d float64) { in.Time += d }), act.Theta, 1e-4, name)
+			assert.InDelta(t, bump(model, right, func(in *Inputs, d float64) { in.Rate += d }), act.Rho, 1e-4, name)
+
+			up, down := in, in
+			up.Underlying += h
+			down.Underlying -= h
+			gamma := (ComputeGreeks(model, right, up).Delta - ComputeGreeks(model, right, down).Delta) / (2 * h)
+			assert.InDelta(t, gamma, act.Gamma, 1e-6, name)
+		}
+	}
+}
+
+func TestComputeGreeks_Expired(t *testing.T) {
+	act := ComputeGreeks(BlackScholes, Put, Inputs{Underlying: 90, Strike: 100})
+	assert.Equal(t, Greeks{Delta: -1}, act)
+
+	act = ComputeGreeks(BlackScholes, Call, Inputs{Underlying: 90, Strike: 100})
+	assert.Equal(t, Greeks{}, act)
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrNoImpliedVol is returned when no volatility prices an option at the given price,
+// typically because the price is outside the no-arbitrage bounds of the option.
+var ErrNoImpliedVol = errors.New("no implied volatility for option price")
+
+const (
+	_minImpliedVol       = 1e-6
+	_maxImpliedVol       = 10.0
+	_impliedVolTolerance = 1e-10
+	_impliedVolMaxIter   = 100
+)
+
+// ImpliedVol returns the volatility at which the model prices the option at the given price.
+// The Vol of the inputs is used as the first guess, defaulting to 0.5 if not positive.
+// Solved by Newton-Raphson, falling back to bisection where a Newton step leaves the bracketing interval.
+func ImpliedVol(model Model, right Right, in Inputs, price float64) (float64, error) {
+	if in.Time <= 0 {
+		return 0, fmt.Errorf("%w: option has expired", ErrNoImpliedVol)
+	}
+
+	lo, hi := _minImpliedVol, _maxImpliedVol
+	at := func(vol float64) float64 {
+		in.Vol = vol
+		return Price(model, right, in)
+	}
+	if price < at(lo) || price > at(hi) {
+		return 0, fmt.Errorf("%w: price %f is outside bounds [%f, %f]", ErrNoImpliedVol, price, at(lo), at(hi))
+	}
+
+	vol := in.Vol
+	if vol <= lo || vol >= hi {
+		vol = 0.5
+	}
+	for i := 0; i < _impliedVolMaxIter; i++ {
+		diff := at(vol) - price
+		if math.Abs(diff) < _impliedVolTolerance {
+			return vol, nil
+		}
+		// Price increases with volatility, so the root is bracketed between lo and hi
+		if diff > 0 {
+			hi = vol
+		} else {
+			lo = vol
+		}
+		in.Vol = vol
+		vega := ComputeGreeks(model, right, in).Vega
+		next := vol - diff/vega
+		if vega <= 0 || next <= lo || next >= hi {
+			next = (lo + hi) / 2
+		}
+		vol = next
+	}
+	if hi-lo > 1e-8 {
+		return 0, fmt.Errorf("%w: did not converge", ErrNoImpliedVol)
+	}
+	return vol, nil
+}
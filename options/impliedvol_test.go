@@ -0,0 +1,48 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpliedVol(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveModel Model
+		giveRight Right
+		give      Inputs
+	}{
+		{name: "at the money call", giveModel: BlackScholes, giveRight: Call, give: Inputs{Underlying: 100, Strike: 100, Time: 1, Rate: 0.05, Vol: 0.2}},
+		{name: "out of the money put", giveModel: BlackScholes, giveRight: Put, give: Inputs{Underlying: 100, Strike: 70, Time: 0.1, Vol: 0.9}},
+		{name: "deep in the money call", giveModel: Black76, giveRight: Call, give: Inputs{Underlying: 40000, Strike: 20000, Time: 0.25, Vol: 0.8}},
+		{name: "high vol", giveModel: Black76, giveRight: Put, give: Inputs{Underlying: 100, Strike: 120, Time: 0.02, Vol: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := Price(tt.giveModel, tt.giveRight, tt.give)
+			guess := tt.give
+			guess.Vol = 0
+			act, err := ImpliedVol(tt.giveModel, tt.giveRight, guess, price)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.give.Vol, act, 1e-6)
+		})
+	}
+}
+
+func TestImpliedVol_NoSolution(t *testing.T) {
+	in := Inputs{Underlying: 100, Strike: 100, Time: 1}
+
+	_, err := ImpliedVol(BlackScholes, Call, in, 101)
+	assert.ErrorIs(t, err, ErrNoImpliedVol)
+
+	_, err = ImpliedVol(BlackScholes, Call, Inputs{Underlying: 120, Strike: 100, Time: 1}, 10)
+	assert.ErrorIs(t, err, ErrNoImpliedVol)
+
+	in.Time = 0
+	_, err = ImpliedVol(BlackScholes, Call, in, 5)
+	assert.ErrorIs(t, err, ErrNoImpliedVol)
+}
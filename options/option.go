@@ -0,0 +1,70 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package options provides European option instruments with Black-Scholes and Black-76 pricing,
+// implied volatility solving and Greeks.
+// A Pricer values an option from the price of its underlying and a volatility surface,
+// so that the backtest simulator can mark and settle option positions from an underlying price series.
+package options
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// _daysPerYear is the number of days in a year used to measure time to expiry, for markets that trade every day.
+const _daysPerYear = 365
+
+// Right is the right of an option holder to buy (call) or sell (put) the underlying.
+type Right int
+
+const (
+	// Call is the right to buy the underlying at the strike.
+	Call Right = iota + 1
+
+	// Put is the right to sell the underlying at the strike.
+	Put
+)
+
+func (r Right) String() string {
+	return [...]string{"", "C", "P"}[r]
+}
+
+// Option is a European option on an underlying asset, exercised only at expiry.
+type Option struct {
+	Underlying market.Asset
+	Strike     decimal.Decimal
+	Expiry     time.Time
+	Right      Right
+}
+
+// Asset returns the option as a tradable asset, with a symbol in the form BTC-25MAR22-40000-C.
+func (o Option) Asset() market.Asset {
+	return market.NewAsset(fmt.Sprintf("%s-%s-%s-%s",
+		o.Underlying.Symbol, strings.ToUpper(o.Expiry.UTC().Format("2Jan06")), o.Strike.String(), o.Right))
+}
+
+// Intrinsic returns the value of exercising the option at the underlying price.
+func (o Option) Intrinsic(underlying decimal.Decimal) decimal.Decimal {
+	var value decimal.Decimal
+	switch o.Right {
+	case Call:
+		value = underlying.Sub(o.Strike)
+	case Put:
+		value = o.Strike.Sub(underlying)
+	}
+	if value.IsNegative() {
+		return decimal.Zero
+	}
+	return value
+}
+
+// YearsToExpiry returns the time from at to expiry in years of 365 days. Zero if expired.
+func (o Option) YearsToExpiry(at time.Time) float64 {
+	return math.Max(0, o.Expiry.Sub(at).Hours()/24/_daysPerYear)
+}
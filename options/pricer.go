@@ -0,0 +1,89 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// Pricer values options from the price of their underlying with a model, rates and a volatility surface.
+type Pricer struct {
+	Model Model
+
+	// Rate is the continuously compounded risk-free rate.
+	Rate float64
+
+	// Yield is the continuous dividend yield of the underlying, for BlackScholes.
+	Yield float64
+
+	// Surface is the implied volatility of the underlying.
+	Surface VolSurface
+}
+
+// NewPricer creates a new Pricer with a zero rate and yield.
+func NewPricer(model Model, surface VolSurface) *Pricer {
+	return &Pricer{
+		Model:   model,
+		Surface: surface,
+	}
+}
+
+// Inputs returns the model inputs to value the option at the underlying price and time.
+func (p *Pricer) Inputs(o Option, underlying decimal.Decimal, at time.Time) Inputs {
+	strike := o.Strike.InexactFloat64()
+	years := o.YearsToExpiry(at)
+	return Inputs{
+		Underlying: underlying.InexactFloat64(),
+		Strike:     strike,
+		Time:       years,
+		Rate:       p.Rate,
+		Yield:      p.Yield,
+		Vol:        p.Surface.Vol(strike, years),
+	}
+}
+
+// Price returns the value of the option at the underlying price and time.
+// Valued at intrinsic value from expiry.
+func (p *Pricer) Price(o Option, underlying decimal.Decimal, at time.Time) decimal.Decimal {
+	if !at.Before(o.Expiry) {
+		return o.Intrinsic(underlying)
+	}
+	return decimal.NewFromFloat(Price(p.Model, o.Right, p.Inputs(o, underlying, at)))
+}
+
+// Greeks returns the Greeks of the option at the underlying price and time.
+func (p *Pricer) Greeks(o Option, underlying decimal.Decimal, at time.Time) Greeks {
+	return ComputeGreeks(p.Model, o.Right, p.Inputs(o, underlying, at))
+}
+
+// MarkKline converts a kline of the underlying into a kline of the option valued at its start.
+// Open and close are the values at the underlying open and close, and the high and low the range of the values
+// at each underlying price, as a put is valued highest at the underlying low. Volumes are zero.
+func (p *Pricer) MarkKline(o Option, k market.Kline) market.Kline {
+	values := []decimal.Decimal{
+		p.Price(o, k.O, k.Start),
+		p.Price(o, k.H, k.Start),
+		p.Price(o, k.L, k.Start),
+		p.Price(o, k.C, k.Start),
+	}
+	marked := market.Kline{Start: k.Start, O: values[0], H: values[0], L: values[0], C: values[3], Synthetic: k.Synthetic}
+	for _, v := range values[1:] {
+		marked.H = decimal.Max(marked.H, v)
+		marked.L = decimal.Min(marked.L, v)
+	}
+	return marked
+}
+
+// ImpliedVol returns the volatility at which the option is valued at the price, for the underlying price and time.
+func (p *Pricer) ImpliedVol(o Option, price, underlying decimal.Decimal, at time.Time) (float64, error) {
+	in := p.Inputs(o, underlying, at)
+	if math.IsNaN(in.Vol) || in.Vol <= 0 {
+		in.Vol = 0
+	}
+	return ImpliedVol(p.Model, o.Right, in, price.InexactFloat64())
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func optionForPricerTest(right Right) Option {
+	return Option{
+		Underlying: market.NewAsset("BTC"),
+		Strike:     dec.New(40000),
+		Expiry:     time.Date(2022, time.March, 25, 8, 0, 0, 0, time.UTC),
+		Right:      right,
+	}
+}
+
+func TestOption_Asset(t *testing.T) {
+	assert.Equal(t, "BTC-25MAR22-40000-C", optionForPricerTest(Call).Asset().Symbol)
+	assert.Equal(t, "BTC-25MAR22-40000-P", optionForPricerTest(Put).Asset().Symbol)
+}
+
+func TestPricer_Price(t *testing.T) {
+	pricer := NewPricer(Black76, FlatSurface(0.8))
+	put := optionForPricerTest(Put)
+	at := put.Expiry.AddDate(0, 0, -73)
+
+	act := pricer.Price(put, dec.New(38000), at)
+	want := Price(Black76, Put, Inputs{Underlying: 38000, Strike: 40000, Time: 0.2, Vol: 0.8})
+	assert.InDelta(t, want, act.InexactFloat64(), 1e-6)
+
+	// Intrinsic value from expiry
+	assert.True(t, dec.New(2000).Equal(pricer.Price(put, dec.New(38000), put.Expiry)))
+	assert.True(t, pricer.Price(optionForPricerTest(Call), dec.New(38000), put.Expiry).IsZero())
+
+	vol, err := pricer.ImpliedVol(put, act, dec.New(38000), at)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, vol, 1e-6)
+
+	assert.Less(t, pricer.Greeks(put, dec.New(38000), at).Delta, 0.0)
+}
+
+func TestPricer_MarkKline(t *testing.T) {
+	pricer := NewPricer(BlackScholes, FlatSurface(0.6))
+	put := optionForPricerTest(Put)
+	k := market.Kline{
+		Start: put.Expiry.AddDate(0, 0, -30),
+		O:     dec.New(40000), H: dec.New(42000), L: dec.New(37000), C: dec.New(41000),
+		Volume: 10,
+	}
+
+	act := pricer.MarkKline(put, k)
+	assert.True(t, k.Start.Equal(act.Start))
+	assert.True(t, pricer.Price(put, k.O, k.Start).Equal(act.O))
+	assert.True(t, pricer.Price(put, k.C, k.Start).Equal(act.C))
+	assert.True(t, pricer.Price(put, k.L, k.Start).Equal(act.H), "put is highest at the underlying low")
+	assert.True(t, pricer.Price(put, k.H, k.Start).Equal(act.L), "put is lowest at the underlying high")
+	assert.Zero(t, act.Volume)
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// ErrInvalidSurface is returned when a volatility surface grid is malformed.
+var ErrInvalidSurface = errors.New("invalid volatility surface")
+
+var _ VolSurface = FlatSurface(0)
+var _ VolSurface = (*GridSurface)(nil)
+
+// VolSurface returns the implied volatility of an option with the strike and time to expiry in years.
+type VolSurface interface {
+	Vol(strike, years float64) float64
+}
+
+// FlatSurface is the same volatility for every strike and expiry,
+// such as the realized volatility of the underlying measured by RealizedVol.
+type FlatSurface float64
+
+// Vol returns the flat volatility.
+func (s FlatSurface) Vol(strike, years float64) float64 {
+	return float64(s)
+}
+
+// GridSurface interpolates implied volatilities quoted on a grid of strikes and expiries.
+// Volatility is interpolated linearly in strike, and in total variance (vol² × time) between expiries.
+// Beyond the grid the volatility of the nearest strike or expiry is used.
+type GridSurface struct {
+	strikes []float64
+	years   []float64
+	vols    [][]float64
+}
+
+// NewGridSurface creates a new GridSurface from volatilities indexed by [expiry][strike].
+// Strikes and expiries, in years, must be increasing.
+func NewGridSurface(strikes, years []float64, vols [][]float64) (*GridSurface, error) {
+	if len(strikes) == 0 || len(years) == 0 || len(vols) != len(years) {
+		return nil, fmt.Errorf("%w: grid must have a row of vols for each expiry", ErrInvalidSurface)
+	}
+	for i, row := range vols {
+		if len(row) != len(strikes) {
+			return nil, fmt.Errorf("%w: row %d must have a vol for each strike", ErrInvalidSurface, i)
+		}
+	}
+	if !sort.Float64sAreSorted(strikes) || !sort.Float64sAreSorted(years) {
+		return nil, fmt.Errorf("%w: strikes and expiries must be increasing", ErrInvalidSurface)
+	}
+	return &GridSurface{strikes: strikes, years: years, vols: vols}, nil
+}
+
+// Vol returns the interpolated volatility.
+func (s *GridSurface) Vol(strike, years float64) float64 {
+	i, w := bracket(s.years, years)
+	if w == 0 {
+		return s.smile(i, strike)
+	}
+	// Interpolate total variance, which increases with time for an arbitrage-free surface
+	v0 := s.smile(i, strike)
+	v1 := s.smile(i+1, strike)
+	t0, t1 := s.years[i], s.years[i+1]
+	variance := (1-w)*v0*v0*t0 + w*v1*v1*t1
+	return math.Sqrt(variance / years)
+}
+
+// smile returns the volatility at the strike for the expiry at index i.
+func (s *GridSurface) smile(i int, strike float64) float64 {
+	j, w := bracket(s.strikes, strike)
+	if w == 0 {
+		return s.vols[i][j]
+	}
+	return (1-w)*s.vols[i][j] + w*s.vols[i][j+1]
+}
+
+// bracket returns the index of the last grid point at or before x and the weight of the next point,
+// zero if x is beyond either end of the grid.
+func bracket(grid []float64, x float64) (int, float64) {
+	if x <= grid[0] {
+		return 0, 0
+	}
+	if x >= grid[len(grid)-1] {
+		return len(grid) - 1, 0
+	}
+	i := sort.SearchFloat64s(grid, x)
+	if grid[i] == x {
+		return i, 0
+	}
+	return i - 1, (x - grid[i-1]) / (grid[i] - grid[i-1])
+}
+
+// RealizedVol returns the annualized standard deviation of the log returns of the kline closes,
+// given the interval between klines, using a year of 365 days.
+func RealizedVol(klines []market.Kline, interval time.Duration) float64 {
+	if len(klines) < 3 || interval <= 0 {
+		return 0
+	}
+	returns := make([]float64, 0, len(klines)-1)
+	var mean float64
+	for i := 1; i < len(klines); i++ {
+		r := math.Log(klines[i].C.InexactFloat64() / klines[i-1].C.InexactFloat64())
+		returns = append(returns, r)
+		mean += r
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	periodsPerYear := float64(_daysPerYear*24*time.Hour) / float64(interval)
+	return math.Sqrt(variance * periodsPerYear)
+}
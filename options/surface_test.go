@@ -0,0 +1,69 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package options
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestGridSurface_Vol(t *testing.T) {
+	surface, err := NewGridSurface(
+		[]float64{80, 100, 120},
+		[]float64{0.25, 1},
+		[][]float64{
+			{0.6, 0.5, 0.55},
+			{0.5, 0.4, 0.45},
+		},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		giveStrike float64
+		giveYears  float64
+		want       float64
+	}{
+		{name: "grid point", giveStrike: 100, giveYears: 0.25, want: 0.5},
+		{name: "between strikes", giveStrike: 90, giveYears: 1, want: 0.45},
+		{name: "beyond strikes", giveStrike: 200, giveYears: 1, want: 0.45},
+		{name: "before first expiry", giveStrike: 80, giveYears: 0.1, want: 0.6},
+		{name: "after last expiry", giveStrike: 80, giveYears: 2, want: 0.5},
+		// Total variance midway between 0.5² × 0.25 and 0.4² × 1
+		{name: "between expiries", giveStrike: 100, giveYears: 0.625, want: math.Sqrt((0.5*0.5*0.25 + 0.4*0.4*1) / 2 / 0.625)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, surface.Vol(tt.giveStrike, tt.giveYears), 1e-9)
+		})
+	}
+}
+
+func TestNewGridSurface_Invalid(t *testing.T) {
+	_, err := NewGridSurface([]float64{100, 80}, []float64{1}, [][]float64{{0.5, 0.5}})
+	assert.ErrorIs(t, err, ErrInvalidSurface)
+
+	_, err = NewGridSurface([]float64{80, 100}, []float64{1}, [][]float64{{0.5}})
+	assert.ErrorIs(t, err, ErrInvalidSurface)
+}
+
+func TestRealizedVol(t *testing.T) {
+	// Closes alternate by a log return of ±1%, a daily standard deviation of about 1%
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var klines []market.Kline
+	for i := 0; i < 101; i++ {
+		c := 100 * math.Exp(0.01*float64(i%2))
+		klines = append(klines, market.Kline{Start: start.AddDate(0, 0, i), C: dec.New(c)})
+	}
+
+	act := RealizedVol(klines, 24*time.Hour)
+	assert.InDelta(t, 0.01*math.Sqrt(100.0/99)*math.Sqrt(365), act, 1e-6)
+	assert.Zero(t, RealizedVol(klines[:2], 24*time.Hour))
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/alphakit/market"
 	"github.com/thecolngroup/gou/id"
 )
@@ -44,14 +45,23 @@ type PerformanceReport struct {
 	TradeReport     *TradeReport     `csv:",inline"`
 	PortfolioReport *PortfolioReport `csv:",inline"`
 	Properties      map[string]any   `csv:"properties"`
+
+	// AccountHistory is the optional series of account snapshots recorded by the dealer.
+	AccountHistory broker.AccountSeries `csv:"-"`
 }
 
 // NewPerformanceReport creates a new PerformanceReport.
 func NewPerformanceReport(roundturns []broker.RoundTurn, equity broker.EquitySeries) PerformanceReport {
+	return NewPerformanceReportWithCalendar(roundturns, equity, nil)
+}
+
+// NewPerformanceReportWithCalendar creates a new PerformanceReport with a portfolio report measured
+// by the sessions of the trading calendar. See NewPortfolioReportWithCalendar.
+func NewPerformanceReportWithCalendar(roundturns []broker.RoundTurn, equity broker.EquitySeries, cal *calendar.Calendar) PerformanceReport {
 	return PerformanceReport{
 		ID:              string(id.New()),
 		TradeReport:     NewTradeReport(roundturns),
-		PortfolioReport: NewPortfolioReport(equity),
+		PortfolioReport: NewPortfolioReportWithCalendar(equity, cal),
 		Properties:      make(map[string]any),
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/gou/num"
 )
 
@@ -65,6 +66,13 @@ type PortfolioReport struct {
 
 // NewPortfolioReport creates a new PortfolioReport from a given equity curve.
 func NewPortfolioReport(curve broker.EquitySeries) *PortfolioReport {
+	return NewPortfolioReportWithCalendar(curve, nil)
+}
+
+// NewPortfolioReportWithCalendar creates a new PortfolioReport that measures daily returns from session close
+// to session close of the trading calendar, and annualizes them and the CAGR by the sessions per year of the calendar.
+// A nil calendar is equivalent to NewPortfolioReport.
+func NewPortfolioReportWithCalendar(curve broker.EquitySeries, cal *calendar.Calendar) *PortfolioReport {
 	if len(curve) == 0 {
 		return nil
 	}
@@ -79,9 +87,17 @@ func NewPortfolioReport(curve broker.EquitySeries) *PortfolioReport {
 	report.Period = report.PeriodEnd.Sub(report.PeriodStart)
 
 	report.EquityReturn = (report.EndEquity - report.StartEquity) / num.NNZ(report.StartEquity, 1)
-	report.CAGR = num.NN(CAGR(report.StartEquity, report.EndEquity, int(report.Period.Hours())/24), 0)
-
 	daily := ReduceEOD(curve)
+	var annualFactor float64 = DailyToAnnualFactor
+	if cal == nil {
+		report.CAGR = num.NN(CAGR(report.StartEquity, report.EndEquity, int(report.Period.Hours())/24), 0)
+	} else {
+		daily = ReduceSessionClose(curve, cal)
+		annualFactor = cal.SessionsPerYear
+		sessions := len(cal.Sessions(report.PeriodStart, report.PeriodEnd))
+		report.CAGR = num.NN(CAGRWithFactor(report.StartEquity, report.EndEquity, sessions, annualFactor), 0)
+	}
+
 	if len(daily) == 0 {
 		return &report
 	}
@@ -94,8 +110,8 @@ func NewPortfolioReport(curve broker.EquitySeries) *PortfolioReport {
 	returns := SimpleReturns(daily)
 	report.DailySimpleReturns = returns
 
-	report.HistVolAnn = HistVolAnn(returns)
-	report.Sharpe = SharpeRatio(returns, SharpeDefaultAnnualRiskFreeRate)
+	report.HistVolAnn = HistVolAnnWithFactor(returns, annualFactor)
+	report.Sharpe = SharpeRatioWithFactor(returns, SharpeDefaultAnnualRiskFreeRate, annualFactor)
 	report.Calmar = CalmarRatio(report.CAGR, report.MaxDrawdown)
 
 	return &report
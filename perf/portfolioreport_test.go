@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/gou/dec"
 )
 
@@ -37,3 +38,25 @@ func TestPortfolioReport(t *testing.T) {
 	assert.Equal(t, want.EndEquity, act.EndEquity)
 	assert.Equal(t, want.EquityReturn, act.EquityReturn)
 }
+
+func TestPortfolioReportWithCalendar(t *testing.T) {
+	nyse := calendar.NewNYSECalendar()
+	at := func(day int) broker.Timestamp {
+		return broker.Timestamp(time.Date(2022, time.January, day, 15, 0, 0, 0, nyse.Location).UnixMilli())
+	}
+	// Monday to the following Monday is 5 sessions
+	give := broker.EquitySeries{
+		at(3):  dec.New(100),
+		at(5):  dec.New(102),
+		at(8):  dec.New(90), // Saturday ignored
+		at(10): dec.New(105),
+	}
+
+	act := NewPortfolioReportWithCalendar(give, nyse)
+	assert.InDelta(t, CAGRWithFactor(100, 105, 5, 252), act.CAGR, 1e-9)
+	assert.Equal(t, []float64{0.02, 105.0/102 - 1}, act.DailySimpleReturns)
+
+	// Without a calendar CAGR is measured over 7 calendar days
+	act = NewPortfolioReport(give)
+	assert.InDelta(t, CAGR(100, 105, 7), act.CAGR, 1e-9)
+}
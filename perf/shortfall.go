@@ -0,0 +1,260 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package perf
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/shopspring/decimal"
+	"gonum.org/v1/gonum/stat"
+
+	"github.com/thecolngroup/alphakit/broker"
+)
+
+var _shortfallReportHeader = []string{
+	"matched",
+	"unmatched live",
+	"unmatched backtest",
+	"slippage bps",
+	"delay",
+	"price",
+	"fees",
+	"total",
+}
+
+// FillMatch pairs a live fill with the backtest fill it is reconciled against.
+// Differences are signed so that a positive value is a cost to the live account.
+type FillMatch struct {
+	Live     broker.Order `csv:"live_,inline"`
+	Backtest broker.Order `csv:"backtest_,inline"`
+
+	// PriceDiff is the adverse difference in fill price per unit:
+	// live less backtest for a buy, backtest less live for a sell.
+	PriceDiff float64 `csv:"price_diff"`
+
+	// SlippageBps is PriceDiff as basis points of the backtest fill price.
+	SlippageBps float64 `csv:"slippage_bps"`
+
+	// PriceCost is PriceDiff multiplied by the live filled size.
+	PriceCost float64 `csv:"price_cost"`
+
+	// FeeCost is the live fee less the backtest fee.
+	FeeCost float64 `csv:"fee_cost"`
+
+	// Delay is the live fill time less the backtest fill time.
+	Delay time.Duration `csv:"delay"`
+}
+
+// ShortfallReport reconciles live (or paper) fills with the fills of a backtest of the same bot over the same period.
+// It breaks down the implementation shortfall, the difference in outcome between the simulation and reality,
+// into price, fee and missed trade components.
+type ShortfallReport struct {
+	MatchedCount           int
+	UnmatchedLiveCount     int
+	UnmatchedBacktestCount int
+
+	// PriceShortfall is the total cost of worse fill prices in the live account.
+	PriceShortfall float64
+
+	// FeeShortfall is the total cost of higher fees in the live account.
+	FeeShortfall float64
+
+	// TotalShortfall is the sum of price and fee shortfall.
+	TotalShortfall float64
+
+	AvgSlippageBps float64
+	MaxSlippageBps float64
+	AvgDelaySec    float64
+	MaxDelaySec    float64
+
+	Matches           []FillMatch    `csv:"-"`
+	UnmatchedLive     []broker.Order `csv:"-"`
+	UnmatchedBacktest []broker.Order `csv:"-"`
+}
+
+// NewShortfallReport matches live fills to backtest fills and reports the differences.
+// Only filled orders are considered. Each live fill is matched to the unmatched backtest fill
+// of the same asset and side that is nearest in time, provided it is within tolerance.
+// Returns nil if there are no filled orders to reconcile.
+func NewShortfallReport(live, simulated []broker.Order, tolerance time.Duration) *ShortfallReport {
+	live, simulated = filledOrders(live), filledOrders(simulated)
+	if len(live) == 0 && len(simulated) == 0 {
+		return nil
+	}
+
+	var report ShortfallReport
+	matched := make([]bool, len(simulated))
+
+	for _, l := range live {
+		best := -1
+		var bestDelay time.Duration
+		for j, b := range simulated {
+			if matched[j] || b.Side != l.Side || b.Asset != l.Asset {
+				continue
+			}
+			delay := absDuration(l.FilledAt.Sub(b.FilledAt))
+			if delay > tolerance {
+				continue
+			}
+			if best < 0 || delay < bestDelay {
+				best, bestDelay = j, delay
+			}
+		}
+		if best < 0 {
+			report.UnmatchedLive = append(report.UnmatchedLive, l)
+			continue
+		}
+		matched[best] = true
+		report.Matches = append(report.Matches, newFillMatch(l, simulated[best]))
+	}
+
+	for j := range simulated {
+		if !matched[j] {
+			report.UnmatchedBacktest = append(report.UnmatchedBacktest, simulated[j])
+		}
+	}
+
+	report.MatchedCount = len(report.Matches)
+	report.UnmatchedLiveCount = len(report.UnmatchedLive)
+	report.UnmatchedBacktestCount = len(report.UnmatchedBacktest)
+
+	if report.MatchedCount == 0 {
+		return &report
+	}
+
+	slippage := make([]float64, report.MatchedCount)
+	delays := make([]float64, report.MatchedCount)
+	report.MaxSlippageBps = report.Matches[0].SlippageBps
+	for i, m := range report.Matches {
+		report.PriceShortfall += m.PriceCost
+		report.FeeShortfall += m.FeeCost
+		slippage[i] = m.SlippageBps
+		delays[i] = m.Delay.Seconds()
+		report.MaxSlippageBps = math.Max(report.MaxSlippageBps, m.SlippageBps)
+		report.MaxDelaySec = math.Max(report.MaxDelaySec, math.Abs(delays[i]))
+	}
+	report.TotalShortfall = report.PriceShortfall + report.FeeShortfall
+	report.AvgSlippageBps = stat.Mean(slippage, nil)
+	report.AvgDelaySec = stat.Mean(delays, nil)
+
+	return &report
+}
+
+// CostEstimate holds cost model parameters calibrated from live fills.
+// Fields correspond to those of backtest.PerpCoster.
+type CostEstimate struct {
+	SpreadPct      decimal.Decimal
+	SlippagePct    decimal.Decimal
+	TransactionPct decimal.Decimal
+}
+
+// EstimatePerpCost suggests cost model parameters that would have reproduced the live fills.
+// The base spread and slippage are those of the cost model used to generate the backtest,
+// since backtest fills already include these costs.
+//
+// The adverse price difference of each live fill from the implied market price is split into:
+//
+// - SpreadPct: twice the median adverse difference, the cost paid by a typical fill
+//
+// - SlippagePct: the mean less the median adverse difference, the additional cost of outliers
+//
+// TransactionPct is the total live fees divided by the total live notional.
+// Funding cannot be observed from fills so is not estimated.
+func EstimatePerpCost(report *ShortfallReport, baseSpreadPct, baseSlippagePct decimal.Decimal) CostEstimate {
+	estimate := CostEstimate{
+		SpreadPct:   baseSpreadPct,
+		SlippagePct: baseSlippagePct,
+	}
+	if report == nil || report.MatchedCount == 0 {
+		return estimate
+	}
+
+	baseCost := baseSlippagePct.Add(baseSpreadPct.Div(decimal.NewFromInt(2))).InexactFloat64()
+	adverse := make([]float64, report.MatchedCount)
+	var fees, notional float64
+	for i, m := range report.Matches {
+		adverse[i] = baseCost + m.SlippageBps/10000
+		fees += m.Live.Fee.InexactFloat64()
+		notional += m.Live.FilledPrice.Mul(m.Live.FilledSize).InexactFloat64()
+	}
+	sort.Float64s(adverse)
+	median := stat.Quantile(0.5, stat.Empirical, adverse, nil)
+	mean := stat.Mean(adverse, nil)
+
+	estimate.SpreadPct = decimal.NewFromFloat(math.Max(0, 2*median))
+	estimate.SlippagePct = decimal.NewFromFloat(math.Max(0, mean-math.Max(0, median)))
+	if notional > 0 {
+		estimate.TransactionPct = decimal.NewFromFloat(fees / notional)
+	}
+
+	return estimate
+}
+
+// PrintShortfallSummary prints a summary of the shortfall report to stdout.
+func PrintShortfallSummary(r *ShortfallReport) {
+	if r == nil {
+		println("No fills to reconcile")
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(_shortfallReportHeader)
+	table.Append([]string{
+		strconv.Itoa(r.MatchedCount),
+		strconv.Itoa(r.UnmatchedLiveCount),
+		strconv.Itoa(r.UnmatchedBacktestCount),
+		fmt.Sprintf("%.2f", r.AvgSlippageBps),
+		fmt.Sprintf("%.2fs", r.AvgDelaySec),
+		fmt.Sprintf("%.2f", r.PriceShortfall),
+		fmt.Sprintf("%.2f", r.FeeShortfall),
+		fmt.Sprintf("%.2f", r.TotalShortfall),
+	})
+	table.Render()
+}
+
+func newFillMatch(live, simulated broker.Order) FillMatch {
+	diff := live.FilledPrice.Sub(simulated.FilledPrice)
+	if live.Side == broker.Sell {
+		diff = diff.Neg()
+	}
+
+	match := FillMatch{
+		Live:      live,
+		Backtest:  simulated,
+		PriceDiff: diff.InexactFloat64(),
+		PriceCost: diff.Mul(live.FilledSize).InexactFloat64(),
+		FeeCost:   live.Fee.Sub(simulated.Fee).InexactFloat64(),
+		Delay:     live.FilledAt.Sub(simulated.FilledAt),
+	}
+	if !simulated.FilledPrice.IsZero() {
+		match.SlippageBps = diff.Div(simulated.FilledPrice).InexactFloat64() * 10000
+	}
+	return match
+}
+
+func filledOrders(orders []broker.Order) []broker.Order {
+	filled := make([]broker.Order, 0, len(orders))
+	for _, order := range orders {
+		if !order.FilledAt.IsZero() {
+			filled = append(filled, order)
+		}
+	}
+	sort.SliceStable(filled, func(i, j int) bool {
+		return filled[i].FilledAt.Before(filled[j].FilledAt)
+	})
+	return filled
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package perf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestNewShortfallReport(t *testing.T) {
+	asset := market.NewAsset("BTCUSD")
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	fill := func(side broker.OrderSide, at time.Duration, price, fee float64) broker.Order {
+		return broker.Order{
+			Asset:       asset,
+			Side:        side,
+			FilledAt:    start.Add(at),
+			FilledPrice: dec.New(price),
+			FilledSize:  dec.New(2),
+			Fee:         dec.New(fee),
+		}
+	}
+
+	sim := []broker.Order{
+		fill(broker.Buy, 0, 100, 0.1),
+		fill(broker.Sell, time.Hour, 110, 0.1),
+		fill(broker.Buy, 2*time.Hour, 100, 0.1),
+		{Asset: asset, Side: broker.Sell}, // Not filled so ignored
+	}
+	live := []broker.Order{
+		fill(broker.Buy, time.Second, 101, 0.2),
+		fill(broker.Sell, time.Hour+2*time.Second, 109, 0.2),
+		fill(broker.Sell, 5*time.Hour, 100, 0.2),
+	}
+
+	act := NewShortfallReport(live, sim, time.Minute)
+	require.NotNil(t, act)
+	assert.Equal(t, 2, act.MatchedCount)
+	assert.Equal(t, 1, act.UnmatchedLiveCount)
+	assert.Equal(t, 1, act.UnmatchedBacktestCount)
+
+	assert.InDelta(t, 1.0, act.Matches[0].PriceDiff, 0.0001)
+	assert.InDelta(t, 100.0, act.Matches[0].SlippageBps, 0.0001)
+	assert.InDelta(t, 1.0, act.Matches[1].PriceDiff, 0.0001)
+	assert.Equal(t, 2*time.Second, act.Matches[1].Delay)
+
+	assert.InDelta(t, 4.0, act.PriceShortfall, 0.0001)
+	assert.InDelta(t, 0.2, act.FeeShortfall, 0.0001)
+	assert.InDelta(t, 4.2, act.TotalShortfall, 0.0001)
+	assert.InDelta(t, 1.5, act.AvgDelaySec, 0.0001)
+
+	assert.Nil(t, NewShortfallReport(nil, nil, time.Minute))
+}
+
+func TestEstimatePerpCost(t *testing.T) {
+	give := &ShortfallReport{
+		MatchedCount: 3,
+		Matches: []FillMatch{
+			{SlippageBps: 10, Live: broker.Order{FilledPrice: dec.New(100), FilledSize: dec.New(1), Fee: dec.New(0.1)}},
+			{SlippageBps: 10, Live: broker.Order{FilledPrice: dec.New(100), FilledSize: dec.New(1), Fee: dec.New(0.1)}},
+			{SlippageBps: 40, Live: broker.Order{FilledPrice: dec.New(100), FilledSize: dec.New(1), Fee: dec.New(0.1)}},
+		},
+	}
+	act := EstimatePerpCost(give, dec.New(0), dec.New(0))
+	assert.InDelta(t, 0.002, act.SpreadPct.InexactFloat64(), 0.000001)
+	assert.InDelta(t, 0.001, act.SlippagePct.InexactFloat64(), 0.000001)
+	assert.InDelta(t, 0.001, act.TransactionPct.InexactFloat64(), 0.000001)
+
+	// Backtest fills already include the base costs
+	act = EstimatePerpCost(give, dec.New(0.002), dec.New(0))
+	assert.InDelta(t, 0.004, act.SpreadPct.InexactFloat64(), 0.000001)
+}
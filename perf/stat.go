@@ -24,28 +24,45 @@ const (
 // SharpeRatio is the annualised value using a daily risk free rate and daily returns.
 // Param daily is the percentage daily returns from the portfolio.
 func SharpeRatio(daily []float64, riskFreeRate float64) float64 {
+	return SharpeRatioWithFactor(daily, riskFreeRate, DailyToAnnualFactor)
+}
+
+// SharpeRatioWithFactor is the annualised Sharpe Ratio using the given number of daily periods in a year,
+// for example the sessions per year of a trading calendar.
+func SharpeRatioWithFactor(daily []float64, riskFreeRate, annualFactor float64) float64 {
 	xr := make([]float64, len(daily)) // Excess returns
 	for i := range daily {
 		xr[i] = daily[i] - riskFreeRate
 	}
 
-	mxr := stat.Mean(xr, nil)                   // Mean excess returns
-	sd := stat.StdDev(xr, nil)                  // SD excess returns
-	dsr := mxr / sd                             // Daily Sharpe
-	return dsr * math.Sqrt(DailyToAnnualFactor) // Scale daily to annual
+	mxr := stat.Mean(xr, nil)            // Mean excess returns
+	sd := stat.StdDev(xr, nil)           // SD excess returns
+	dsr := mxr / sd                      // Daily Sharpe
+	return dsr * math.Sqrt(annualFactor) // Scale daily to annual
 }
 
 // HistVolAnn is the annualized historic volatility of daily returns.
 func HistVolAnn(daily []float64) float64 {
+	return HistVolAnnWithFactor(daily, DailyToAnnualFactor)
+}
+
+// HistVolAnnWithFactor is the annualized historic volatility using the given number of daily periods in a year.
+func HistVolAnnWithFactor(daily []float64, annualFactor float64) float64 {
 	sd := stat.StdDev(daily, nil)
-	return sd * math.Sqrt(DailyToAnnualFactor)
+	return sd * math.Sqrt(annualFactor)
 }
 
 // CAGR Compound Annual Growth Rate
 func CAGR(initial, final float64, days int) float64 {
+	return CAGRWithFactor(initial, final, days, 365)
+}
+
+// CAGRWithFactor is the Compound Annual Growth Rate over a number of periods, using the given number of periods
+// in a year, for example the sessions elapsed and the sessions per year of a trading calendar.
+func CAGRWithFactor(initial, final float64, periods int, annualFactor float64) float64 {
 	growthRate := (final - initial) / initial
 	x := 1 + growthRate
-	y := 365.0 / float64(days)
+	y := annualFactor / float64(periods)
 	return math.Pow(x, y) - 1
 }
 
@@ -19,6 +19,12 @@ func TestCAGR(t *testing.T) {
 	assert.Equal(t, want, num.Round2DP(act))
 }
 
+func TestCAGRWithFactor(t *testing.T) {
+	// 126 sessions is half a year of 252 sessions
+	act := CAGRWithFactor(1000, 1100, 126, 252)
+	assert.Equal(t, 0.21, num.Round2DP(act))
+}
+
 func TestKellyCriterion(t *testing.T) {
 	giveProfitFactor := 1.6
 	giveWinP := 0.7
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/gou/num"
 )
 
@@ -37,3 +38,27 @@ func ReduceEOD(curve broker.EquitySeries) broker.EquitySeries {
 	}
 	return reduced
 }
+
+// ReduceSessionClose filters the equity curve to the last value in each session of the trading calendar.
+// Values are grouped by the trading date of their session, so the evening values of a session that opens
+// the day before count towards the next trading day. Values recorded while the market is closed,
+// including exactly at the close, are ignored.
+func ReduceSessionClose(curve broker.EquitySeries, cal *calendar.Calendar) broker.EquitySeries {
+	last := make(map[int64]broker.Timestamp)
+	for k := range curve {
+		session, ok := cal.Session(k.Time())
+		if !ok {
+			continue
+		}
+		date := session.Date.Unix()
+		if prev, ok := last[date]; !ok || k > prev {
+			last[date] = k
+		}
+	}
+
+	reduced := make(broker.EquitySeries, len(last))
+	for _, k := range last {
+		reduced[k] = curve[k]
+	}
+	return reduced
+}
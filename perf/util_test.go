@@ -9,6 +9,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/calendar"
 	"github.com/thecolngroup/gou/dec"
 )
 
@@ -40,3 +41,75 @@ func TestReduceEoD(t *testing.T) {
 	act := ReduceEOD(give)
 	assert.Equal(t, want, act)
 }
+
+func TestReduceSessionClose(t *testing.T) {
+	// at is a wall clock time in the location of the calendar
+	type at struct {
+		month     time.Month
+		day, hour int
+		min       int
+	}
+	tests := []struct {
+		name string
+		cal  *calendar.Calendar
+		give map[at]float64
+		want []at
+	}{
+		{
+			name: "nyse",
+			cal:  calendar.NewNYSECalendar(),
+			give: map[at]float64{
+				{time.April, 14, 9, 30}:  10,
+				{time.April, 14, 15, 30}: 11, // Last in Thursday session
+				{time.April, 14, 16, 0}:  12, // At close so excluded
+				{time.April, 14, 16, 30}: 13, // After close
+				{time.April, 15, 12, 0}:  14, // Good Friday
+				{time.April, 18, 9, 30}:  15, // Last in Monday session
+			},
+			want: []at{{time.April, 14, 15, 30}, {time.April, 18, 9, 30}},
+		},
+		{
+			name: "cme opens the evening before",
+			cal:  calendar.NewCMEGlobexCalendar(),
+			give: map[at]float64{
+				{time.May, 1, 18, 0}:  10, // Sunday evening in Monday session
+				{time.May, 2, 15, 59}: 11, // Last in Monday session
+				{time.May, 2, 16, 0}:  12, // At close so excluded
+				{time.May, 2, 16, 30}: 13, // Daily break
+				{time.May, 2, 17, 0}:  14, // Monday evening in Tuesday session
+				{time.May, 3, 10, 0}:  15, // Last in Tuesday session
+			},
+			want: []at{{time.May, 2, 15, 59}, {time.May, 3, 10, 0}},
+		},
+		{
+			name: "fx closed at the weekend",
+			cal:  calendar.NewFXCalendar(),
+			give: map[at]float64{
+				{time.May, 6, 16, 59}: 10, // Last in Friday session
+				{time.May, 6, 17, 0}:  11, // At close so excluded
+				{time.May, 7, 12, 0}:  12, // Saturday
+				{time.May, 8, 17, 0}:  13, // Sunday evening in Monday session
+				{time.May, 8, 23, 0}:  14, // Last in Monday session so far
+			},
+			want: []at{{time.May, 6, 16, 59}, {time.May, 8, 23, 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := func(a at) broker.Timestamp {
+				return broker.Timestamp(time.Date(2022, a.month, a.day, a.hour, a.min, 0, 0, tt.cal.Location).UnixMilli())
+			}
+			give := make(broker.EquitySeries, len(tt.give))
+			for a, v := range tt.give {
+				give[ts(a)] = dec.New(v)
+			}
+			want := make(broker.EquitySeries, len(tt.want))
+			for _, a := range tt.want {
+				want[ts(a)] = dec.New(tt.give[a])
+			}
+
+			act := ReduceSessionClose(give, tt.cal)
+			assert.Equal(t, want, act)
+		})
+	}
+}
@@ -42,3 +42,21 @@ func WriteEquitySeriesToCSV(filename string, series broker.EquitySeries) error {
 	}
 	return csv.WriteToCSV(filename, rows)
 }
+
+type accountSeriesRow struct {
+	Time     time.Time              `csv:"time"`
+	Snapshot broker.AccountSnapshot `csv:",inline"`
+}
+
+// WriteAccountSeriesToCSV writes a series of account snapshots to a CSV file.
+func WriteAccountSeriesToCSV(filename string, series broker.AccountSeries) error {
+	rows := make([]accountSeriesRow, len(series))
+	ks := series.SortKeys()
+	for i := 0; i < len(ks); i++ {
+		rows[i] = accountSeriesRow{
+			Time:     ks[i].Time(),
+			Snapshot: series[ks[i]],
+		}
+	}
+	return csv.WriteToCSV(filename, rows)
+}
@@ -0,0 +1,108 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+// URIScheme is the scheme of a store URI.
+const URIScheme = "store"
+
+// ErrInvalidURI is returned when a store URI cannot be parsed.
+var ErrInvalidURI = errors.New("invalid store uri")
+
+// Query selects a range of klines in the series of an asset and interval.
+type Query struct {
+	Asset    market.Asset
+	Interval time.Duration
+
+	// From is the inclusive start of the range. Zero is unbounded.
+	From time.Time
+
+	// To is the exclusive end of the range. Zero is unbounded.
+	To time.Time
+}
+
+// IsURI returns true if s has the store URI scheme.
+func IsURI(s string) bool {
+	return strings.HasPrefix(strings.ToLower(s), URIScheme+"://")
+}
+
+// ParseURI parses a store URI of the form store://asset/interval?from=time&to=time into a Query,
+// e.g. store://btcusdt/1h?from=2021-01-01&to=2021-04-01.
+// Interval is a duration such as 15m or 4h, or a number of days such as 1d.
+// From and to are optional, each a date or an RFC3339 time.
+func ParseURI(uri string) (Query, error) {
+	var q Query
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return q, fmt.Errorf("%w: %s", ErrInvalidURI, err)
+	}
+	if !strings.EqualFold(u.Scheme, URIScheme) {
+		return q, fmt.Errorf("%w: scheme must be '%s'", ErrInvalidURI, URIScheme)
+	}
+	if u.Host == "" {
+		return q, fmt.Errorf("%w: asset is required", ErrInvalidURI)
+	}
+	q.Asset = market.NewAsset(u.Host)
+
+	if q.Interval, err = parseInterval(strings.Trim(u.Path, "/")); err != nil {
+		return q, fmt.Errorf("%w: %s", ErrInvalidURI, err)
+	}
+
+	values := u.Query()
+	for k := range values {
+		if k != "from" && k != "to" {
+			return q, fmt.Errorf("%w: '%s' is not a valid query key", ErrInvalidURI, k)
+		}
+	}
+	if q.From, err = parseTime(values.Get("from")); err != nil {
+		return q, fmt.Errorf("%w: %s", ErrInvalidURI, err)
+	}
+	if q.To, err = parseTime(values.Get("to")); err != nil {
+		return q, fmt.Errorf("%w: %s", ErrInvalidURI, err)
+	}
+	return q, nil
+}
+
+// parseInterval parses a positive duration, extended with a day unit.
+func parseInterval(s string) (time.Duration, error) {
+	var interval time.Duration
+	if strings.HasSuffix(s, "d") {
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid interval", s)
+		}
+		interval = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if interval, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("'%s' is not a valid interval", s)
+		}
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("'%s' is not a valid interval", s)
+	}
+	return interval, nil
+}
+
+// parseTime parses a date or an RFC3339 time. Empty returns the zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name string
+		give string
+		want Query
+		err  error
+	}{
+		{
+			name: "asset and interval",
+			give: "store://btcusdt/1h",
+			want: Query{Asset: market.NewAsset("btcusdt"), Interval: time.Hour},
+		},
+		{
+			name: "date range",
+			give: "store://btcusdt/15m?from=2021-01-01&to=2021-04-01",
+			want: Query{
+				Asset:    market.NewAsset("btcusdt"),
+				Interval: 15 * time.Minute,
+				From:     time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+				To:       time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "days and time",
+			give: "store://eurusd/1d?from=2021-01-01T12:00:00Z",
+			want: Query{
+				Asset:    market.NewAsset("eurusd"),
+				Interval: 24 * time.Hour,
+				From:     time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "wrong scheme",
+			give: "file://btcusdt/1h",
+			err:  ErrInvalidURI,
+		},
+		{
+			name: "missing interval",
+			give: "store://btcusdt",
+			err:  ErrInvalidURI,
+		},
+		{
+			name: "invalid time",
+			give: "store://btcusdt/1h?from=yesterday",
+			err:  ErrInvalidURI,
+		},
+		{
+			name: "unknown key",
+			give: "store://btcusdt/1h?since=2021-01-01",
+			err:  ErrInvalidURI,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			act, err := ParseURI(tt.give)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, act)
+		})
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+// Package store provides a local market data store of klines in an embedded SQLite database.
+// Klines are ingested from any market.KlineReader and keyed by asset, interval and start time,
+// so that re-ingesting overlapping files only adds the klines that are new.
+// The database is a single file, and the SQLite driver is pure Go so no cgo toolchain is required.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/thecolngroup/alphakit/market"
+
+	// Registers the pure Go "sqlite" database driver.
+	_ "modernc.org/sqlite"
+)
+
+const _ingestBatchSize = 10000
+
+const _schema = `
+CREATE TABLE IF NOT EXISTS klines (
+	asset                  TEXT    NOT NULL,
+	interval               INTEGER NOT NULL,
+	start                  INTEGER NOT NULL,
+	o                      TEXT    NOT NULL,
+	h                      TEXT    NOT NULL,
+	l                      TEXT    NOT NULL,
+	c                      TEXT    NOT NULL,
+	volume                 REAL    NOT NULL,
+	quote_volume           REAL    NOT NULL,
+	trade_count            INTEGER NOT NULL,
+	taker_buy_volume       REAL    NOT NULL,
+	taker_buy_quote_volume REAL    NOT NULL,
+	synthetic              INTEGER NOT NULL DEFAULT 0,
+	after_gap              INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (asset, interval, start)
+) WITHOUT ROWID;
+`
+
+const _columns = `start, o, h, l, c, volume, quote_volume, trade_count, taker_buy_volume, taker_buy_quote_volume, synthetic, after_gap`
+
+// _addedColumns are the columns added to the schema after its first release.
+// They are added to an existing database when it is opened, and are false for the klines already stored.
+var _addedColumns = []string{
+	`synthetic INTEGER NOT NULL DEFAULT 0`,
+	`after_gap INTEGER NOT NULL DEFAULT 0`,
+}
+
+var _ market.KlineReadCloser = (*KlineReader)(nil)
+
+// ErrInvalidInterval is returned when a series interval is not positive.
+var ErrInvalidInterval = errors.New("invalid interval")
+
+// Store is a market data store backed by an SQLite database file.
+// A Store is safe for concurrent use by multiple goroutines.
+type Store struct {
+	db *sql.DB
+}
+
+// Span summarizes the klines stored for a series.
+type Span struct {
+	First time.Time
+	Last  time.Time
+	Count int
+}
+
+// Open opens the store in the given database file, creating the file if it does not exist.
+func Open(filename string) (*Store, error) {
+	// SQLite allows a single writer, so wait for a concurrent write to finish rather than fail
+	db, err := sql.Open("sqlite", filename+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(_schema); err != nil {
+		//nolint:errcheck // Already returning the schema error
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		//nolint:errcheck // Already returning the migration error
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// migrate adds the columns missing from a database created with an earlier schema.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('klines')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, column := range _addedColumns {
+		if existing[strings.Fields(column)[0]] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE klines ADD COLUMN ` + column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Ingest reads the reader to io.EOF and stores each kline in the series of the asset and interval.
+// Klines with the same start as a stored kline are skipped, so ingestion can be repeated as new data arrives.
+// Returns the number of klines added.
+func (s *Store) Ingest(asset market.Asset, interval time.Duration, reader market.KlineReader) (int, error) {
+	if interval <= 0 {
+		return 0, ErrInvalidInterval
+	}
+	symbol := normalizeSymbol(asset)
+
+	var added int
+	batch := make([]market.Kline, 0, _ingestBatchSize)
+	for {
+		k, err := reader.Read()
+		if err != nil && err != io.EOF {
+			return added, err
+		}
+		if err == nil {
+			batch = append(batch, k)
+		}
+		if len(batch) == _ingestBatchSize || (err == io.EOF && len(batch) > 0) {
+			n, insertErr := s.insert(symbol, interval, batch)
+			added += n
+			if insertErr != nil {
+				return added, insertErr
+			}
+			batch = batch[:0]
+		}
+		if err == io.EOF {
+			return added, nil
+		}
+	}
+}
+
+// insert stores a batch of klines in a single transaction.
+func (s *Store) insert(symbol string, interval time.Duration, klines []market.Kline) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	//nolint:errcheck // Rollback after commit is a no-op
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR IGNORE INTO klines (asset, interval, ` + _columns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var added int
+	for _, k := range klines {
+		res, err := stmt.Exec(symbol, int64(interval), k.Start.UnixNano(),
+			k.O.String(), k.H.String(), k.L.String(), k.C.String(),
+			k.Volume, k.QuoteVolume, k.TradeCount, k.TakerBuyVolume, k.TakerBuyQuoteVolume, k.Synthetic, k.AfterGap)
+		if err != nil {
+			return 0, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// Span returns the first and last start time and the number of klines stored for the asset and interval.
+// A series with no klines returns a zero Span.
+func (s *Store) Span(asset market.Asset, interval time.Duration) (Span, error) {
+	var span Span
+	var first, last sql.NullInt64
+	row := s.db.QueryRow(`SELECT MIN(start), MAX(start), COUNT(*) FROM klines WHERE asset = ? AND interval = ?`,
+		normalizeSymbol(asset), int64(interval))
+	if err := row.Scan(&first, &last, &span.Count); err != nil {
+		return span, err
+	}
+	if span.Count > 0 {
+		span.First = time.Unix(0, first.Int64).UTC()
+		span.Last = time.Unix(0, last.Int64).UTC()
+	}
+	return span, nil
+}
+
+// NewKlineReader returns a reader of the klines matching the query in chronological order.
+// The reader holds a database cursor and must be closed.
+func (s *Store) NewKlineReader(q Query) (*KlineReader, error) {
+	if q.Interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	query := `SELECT ` + _columns + ` FROM klines WHERE asset = ? AND interval = ?`
+	args := []any{normalizeSymbol(q.Asset), int64(q.Interval)}
+	if !q.From.IsZero() {
+		query += ` AND start >= ?`
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		query += ` AND start < ?`
+		args = append(args, q.To.UnixNano())
+	}
+	query += ` ORDER BY start`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &KlineReader{rows: rows}, nil
+}
+
+// ReadKlines returns the klines matching the query in chronological order.
+func (s *Store) ReadKlines(q Query) ([]market.Kline, error) {
+	reader, err := s.NewKlineReader(q)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return reader.ReadAll()
+}
+
+// KlineReader reads klines from a store query.
+type KlineReader struct {
+	rows *sql.Rows
+}
+
+// Read returns the next kline, or io.EOF when the query has no more results.
+func (r *KlineReader) Read() (market.Kline, error) {
+	var k market.Kline
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return k, err
+		}
+		return k, io.EOF
+	}
+
+	var start int64
+	var o, h, l, c string
+	if err := r.rows.Scan(&start, &o, &h, &l, &c,
+		&k.Volume, &k.QuoteVolume, &k.TradeCount, &k.TakerBuyVolume, &k.TakerBuyQuoteVolume, &k.Synthetic, &k.AfterGap); err != nil {
+		return k, err
+	}
+	k.Start = time.Unix(0, start).UTC()
+	prices := []*decimal.Decimal{&k.O, &k.H, &k.L, &k.C}
+	for i, v := range []string{o, h, l, c} {
+		price, err := decimal.NewFromString(v)
+		if err != nil {
+			return market.Kline{}, fmt.Errorf("%w: %s", market.ErrInvalidPriceFormat, v)
+		}
+		*prices[i] = price
+	}
+	return k, nil
+}
+
+// ReadAll reads all the remaining klines.
+func (r *KlineReader) ReadAll() ([]market.Kline, error) {
+	var klines []market.Kline
+	for {
+		k, err := r.Read()
+		if err == io.EOF {
+			return klines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		klines = append(klines, k)
+	}
+}
+
+// Close releases the database cursor.
+func (r *KlineReader) Close() error {
+	return r.rows.Close()
+}
+
+// normalizeSymbol returns the upper case symbol so that lookups are case insensitive.
+func normalizeSymbol(asset market.Asset) string {
+	return strings.ToUpper(asset.Symbol)
+}
@@ -0,0 +1,143 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package store
+
+import (
+	"database/sql"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func openForStoreTest(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "market.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func ingestForStoreTest(t *testing.T, s *Store, asset market.Asset, klines []market.Kline) int {
+	t.Helper()
+	reader, err := market.SliceKlineSource(klines).Open()
+	require.NoError(t, err)
+	added, err := s.Ingest(asset, time.Hour, reader)
+	require.NoError(t, err)
+	return added
+}
+
+func assertKlinesEqual(t *testing.T, want, act []market.Kline) {
+	t.Helper()
+	require.Len(t, act, len(want))
+	for i := range want {
+		assert.True(t, want[i].Start.Equal(act[i].Start), "start %d", i)
+		assert.True(t, want[i].O.Equal(act[i].O), "open %d", i)
+		assert.True(t, want[i].H.Equal(act[i].H), "high %d", i)
+		assert.True(t, want[i].L.Equal(act[i].L), "low %d", i)
+		assert.True(t, want[i].C.Equal(act[i].C), "close %d", i)
+		assert.Equal(t, want[i].Volume, act[i].Volume, "volume %d", i)
+		assert.Equal(t, want[i].QuoteVolume, act[i].QuoteVolume, "quote volume %d", i)
+		assert.Equal(t, want[i].TradeCount, act[i].TradeCount, "trade count %d", i)
+		assert.Equal(t, want[i].TakerBuyVolume, act[i].TakerBuyVolume, "taker buy volume %d", i)
+		assert.Equal(t, want[i].TakerBuyQuoteVolume, act[i].TakerBuyQuoteVolume, "taker buy quote volume %d", i)
+		assert.Equal(t, want[i].Synthetic, act[i].Synthetic, "synthetic %d", i)
+		assert.Equal(t, want[i].AfterGap, act[i].AfterGap, "after gap %d", i)
+	}
+}
+
+func TestStore_Ingest(t *testing.T) {
+	want, err := market.ReadKlinesFromCSV("../market/testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	want[1].Synthetic = true
+	want[2].AfterGap = true
+	btc := market.NewAsset("BTCUSDT")
+	s := openForStoreTest(t)
+
+	// Ingest the first 1000 klines, then the whole series overlapping them, then again with nothing new
+	assert.Equal(t, 1000, ingestForStoreTest(t, s, btc, want[:1000]))
+	assert.Equal(t, len(want)-1000, ingestForStoreTest(t, s, btc, want))
+	assert.Equal(t, 0, ingestForStoreTest(t, s, btc, want))
+
+	span, err := s.Span(market.NewAsset("btcusdt"), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, want[0].Start.Equal(span.First))
+	assert.True(t, want[len(want)-1].Start.Equal(span.Last))
+	assert.Equal(t, len(want), span.Count)
+
+	act, err := s.ReadKlines(Query{Asset: btc, Interval: time.Hour})
+	require.NoError(t, err)
+	assertKlinesEqual(t, want, act)
+
+	// Other series are independent
+	span, err = s.Span(btc, 4*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, Span{}, span)
+}
+
+func TestOpen_Migrate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "market.db")
+	db, err := sql.Open("sqlite", filename)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE klines (
+		asset TEXT NOT NULL, interval INTEGER NOT NULL, start INTEGER NOT NULL,
+		o TEXT NOT NULL, h TEXT NOT NULL, l TEXT NOT NULL, c TEXT NOT NULL,
+		volume REAL NOT NULL, quote_volume REAL NOT NULL, trade_count INTEGER NOT NULL,
+		taker_buy_volume REAL NOT NULL, taker_buy_quote_volume REAL NOT NULL,
+		PRIMARY KEY (asset, interval, start)) WITHOUT ROWID`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO klines VALUES ('BTCUSDT', ?, 0, '1', '2', '0.5', '1.5', 10, 15, 3, 4, 6)`, int64(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	// A database created before the gap flag columns were added is migrated on open
+	s, err := Open(filename)
+	require.NoError(t, err)
+	defer s.Close()
+	act, err := s.ReadKlines(Query{Asset: market.NewAsset("BTCUSDT"), Interval: time.Hour})
+	require.NoError(t, err)
+	require.Len(t, act, 1)
+	assert.Equal(t, "1.5", act[0].C.String())
+	assert.False(t, act[0].Synthetic)
+
+	want := []market.Kline{{Start: time.Unix(3600, 0).UTC(), O: act[0].C, H: act[0].C, L: act[0].C, C: act[0].C, Synthetic: true}}
+	assert.Equal(t, 1, ingestForStoreTest(t, s, market.NewAsset("BTCUSDT"), want))
+	act, err = s.ReadKlines(Query{Asset: market.NewAsset("BTCUSDT"), Interval: time.Hour})
+	require.NoError(t, err)
+	assertKlinesEqual(t, want, act[1:])
+}
+
+func TestStore_NewKlineReader(t *testing.T) {
+	klines, err := market.ReadKlinesFromCSV("../market/testdata/BTCUSDT-1h-2021-Q1.csv")
+	require.NoError(t, err)
+	btc := market.NewAsset("BTCUSDT")
+	s := openForStoreTest(t)
+	ingestForStoreTest(t, s, btc, klines)
+	ingestForStoreTest(t, s, market.NewAsset("ETHUSDT"), klines[:10])
+
+	from := time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, time.February, 2, 0, 0, 0, 0, time.UTC)
+	reader, err := s.NewKlineReader(Query{Asset: btc, Interval: time.Hour, From: from, To: to})
+	require.NoError(t, err)
+	defer reader.Close()
+
+	first, err := reader.Read()
+	require.NoError(t, err)
+	assert.True(t, from.Equal(first.Start))
+
+	rest, err := reader.ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rest, 23)
+	assert.True(t, to.Add(-time.Hour).Equal(rest[22].Start))
+
+	_, err = reader.Read()
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = s.NewKlineReader(Query{Asset: btc})
+	assert.ErrorIs(t, err, ErrInvalidInterval)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package ta
+
+import (
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Indicator[market.Kline] = (*CumulativeDelta)(nil)
+var _ Indicator[market.Kline] = (*Imbalance)(nil)
+
+// CumulativeDelta is the running total of taker buy volume less taker sell volume.
+// Requires klines with TakerBuyVolume, such as those decoded from Binance data.
+// Klines without order flow data do not change the total, and the indicator is invalid until one has been received.
+type CumulativeDelta struct {
+	cum    float64
+	flow   bool
+	series []float64
+}
+
+// NewCumulativeDelta creates a new CumulativeDelta indicator.
+func NewCumulativeDelta() *CumulativeDelta {
+	return &CumulativeDelta{}
+}
+
+// Update updates the indicator with the next value(s).
+func (ind *CumulativeDelta) Update(prices ...market.Kline) error {
+
+	for i := range prices {
+		ind.flow = ind.flow || hasOrderFlow(prices[i])
+		ind.cum += Delta(prices[i])
+		ind.series = append(ind.series, ind.cum)
+	}
+
+	return nil
+}
+
+// Valid returns true if the indicator is valid.
+// An indicator is invalid if it hasn't received a kline with order flow data yet.
+func (ind *CumulativeDelta) Valid() bool {
+	return ind.flow
+}
+
+// Value returns the current value of the indicator.
+func (ind *CumulativeDelta) Value() float64 {
+	return Lookback(ind.series, 0)
+}
+
+// History returns the historical values of the indicator.
+func (ind *CumulativeDelta) History() []float64 {
+	return ind.series
+}
+
+// Imbalance is the ratio of delta to volume over a rolling window, in the range -1 (all taker sells)
+// to 1 (all taker buys). Klines without order flow data are excluded from the window,
+// and windows without volume have an imbalance of 0.
+type Imbalance struct {
+	// Length is the number of klines in the window.
+	Length int
+
+	buys   []float64
+	vols   []float64
+	series []float64
+}
+
+// NewImbalance creates a new Imbalance indicator over the given number of klines.
+func NewImbalance(length int) *Imbalance {
+	return &Imbalance{
+		Length: length,
+	}
+}
+
+// Update updates the indicator with the next value(s).
+func (ind *Imbalance) Update(prices ...market.Kline) error {
+
+	for i := range prices {
+		var buy, vol float64
+		if hasOrderFlow(prices[i]) {
+			buy, vol = prices[i].TakerBuyVolume, prices[i].Volume
+		}
+		ind.buys = WindowAppend(ind.buys, ind.Length-1, buy)
+		ind.vols = WindowAppend(ind.vols, ind.Length-1, vol)
+
+		buy, vol = 0, 0
+		for j := range ind.vols {
+			buy += ind.buys[j]
+			vol += ind.vols[j]
+		}
+
+		var imbalance float64
+		if vol != 0 {
+			imbalance = (2*buy - vol) / vol
+		}
+		ind.series = append(ind.series, imbalance)
+	}
+
+	return nil
+}
+
+// Valid returns true if the indicator has enough data to be calculated.
+func (ind *Imbalance) Valid() bool {
+	return len(ind.vols) >= ind.Length
+}
+
+// Value returns the current value of the indicator.
+func (ind *Imbalance) Value() float64 {
+	return Lookback(ind.series, 0)
+}
+
+// History returns the historical values of the indicator.
+func (ind *Imbalance) History() []float64 {
+	return ind.series
+}
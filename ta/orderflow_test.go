@@ -0,0 +1,58 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package ta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+)
+
+func TestCumulativeDelta(t *testing.T) {
+	give := []market.Kline{
+		{Volume: 10, TakerBuyVolume: 7},
+		{Volume: 10, TakerBuyVolume: 2},
+		{Volume: 4, TakerBuyVolume: 3},
+	}
+	want := []float64{4, -2, 0}
+
+	ind := NewCumulativeDelta()
+	require.NoError(t, ind.Update(give...))
+
+	assert.True(t, ind.Valid())
+	assert.Equal(t, want, ind.History())
+	assert.Equal(t, 0.0, ind.Value())
+}
+
+func TestCumulativeDelta_NoOrderFlow(t *testing.T) {
+	ind := NewCumulativeDelta()
+	require.NoError(t, ind.Update(market.Kline{Volume: 10}, market.Kline{Volume: 5}))
+	assert.False(t, ind.Valid())
+	assert.Equal(t, []float64{0, 0}, ind.History())
+
+	require.NoError(t, ind.Update(market.Kline{Volume: 10, TakerBuyVolume: 7}))
+	assert.True(t, ind.Valid())
+	assert.Equal(t, 4.0, ind.Value())
+}
+
+func TestImbalance(t *testing.T) {
+	give := []market.Kline{
+		{Volume: 10, TakerBuyVolume: 10},
+		{Volume: 10, TakerBuyVolume: 1},
+		{Volume: 20, TakerBuyVolume: 5},
+		{},
+		{Volume: 10},
+	}
+	want := []float64{1, 2.0 / 20, -18.0 / 30, -0.5, 0}
+
+	ind := NewImbalance(2)
+	require.NoError(t, ind.Update(give[:1]...))
+	assert.False(t, ind.Valid())
+
+	require.NoError(t, ind.Update(give[1:]...))
+	assert.True(t, ind.Valid())
+	assert.Equal(t, want, ind.History())
+}
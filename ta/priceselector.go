@@ -30,3 +30,43 @@ func OHLC4(price market.Kline) float64 {
 func Close(price market.Kline) float64 {
 	return price.C.InexactFloat64()
 }
+
+// Volume returns the base asset volume.
+func Volume(price market.Kline) float64 {
+	return price.Volume
+}
+
+// QuoteVolume returns the quote asset volume.
+func QuoteVolume(price market.Kline) float64 {
+	return price.QuoteVolume
+}
+
+// TradeCount returns the number of trades.
+func TradeCount(price market.Kline) float64 {
+	return float64(price.TradeCount)
+}
+
+// TakerBuyVolume returns the base asset volume bought by takers.
+func TakerBuyVolume(price market.Kline) float64 {
+	return price.TakerBuyVolume
+}
+
+// TakerSellVolume returns the base asset volume sold by takers, or 0 if the kline has no order flow data.
+func TakerSellVolume(price market.Kline) float64 {
+	if !hasOrderFlow(price) {
+		return 0
+	}
+	return price.Volume - price.TakerBuyVolume
+}
+
+// Delta returns the taker buy volume less the taker sell volume, or 0 if the kline has no order flow data.
+func Delta(price market.Kline) float64 {
+	return TakerBuyVolume(price) - TakerSellVolume(price)
+}
+
+// hasOrderFlow returns true if the kline has taker volumes from its price source.
+// Sources without order flow leave the taker volumes zero, which is indistinguishable
+// from a kline of only taker sells, so such klines are also treated as having no order flow data.
+func hasOrderFlow(price market.Kline) bool {
+	return price.TakerBuyVolume != 0 || price.TakerBuyQuoteVolume != 0
+}
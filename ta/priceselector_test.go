@@ -24,3 +24,14 @@ func TestClose(t *testing.T) {
 	act := Close(give)
 	assert.Equal(t, want, act)
 }
+
+func TestDelta(t *testing.T) {
+	give := market.Kline{Volume: 10, TakerBuyVolume: 7}
+	assert.Equal(t, 3.0, TakerSellVolume(give))
+	assert.Equal(t, 4.0, Delta(give))
+
+	// No order flow data
+	give = market.Kline{Volume: 10}
+	assert.Equal(t, 0.0, TakerSellVolume(give))
+	assert.Equal(t, 0.0, Delta(give))
+}
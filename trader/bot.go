@@ -4,6 +4,9 @@
 // Package trader provides an API for building trading bots.
 // A bot receives prices and execute orders with a broker.
 // Child packages offer specific bot implementations.
+//
+// Only a single asset Bot can be optimized by package optimize or run by the studyrun app.
+// A MultiAssetBot is backtested by playing a market.MultiAssetFeed to its dealers and the bot.
 package trader
 
 import (
@@ -34,6 +37,22 @@ type Bot interface {
 	Close(context.Context) error
 }
 
+// MultiAssetBot is a trading algo that trades several assets together, e.g. a portfolio or spread strategy.
+// It receives the time-aligned prices of all its assets from a market.MultiAssetFeed.
+type MultiAssetBot interface {
+	// Warmup the indicators used by the bot with historical data prior to active trading.
+	Warmup(context.Context, []market.AssetKlines) error
+
+	// Sets the dealer to be used for order execution of each asset.
+	SetDealers(map[market.Asset]broker.Dealer)
+
+	// ReceivePrices gives the bot the next prices of its assets and evaluates the algo.
+	market.MultiAssetReceiver
+
+	// Clean-up the bot before close down, e.g. close open positions.
+	Close(context.Context) error
+}
+
 // ErrInvalidConfig is returned by MakeFromConfig.
 var ErrInvalidConfig = errors.New("invalid bot config")
 
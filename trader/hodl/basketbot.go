@@ -0,0 +1,90 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package hodl
+
+import (
+	"context"
+	"sort"
+
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/trader"
+	"github.com/thecolngroup/gou/dec"
+)
+
+var _ trader.MultiAssetBot = (*BasketBot)(nil)
+
+// BasketBot implements a buy and hold algo across several assets,
+// as a reference for bots that trade from a market.MultiAssetFeed.
+// Should only be used for backtesting purposes.
+// A long position of one unit is opened in each asset on its first traded price,
+// call Close() to then sell the positions and create the trades.
+// Synthetic prices filled in by the feed are not traded.
+type BasketBot struct {
+	dealers map[market.Asset]broker.Dealer
+	bought  map[market.Asset]bool
+}
+
+// NewBasketBot returns a BasketBot trading each asset with its dealer.
+func NewBasketBot(dealers map[market.Asset]broker.Dealer) *BasketBot {
+	return &BasketBot{
+		dealers: dealers,
+		bought:  make(map[market.Asset]bool, len(dealers)),
+	}
+}
+
+// SetDealers sets the dealer to use for order execution of each asset.
+// Should only be given simulated dealers for backtesting.
+func (b *BasketBot) SetDealers(dealers map[market.Asset]broker.Dealer) {
+	b.dealers = dealers
+}
+
+// Warmup is not used.
+func (b *BasketBot) Warmup(ctx context.Context, prices []market.AssetKlines) error {
+	return nil
+}
+
+// ReceivePrices updates the algo with the next prices of the assets.
+func (b *BasketBot) ReceivePrices(ctx context.Context, prices market.AssetKlines) error {
+	if b.bought == nil {
+		b.bought = make(map[market.Asset]bool, len(b.dealers))
+	}
+	for _, asset := range sortedDealerAssets(b.dealers) {
+		price, ok := prices.Klines[asset]
+		if !ok || price.Synthetic || b.bought[asset] {
+			continue
+		}
+		order := broker.NewOrder(asset, broker.Buy, dec.New(1))
+		if _, _, err := b.dealers[asset].PlaceOrder(ctx, order); err != nil {
+			return err
+		}
+		b.bought[asset] = true
+	}
+	return nil
+}
+
+// Close closes any open positions.
+func (b *BasketBot) Close(ctx context.Context) error {
+	for _, asset := range sortedDealerAssets(b.dealers) {
+		if !b.bought[asset] {
+			continue
+		}
+		order := broker.NewOrder(asset, broker.Sell, dec.New(1))
+		order.ReduceOnly = true
+		if _, _, err := b.dealers[asset].PlaceOrder(ctx, order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedDealerAssets returns the assets in symbol order so that orders are placed deterministically.
+func sortedDealerAssets(dealers map[market.Asset]broker.Dealer) []market.Asset {
+	assets := make([]market.Asset, 0, len(dealers))
+	for asset := range dealers {
+		assets = append(assets, asset)
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i].Symbol < assets[j].Symbol })
+	return assets
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package hodl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/broker"
+	"github.com/thecolngroup/alphakit/broker/backtest"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/gou/dec"
+)
+
+// readerForBasketBotTest returns a reader of hourly klines from start with a close of 10 plus the hour.
+func readerForBasketBotTest(t *testing.T, start time.Time, hours ...int) market.KlineReader {
+	t.Helper()
+	var klines []market.Kline
+	for _, h := range hours {
+		c := dec.New(float64(10 + h))
+		klines = append(klines, market.Kline{Start: start.Add(time.Duration(h) * time.Hour), O: c, H: c, L: c, C: c, Volume: 1})
+	}
+	reader, err := market.SliceKlineSource(klines).Open()
+	require.NoError(t, err)
+	return reader
+}
+
+func TestBasketBot_ReceivePrices(t *testing.T) {
+	btc, eth := market.NewAsset("BTCUSD"), market.NewAsset("ETHUSD")
+	expOrder := broker.Order{Asset: btc, Type: broker.Market, Side: broker.Buy, Size: dec.New(1)}
+	btcDealer, ethDealer := &broker.MockDealer{}, &broker.MockDealer{}
+	btcDealer.On("PlaceOrder", context.Background(), expOrder)
+
+	// The synthetic ETH price is not traded and BTC is only bought once
+	bot := NewBasketBot(map[market.Asset]broker.Dealer{btc: btcDealer, eth: ethDealer})
+	prices := market.AssetKlines{Klines: map[market.Asset]market.Kline{btc: {}, eth: {Synthetic: true}}}
+	require.NoError(t, bot.ReceivePrices(context.Background(), prices))
+	require.NoError(t, bot.ReceivePrices(context.Background(), prices))
+	btcDealer.AssertExpectations(t)
+	btcDealer.AssertNumberOfCalls(t, "PlaceOrder", 1)
+	ethDealer.AssertNotCalled(t, "PlaceOrder")
+}
+
+func TestBasketBot_Backtest(t *testing.T) {
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	btc, eth := market.NewAsset("BTCUSD"), market.NewAsset("ETHUSD")
+
+	// ETH starts an hour after BTC and is missing hour 2, which the feed fills
+	feed := market.NewMultiAssetFeed(map[market.Asset]market.KlineReader{
+		btc: readerForBasketBotTest(t, start, 0, 1, 2, 3, 4),
+		eth: readerForBasketBotTest(t, start, 1, 3, 4),
+	})
+	feed.MissingPolicy = market.MissingFill
+
+	btcDealer, ethDealer := backtest.NewDealer(), backtest.NewDealer()
+	bot := NewBasketBot(map[market.Asset]broker.Dealer{btc: btcDealer, eth: ethDealer})
+	dealers := market.AssetReceivers{btc: btcDealer, eth: ethDealer}
+	require.NoError(t, feed.Play(context.Background(), dealers, bot))
+	require.NoError(t, bot.Close(context.Background()))
+
+	// Each asset is bought at its first close and sold at the last
+	tests := []struct {
+		dealer *backtest.Dealer
+		want   float64
+	}{
+		{btcDealer, 4},
+		{ethDealer, 3},
+	}
+	for _, tt := range tests {
+		trades, _, err := tt.dealer.ListRoundTurns(context.Background(), nil)
+		require.NoError(t, err)
+		require.Len(t, trades, 1)
+		assert.Equal(t, tt.want, trades[0].Profit.InexactFloat64())
+	}
+	assert.Len(t, ethDealer.EquityHistory(), 4)
+}
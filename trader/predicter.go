@@ -17,3 +17,14 @@ type Predicter interface {
 	// Valid indicates readiness for prediction.
 	Valid() bool
 }
+
+// TimeframePredicter is a Predicter that also uses bars of higher timeframes than the prices it receives,
+// for example to filter H1 entries with a D1 trend.
+// A bot that supports it feeds the base prices through a market.MultiTimeframeFeed configured with the declared timeframes.
+type TimeframePredicter interface {
+	Predicter
+	market.TimeframeReceiver
+
+	// Timeframes declares the higher timeframes the predicter requires.
+	Timeframes() []market.Timeframe
+}
@@ -12,6 +12,8 @@ import (
 
 var _ Bot = (*StubBot)(nil)
 
+var _ MultiAssetBot = (*StubMultiAssetBot)(nil)
+
 // StubBot is a testing double.
 type StubBot struct {
 }
@@ -38,3 +40,26 @@ func (b *StubBot) ReceivePrice(ctx context.Context, price market.Kline) error {
 func (b *StubBot) Close(ctx context.Context) error {
 	return nil
 }
+
+// StubMultiAssetBot is a testing double.
+type StubMultiAssetBot struct {
+}
+
+// SetDealers not implemented.
+func (b *StubMultiAssetBot) SetDealers(dealers map[market.Asset]broker.Dealer) {
+}
+
+// Warmup not implemented.
+func (b *StubMultiAssetBot) Warmup(ctx context.Context, prices []market.AssetKlines) error {
+	return nil
+}
+
+// ReceivePrices not implemented.
+func (b *StubMultiAssetBot) ReceivePrices(ctx context.Context, prices market.AssetKlines) error {
+	return nil
+}
+
+// Close not implemented.
+func (b *StubMultiAssetBot) Close(ctx context.Context) error {
+	return nil
+}
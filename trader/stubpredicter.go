@@ -0,0 +1,40 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package trader
+
+import (
+	"context"
+
+	"github.com/thecolngroup/alphakit/market"
+)
+
+var _ Predicter = (*StubPredicter)(nil)
+
+// StubPredicter is a test double for a predicter.
+type StubPredicter struct {
+	// Prediction is the score returned by Predict.
+	Prediction float64
+
+	// IsValid is the validity of the predicter.
+	IsValid bool
+
+	// Prices records the prices received.
+	Prices []market.Kline
+}
+
+// ReceivePrice records the price.
+func (p *StubPredicter) ReceivePrice(ctx context.Context, price market.Kline) error {
+	p.Prices = append(p.Prices, price)
+	return nil
+}
+
+// Predict returns Prediction.
+func (p *StubPredicter) Predict() float64 {
+	return p.Prediction
+}
+
+// Valid returns IsValid.
+func (p *StubPredicter) Valid() bool {
+	return p.IsValid
+}
@@ -35,6 +35,7 @@ type Bot struct {
 	Sizer     money.Sizer
 
 	dealer broker.Dealer
+	feed   *market.MultiTimeframeFeed
 }
 
 // NewBot sets default enter and exit scores and basic Risker and Sizer implementations.
@@ -106,7 +107,24 @@ func (b *Bot) Close(ctx context.Context) error {
 	return nil
 }
 
+// updateIndicators updates the Risker and Predicter with the price.
+// Synthetic klines are skipped.
+// If the Predicter is a trader.TimeframePredicter the price is routed through a feed that also delivers its higher timeframe bars.
 func (b *Bot) updateIndicators(ctx context.Context, price market.Kline) error {
+	// Synthetic klines fill gaps in the price data and would distort the indicators with flat prices
+	if price.Synthetic {
+		return nil
+	}
+	if p, ok := b.Predicter.(trader.TimeframePredicter); ok {
+		if b.feed == nil {
+			b.feed = market.NewMultiTimeframeFeed(0, &indicatorReceiver{bot: b, predicter: p}, p.Timeframes()...)
+		}
+		return b.feed.ReceivePrice(ctx, price)
+	}
+	return b.receiveIndicators(ctx, price)
+}
+
+func (b *Bot) receiveIndicators(ctx context.Context, price market.Kline) error {
 	if err := b.Risker.ReceivePrice(ctx, price); err != nil {
 		return err
 	}
@@ -277,3 +295,17 @@ func filterPositions(positions []broker.Position, asset market.Asset, side broke
 
 	return filtered
 }
+
+// indicatorReceiver receives the base prices and higher timeframe bars from the feed of a Bot.
+type indicatorReceiver struct {
+	bot       *Bot
+	predicter trader.TimeframePredicter
+}
+
+func (r *indicatorReceiver) ReceivePrice(ctx context.Context, price market.Kline) error {
+	return r.bot.receiveIndicators(ctx, price)
+}
+
+func (r *indicatorReceiver) ReceiveTimeframePrice(ctx context.Context, tf market.Timeframe, price market.Kline) error {
+	return r.predicter.ReceiveTimeframePrice(ctx, tf, price)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/thecolngroup/alphakit/broker"
 	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/trader"
 	"github.com/thecolngroup/alphakit/web"
 	"github.com/thecolngroup/gou/dec"
 )
@@ -293,3 +294,22 @@ func TestBot_getOpenPosition(t *testing.T) {
 	assert.Equal(t, act, want)
 	dealer.AssertExpectations(t)
 }
+
+func TestBot_SkipsSyntheticKlines(t *testing.T) {
+	predicter := &trader.StubPredicter{}
+	bot := NewBot()
+	bot.Predicter = predicter
+
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	prices := []market.Kline{
+		{Start: start, C: dec.New(10)},
+		{Start: start.Add(time.Hour), C: dec.New(10), Synthetic: true},
+		{Start: start.Add(2 * time.Hour), C: dec.New(12)},
+	}
+	assert.NoError(t, bot.Warmup(context.Background(), prices))
+
+	assert.Len(t, predicter.Prices, 2)
+	for _, p := range predicter.Prices {
+		assert.False(t, p.Synthetic)
+	}
+}
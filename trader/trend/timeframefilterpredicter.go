@@ -0,0 +1,79 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package trend
+
+import (
+	"context"
+
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/ta"
+	"github.com/thecolngroup/alphakit/trader"
+)
+
+var _ trader.TimeframePredicter = (*TimeframeFilterPredicter)(nil)
+
+// TimeframeFilterPredicter filters the predictions of another Predicter with the trend of a higher timeframe.
+// Long predictions pass only when the last completed higher timeframe price is above its moving average,
+// short predictions only when below. Predictions against the trend are returned as 0.
+type TimeframeFilterPredicter struct {
+	// Predicter is the base predicter that receives the base prices.
+	Predicter trader.Predicter
+
+	// Timeframe is the higher timeframe of the trend filter.
+	Timeframe market.Timeframe
+
+	// PriceSelector is the kline component to use for price. Close by default.
+	PriceSelector ta.PriceSelector
+
+	// MA is the moving average of the higher timeframe price.
+	MA ta.Indicator[float64]
+
+	price float64
+}
+
+// NewTimeframeFilterPredicter creates a new predicter with Close price selector.
+func NewTimeframeFilterPredicter(predicter trader.Predicter, tf market.Timeframe, ma ta.Indicator[float64]) *TimeframeFilterPredicter {
+	return &TimeframeFilterPredicter{
+		Predicter:     predicter,
+		Timeframe:     tf,
+		PriceSelector: ta.Close,
+		MA:            ma,
+	}
+}
+
+// Timeframes declares the higher timeframe of the trend filter.
+func (p *TimeframeFilterPredicter) Timeframes() []market.Timeframe {
+	return []market.Timeframe{p.Timeframe}
+}
+
+// ReceivePrice updates the base predicter with the next base price.
+func (p *TimeframeFilterPredicter) ReceivePrice(ctx context.Context, price market.Kline) error {
+	return p.Predicter.ReceivePrice(ctx, price)
+}
+
+// ReceiveTimeframePrice updates the trend filter with the next completed higher timeframe bar.
+func (p *TimeframeFilterPredicter) ReceiveTimeframePrice(ctx context.Context, tf market.Timeframe, price market.Kline) error {
+	if tf != p.Timeframe {
+		return nil
+	}
+	p.price = p.PriceSelector(price)
+	return p.MA.Update(p.price)
+}
+
+// Predict returns the base prediction if it agrees with the higher timeframe trend, otherwise 0.
+func (p *TimeframeFilterPredicter) Predict() float64 {
+	score := p.Predicter.Predict()
+	switch {
+	case score > 0 && p.price <= p.MA.Value():
+		return 0
+	case score < 0 && p.price >= p.MA.Value():
+		return 0
+	}
+	return score
+}
+
+// Valid returns true if the base predicter and the moving average are valid.
+func (p *TimeframeFilterPredicter) Valid() bool {
+	return p.Predicter.Valid() && p.MA.Valid()
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Coln Group Ltd
+// SPDX-License-Identifier: MIT
+
+package trend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thecolngroup/alphakit/market"
+	"github.com/thecolngroup/alphakit/ta"
+	"github.com/thecolngroup/alphakit/trader"
+	"github.com/thecolngroup/gou/dec"
+)
+
+func TestTimeframeFilterPredicter_Predict(t *testing.T) {
+	tests := []struct {
+		name      string
+		giveScore float64
+		givePrice float64
+		giveMA    float64
+		want      float64
+	}{
+		{
+			name:      "long with trend",
+			giveScore: 1,
+			givePrice: 110,
+			giveMA:    100,
+			want:      1,
+		},
+		{
+			name:      "long against trend",
+			giveScore: 1,
+			givePrice: 90,
+			giveMA:    100,
+			want:      0,
+		},
+		{
+			name:      "short with trend",
+			giveScore: -0.9,
+			givePrice: 90,
+			giveMA:    100,
+			want:      -0.9,
+		},
+		{
+			name:      "short against trend",
+			giveScore: -1,
+			givePrice: 110,
+			giveMA:    100,
+			want:      0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicter := NewTimeframeFilterPredicter(
+				&trader.StubPredicter{Prediction: tt.giveScore},
+				market.Timeframe{Unit: market.Day, Count: 1},
+				&ta.StubIndicator{Values: []float64{tt.giveMA}},
+			)
+			predicter.price = tt.givePrice
+			assert.Equal(t, tt.want, predicter.Predict())
+		})
+	}
+}
+
+func TestBot_RoutesTimeframeBarsToPredicter(t *testing.T) {
+	var base trader.StubPredicter
+	d1 := market.Timeframe{Unit: market.Day, Count: 1}
+	predicter := NewTimeframeFilterPredicter(&base, d1, &ta.StubIndicator{})
+	bot := NewBot()
+	bot.Predicter = predicter
+
+	start := time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var prices []market.Kline
+	for i := 0; i < 36; i++ {
+		c := dec.New(float64(i))
+		prices = append(prices, market.Kline{Start: start.Add(time.Duration(i) * time.Hour), O: c, H: c, L: c, C: c})
+	}
+	require.NoError(t, bot.Warmup(context.Background(), prices))
+
+	assert.Len(t, base.Prices, 36)
+	// The first day is complete, the second is not
+	assert.Equal(t, 23.0, predicter.price)
+}